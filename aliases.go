@@ -15,7 +15,7 @@ func ApplyAliases(a *AstNode, aliases map[string]string) (*AstNode, error) {
 				newArgs[0] = v
 			} else {
 				for k, v := range aliases {
-					if len(k) > 0 && k[0] == '^' && k[len(k)-1] == '$' {
+					if isRegex(k) {
 						r := regexp.MustCompile(k)
 
 						newArgs[0] = string(r.ReplaceAll([]byte(newArgs[0]), []byte(v)))
@@ -94,7 +94,7 @@ func GetAst(jsonTxt string) (*AstNode, error) {
 		// we receive something that doesn't make any sense like ge(a). The main argument case where we should
 		// treat this as a validation is an unknown operator. However, in theory the upstream generator
 		// passing us something likely means we should treat it as unsupported if we are out of date.
-		return nil, NewValidationErr(fmt.Errorf("(%s): %w", astNode.AsFilter(), err))
+		return nil, newValidationErrForAst(astNode, fmt.Errorf("(%s): %w", astNode.AsFilter(), err))
 	} else {
 		return astNode, nil
 	}
@@ -226,7 +226,7 @@ func (a *AstNode) checkValid() error {
 			}
 		}
 		if len(a.Children) < 2 {
-			return fmt.Errorf("and should have at least two children")
+			return newNodeErr(a, fmt.Errorf("and should have at least two children"))
 		}
 	case "OR":
 		for _, c := range a.Children {
@@ -236,36 +236,36 @@ func (a *AstNode) checkValid() error {
 			}
 		}
 		if len(a.Children) < 2 {
-			return fmt.Errorf("or should have at least two children")
+			return newNodeErr(a, fmt.Errorf("or should have at least two children"))
 		}
 	case "IN", "CONTAINS_ANY", "CONTAINS_ALL":
 		if len(a.Children) > 0 {
-			return fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType)))
 		}
 
 		if len(a.Args) < 2 {
-			return fmt.Errorf("insufficient number of arguments to %s", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("insufficient number of arguments to %s", strings.ToLower(a.NodeType)))
 		}
 	case "EQ", "LE", "LT", "GT", "GE", "LIKE", "ILIKE", "CONTAINS", "TEXT":
 		if len(a.Children) > 0 {
-			return fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType)))
 		}
 
 		if len(a.Args) != 2 {
-			return fmt.Errorf("operator %v should have exactly 2 arguments", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("operator %v should have exactly 2 arguments", strings.ToLower(a.NodeType)))
 
 		}
 	case "IS_NULL":
 		if len(a.Children) > 0 {
-			return fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("operator %v should not have any children", strings.ToLower(a.NodeType)))
 		}
 
 		if len(a.Args) != 1 {
-			return fmt.Errorf("operator %v should have exactly 1 argument", strings.ToLower(a.NodeType))
+			return newNodeErr(a, fmt.Errorf("operator %v should have exactly 1 argument", strings.ToLower(a.NodeType)))
 
 		}
 	default:
-		return fmt.Errorf("unsupported operator %s()", strings.ToLower(a.NodeType))
+		return newNodeErr(a, fmt.Errorf("unsupported operator %s()", strings.ToLower(a.NodeType)))
 	}
 
 	return nil
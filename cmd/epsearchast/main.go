@@ -0,0 +1,240 @@
+// Command epsearchast translates an EP-Internal-Search-AST-v3 header (or a filter such as eq(status,paid)) into the queries generated for each backend,
+// which makes it easier to debug a filter without writing a program.
+//
+// Usage:
+//
+//	epsearchast [-config config.yaml] [filter]
+//
+// If the filter isn't an argument it's read from stdin. Headers can be JSON or URL-encoded JSON (as accepted by epsearchast.GetAst), anything else is
+// parsed with epsearchast.ParseFilter.
+//
+// The config file is optional, and is an astschema.Schema (JSON or YAML). The filter is validated with the schema and aliases are applied before it's
+// translated, and each backend uses the mapping and query builder from the schema:
+//
+//	allowed_index_intersections: 4
+//	fields:
+//	  status:
+//	    operators: [eq, in]
+//	    aliases: [state]
+//	    validator: oneof=live draft
+//	    sql:
+//	      column: order_status
+//	  price:
+//	    operators: [lt, gt]
+//	    type: int64
+//
+// The exit code is 0 on success, 2 if the filter can't be parsed, 3 if it isn't valid, and 1 for any other error (e.g., a backend can't translate the filter).
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/elasticpath/epcc-search-ast-helper/es"
+	"github.com/elasticpath/epcc-search-ast-helper/gorm"
+	"github.com/elasticpath/epcc-search-ast-helper/mongo"
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	exitOk              = 0
+	exitError           = 1
+	exitParsingError    = 2
+	exitValidationError = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("epsearchast", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", "", "a JSON or YAML schema (see astschema.Schema) to validate the filter with, and to configure each backend")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	input := strings.Join(flags.Args(), " ")
+
+	if flags.NArg() == 0 {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "could not read filter from stdin: %v\n", err)
+			return exitError
+		}
+
+		input = string(b)
+	}
+
+	ast, err := getAst(strings.TrimSpace(input))
+	if err != nil {
+		return printError(stderr, err)
+	}
+
+	var s *astschema.Schema
+
+	if *configFile != "" {
+		if s, err = astschema.LoadFile(*configFile); err != nil {
+			fmt.Fprintf(stderr, "could not read config: %v\n", err)
+			return exitError
+		}
+
+		if err = s.Validate(ast); err != nil {
+			return printError(stderr, err)
+		}
+
+		if ast, err = s.ApplyAliases(ast); err != nil {
+			return printError(stderr, err)
+		}
+	}
+
+	exitCode := exitOk
+
+	section := func(name string, f func() (string, error)) {
+		out, err := f()
+		if err != nil {
+			out = "error: " + err.Error()
+			exitCode = exitError
+		}
+
+		fmt.Fprintf(stdout, "%s:\n%s\n\n", name, out)
+	}
+
+	section("AST", func() (string, error) {
+		return marshalJson(ast)
+	})
+
+	section("Filter", func() (string, error) {
+		return ast.AsFilter(), nil
+	})
+
+	section("GORM", func() (string, error) {
+		sqlAst, err := applyMapping(s, ast, astschema.Sql)
+		if err != nil {
+			return "", err
+		}
+
+		qb := astgorm.DefaultGormQueryBuilder{}
+		if s != nil {
+			qb = astgorm.GormQueryBuilderFromSchema(s)
+		}
+
+		sq, err := epsearchast.SemanticReduceAst(sqlAst, qb)
+		if err != nil {
+			return "", err
+		}
+
+		queryArgs, err := marshalJson(sq.Args)
+		if err != nil {
+			return "", err
+		}
+
+		out := fmt.Sprintf("Clause: %s\nArgs: %s", sq.Clause, queryArgs)
+
+		if s != nil && len(s.FieldTypes()) > 0 {
+			// The GORM builder doesn't support field types, values are always strings that the database converts.
+			out = "Note: field types are not used, values are passed as strings\n" + out
+		}
+
+		return out, nil
+	})
+
+	section("Mongo", func() (string, error) {
+		qb := astmongo.DefaultMongoQueryBuilder{}
+		if s != nil {
+			qb = astmongo.MongoQueryBuilderFromSchema(s)
+		}
+
+		return reduceToExtJson(s, ast, qb)
+	})
+
+	section("Elasticsearch", func() (string, error) {
+		esAst, err := applyMapping(s, ast, astschema.Es)
+		if err != nil {
+			return "", err
+		}
+
+		qb := astes.DefaultEsQueryBuilder{}
+		if s != nil {
+			qb = astes.EsQueryBuilderFromSchema(s)
+		}
+
+		query, err := epsearchast.SemanticReduceAst(esAst, qb)
+		if err != nil {
+			return "", err
+		}
+
+		return marshalJson(query)
+	})
+
+	section("Atlas Search", func() (string, error) {
+		qb := astmongo.DefaultAtlasSearchQueryBuilder{}
+		if s != nil {
+			qb = astmongo.AtlasSearchQueryBuilderFromSchema(s)
+		}
+
+		return reduceToExtJson(s, ast, qb)
+	})
+
+	return exitCode
+}
+
+// getAst parses the input as a header if it looks like JSON (or URL-encoded JSON), and as a filter otherwise.
+func getAst(input string) (*epsearchast.AstNode, error) {
+	if strings.HasPrefix(input, "{") || strings.HasPrefix(input, "%") {
+		return epsearchast.GetAst(input)
+	}
+
+	return epsearchast.ParseFilter(input)
+}
+
+// applyMapping returns the AST with the mapping of the schema for the backend applied, or the AST unchanged if there is no schema.
+func applyMapping(s *astschema.Schema, ast *epsearchast.AstNode, backend astschema.Backend) (*epsearchast.AstNode, error) {
+	if s == nil {
+		return ast, nil
+	}
+
+	return s.ApplyMapping(ast, backend)
+}
+
+func reduceToExtJson(s *astschema.Schema, ast *epsearchast.AstNode, qb epsearchast.SemanticReducer[bson.D]) (string, error) {
+	mongoAst, err := applyMapping(s, ast, astschema.Mongo)
+	if err != nil {
+		return "", err
+	}
+
+	query, err := epsearchast.SemanticReduceAst(mongoAst, qb)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := bson.MarshalExtJSONIndent(query, false, false, "", "  ")
+	return string(b), err
+}
+
+func marshalJson(v any) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+func printError(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, err)
+
+	switch {
+	case errors.As(err, &epsearchast.ParsingErr{}):
+		return exitParsingError
+	case errors.As(err, &epsearchast.ValidationErr{}):
+		return exitValidationError
+	default:
+		return exitError
+	}
+}
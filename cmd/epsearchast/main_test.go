@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func writeConfig(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestRunPrintsEveryBackend(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	exitCode, stdout, stderr := runCommand(t, "", `eq(status,paid):in(tags,a,b)`)
+
+	// Verification
+	require.Equal(t, exitOk, exitCode)
+	require.Empty(t, stderr)
+
+	for _, section := range []string{"AST:\n", "Filter:\n", "GORM:\n", "Mongo:\n", "Elasticsearch:\n", "Atlas Search:\n"} {
+		require.Contains(t, stdout, section)
+	}
+
+	require.Contains(t, stdout, "Filter:\neq(\"status\",\"paid\"):in(\"tags\",\"a\",\"b\")\n")
+	require.Contains(t, stdout, "GORM:\nClause: ( status = ? AND tags IN ? )\n")
+	require.Contains(t, stdout, `"$eq": "paid"`)
+	require.Contains(t, stdout, `"terms": {`)
+	require.Contains(t, stdout, `"equals": {`)
+}
+
+func TestRunReadsHeadersAndFilters(t *testing.T) {
+	//language=JSON
+	header := `{"type": "EQ", "args": ["status", "paid"]}`
+
+	testCases := map[string]struct {
+		stdin string
+		args  []string
+	}{
+		"filter argument":      {args: []string{`eq(status,paid)`}},
+		"filter split by args": {args: []string{`eq(status,paid):`, `eq(status,paid)`}},
+		"filter on stdin":      {stdin: "eq(status,paid)\n"},
+		"header argument":      {args: []string{header}},
+		"header on stdin":      {stdin: header},
+		"url encoded header":   {stdin: url.QueryEscape(header)},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			exitCode, stdout, stderr := runCommand(t, tc.stdin, tc.args...)
+
+			// Verification
+			require.Equal(t, exitOk, exitCode, stderr)
+			require.Contains(t, stdout, "Filter:\neq(\"status\",\"paid\")")
+		})
+	}
+}
+
+func TestRunReturnsParsingErrorExitCode(t *testing.T) {
+	testCases := map[string]string{
+		"filter": `eq(status,paid`,
+		"header": `{"type": `,
+	}
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			exitCode, stdout, stderr := runCommand(t, input)
+
+			// Verification
+			require.Equal(t, exitParsingError, exitCode)
+			require.Empty(t, stdout)
+			require.Contains(t, stderr, "could not parse filter")
+		})
+	}
+}
+
+func TestRunReturnsValidationErrorExitCode(t *testing.T) {
+	//language=yaml
+	yamlConfig := `
+fields:
+  status:
+    operators: [eq]
+  price:
+    operators: [gt]
+    type: int64
+`
+
+	//language=JSON
+	jsonConfig := `{"fields": {"status": {"operators": ["eq"]}, "price": {"operators": ["gt"], "type": "int64"}}}`
+
+	testCases := map[string]string{
+		"unsupported operator": `in(status,paid,draft)`,
+		"unknown field":        `eq(name,shirt)`,
+		"invalid type":         `gt(price,cheap)`,
+		"invalid ast":          `eq(status)`,
+	}
+
+	for configName, config := range map[string]string{"config.yaml": yamlConfig, "config.json": jsonConfig} {
+		for name, filter := range testCases {
+			t.Run(configName+" "+name, func(t *testing.T) {
+				// Fixture Setup
+				configFile := writeConfig(t, configName, config)
+
+				// Execute SUT
+				exitCode, stdout, stderr := runCommand(t, "", "-config", configFile, filter)
+
+				// Verification
+				require.Equal(t, exitValidationError, exitCode)
+				require.Empty(t, stdout)
+				require.Contains(t, stderr, "error validating filter")
+			})
+		}
+	}
+}
+
+func TestRunUsesConfig(t *testing.T) {
+	// Fixture Setup
+	//language=yaml
+	configFile := writeConfig(t, "config.yaml", `
+fields:
+  status:
+    operators: [eq]
+    aliases: [state]
+    sql:
+      column: order_status
+    mongo:
+      path: payment.status
+    es:
+      field: payment_status
+      multi_fields:
+        equality: payment_status.keyword
+  price:
+    operators: [gt]
+    type: int64
+`)
+
+	// Execute SUT
+	exitCode, stdout, stderr := runCommand(t, "", "-config", configFile, `eq(state,paid):gt(price,5)`)
+
+	// Verification
+	require.Equal(t, exitOk, exitCode, stderr)
+	require.Contains(t, stdout, "Filter:\neq(\"status\",\"paid\"):gt(\"price\",\"5\")\n")
+	require.Contains(t, stdout, "GORM:\nNote: field types are not used, values are passed as strings\nClause: ( order_status = ? AND price > ? )\nArgs: [\n  \"paid\",\n  \"5\"\n]\n")
+	require.Contains(t, stdout, `"payment.status": {`)
+	require.Contains(t, stdout, `"$gt": 5`)
+	require.Contains(t, stdout, `"payment_status.keyword": "paid"`)
+	require.Contains(t, stdout, `"gt": 5`)
+	require.Contains(t, stdout, `"path": "payment.status"`)
+}
+
+func TestRunReturnsErrorExitCodeForInvalidConfig(t *testing.T) {
+	testCases := map[string]string{
+		"unknown type":     "fields:\n  price:\n    operators: [eq]\n    type: money\n",
+		"unknown key":      "fields:\n  price:\n    allowed_ops: [eq]\n",
+		"invalid value":    "fields: [price]\n",
+		"unknown operator": "fields:\n  price:\n    operators: [equals]\n",
+	}
+
+	for name, config := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			configFile := writeConfig(t, "config.yaml", config)
+
+			// Execute SUT
+			exitCode, stdout, stderr := runCommand(t, "", "-config", configFile, `eq(price,5)`)
+
+			// Verification
+			require.Equal(t, exitError, exitCode)
+			require.Empty(t, stdout)
+			require.Contains(t, stderr, "could not read config")
+		})
+	}
+}
+
+func TestRunReturnsErrorExitCodeForMissingConfig(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	exitCode, _, stderr := runCommand(t, "", "-config", filepath.Join(t.TempDir(), "missing.yaml"), `eq(price,5)`)
+
+	// Verification
+	require.Equal(t, exitError, exitCode)
+	require.Contains(t, stderr, "could not read config")
+}
@@ -0,0 +1,103 @@
+package epsearchast
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// JsonApiContentType is the media type of a JsonApiErrors document.
+	JsonApiContentType = "application/vnd.api+json"
+
+	// ProblemDetailsContentType is the media type of a ProblemDetails document.
+	ProblemDetailsContentType = "application/problem+json"
+)
+
+// JsonApiErrors is a JSON:API document containing errors (https://jsonapi.org/format/#error-objects).
+type JsonApiErrors struct {
+	Errors []JsonApiError `json:"errors"`
+}
+
+// JsonApiError is a JSON:API error object.
+type JsonApiError struct {
+	Status string              `json:"status"`
+	Title  string              `json:"title"`
+	Detail string              `json:"detail"`
+	Source *JsonApiErrorSource `json:"source,omitempty"`
+	Meta   *JsonApiErrorMeta   `json:"meta,omitempty"`
+}
+
+// JsonApiErrorSource is the part of the request that caused the error, for a ValidationErr this is the filter parameter.
+type JsonApiErrorSource struct {
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// JsonApiErrorMeta contains the filter of the node that caused the error.
+type JsonApiErrorMeta struct {
+	Filter string `json:"filter"`
+}
+
+// ProblemDetails is an RFC 7807 problem details document (https://www.rfc-editor.org/rfc/rfc7807), with the filter of the node that caused the error as an extension member.
+type ProblemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Filter string `json:"filter,omitempty"`
+}
+
+// HttpStatus returns the HTTP status code to respond with for an error from this package.
+// A ValidationErr is a 400, anything else (including a ParsingErr, as the header is generated by another service and not the caller) is a 500.
+func HttpStatus(err error) int {
+	if errors.As(err, &ValidationErr{}) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// NewJsonApiErrors renders the error as a JSON:API document with a single error, if the error is a ValidationErr the source is the filter parameter,
+// and the filter of the node that failed validation (see ValidationErr.Filter) is in the meta.
+func NewJsonApiErrors(err error) JsonApiErrors {
+	status := HttpStatus(err)
+
+	jsonApiError := JsonApiError{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}
+
+	ve := ValidationErr{}
+	if errors.As(err, &ve) {
+		jsonApiError.Source = &JsonApiErrorSource{Parameter: "filter"}
+
+		if ve.Filter() != "" {
+			jsonApiError.Meta = &JsonApiErrorMeta{Filter: ve.Filter()}
+		}
+	}
+
+	return JsonApiErrors{
+		Errors: []JsonApiError{jsonApiError},
+	}
+}
+
+// NewProblemDetails renders the error as an RFC 7807 problem details document, if the error is a ValidationErr the filter of the node that failed validation
+// (see ValidationErr.Filter) is included.
+func NewProblemDetails(err error) ProblemDetails {
+	status := HttpStatus(err)
+
+	problem := ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	}
+
+	ve := ValidationErr{}
+	if errors.As(err, &ve) {
+		problem.Filter = ve.Filter()
+	}
+
+	return problem
+}
@@ -0,0 +1,250 @@
+package epsearchast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getValidationErr(t *testing.T, jsonTxt string) error {
+	astNode, err := GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	err = ValidateAstFieldAndOperators(astNode, map[string][]string{"status": {"eq"}})
+	require.Error(t, err)
+
+	return err
+}
+
+func TestValidationErrHasFilter(t *testing.T) {
+	testCases := map[string]struct {
+		err      func(t *testing.T) error
+		expected string
+	}{
+		"invalid ast": {
+			err: func(t *testing.T) error {
+				_, err := GetAst(`{"type": "EQ", "args": ["status"]}`)
+				return err
+			},
+			expected: `eq("status")`,
+		},
+		"invalid filter": {
+			err: func(t *testing.T) error {
+				_, err := ParseFilter(`in(status)`)
+				return err
+			},
+			expected: `in("status")`,
+		},
+		"unknown field": {
+			err: func(t *testing.T) error {
+				return getValidationErr(t, `{"type": "EQ", "args": ["name", "shirt"]}`)
+			},
+			expected: `eq("name","shirt")`,
+		},
+		"invalid node in a conjunction": {
+			err: func(t *testing.T) error {
+				_, err := ParseFilter(`eq(status,paid):(eq(status,draft)|in(status))`)
+				return err
+			},
+			expected: `in("status")`,
+		},
+		"unknown field in a conjunction": {
+			err: func(t *testing.T) error {
+				return getValidationErr(t, `{"type": "AND", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "EQ", "args": ["name", "shirt"]}]}`)
+			},
+			expected: `eq("name","shirt")`,
+		},
+		"invalid value in a conjunction": {
+			err: func(t *testing.T) error {
+				astNode, err := ParseFilter(`eq(status,paid):gt(price,cheap)`)
+				require.NoError(t, err)
+
+				return ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypes(astNode, map[string][]string{"status": {"eq"}, "price": {"gt"}}, nil, nil, map[string]FieldType{"price": Int64})
+			},
+			expected: `gt("price","cheap")`,
+		},
+		"too complex": {
+			err: func(t *testing.T) error {
+				astNode, err := ParseFilter(`eq(status,a)|eq(status,b)|eq(status,c)`)
+				require.NoError(t, err)
+
+				return ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIndexIntersections(astNode, map[string][]string{"status": {"eq"}}, nil, nil, nil, 2)
+			},
+			expected: `eq("status","a")|eq("status","b")|eq("status","c")`,
+		},
+		"constructed": {
+			err: func(t *testing.T) error {
+				return NewValidationErr(fmt.Errorf("invalid"))
+			},
+			expected: "",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			err := tc.err(t)
+
+			// Execute SUT
+			ve := ValidationErr{}
+			ok := errors.As(err, &ve)
+
+			// Verify
+			require.True(t, ok)
+			require.Equal(t, tc.expected, ve.Filter())
+
+			if tc.expected != "" {
+				require.Equal(t, tc.expected, ve.Node().AsFilter())
+			}
+		})
+	}
+}
+
+func TestHttpStatus(t *testing.T) {
+	_, parsingErr := GetAst(`{"type": `)
+	validationErr := getValidationErr(t, `{"type": "EQ", "args": ["name", "shirt"]}`)
+
+	testCases := map[string]struct {
+		err      error
+		expected int
+	}{
+		"parsing error":          {parsingErr, http.StatusInternalServerError},
+		"validation error":       {validationErr, http.StatusBadRequest},
+		"wrapped validation err": {fmt.Errorf("could not search: %w", validationErr), http.StatusBadRequest},
+		"other error":            {fmt.Errorf("database is down"), http.StatusInternalServerError},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			status := HttpStatus(tc.err)
+
+			// Verify
+			require.Equal(t, tc.expected, status)
+		})
+	}
+}
+
+func TestNewJsonApiErrors(t *testing.T) {
+	_, parsingErr := GetAst(`{"type": `)
+
+	testCases := map[string]struct {
+		err      error
+		expected string
+	}{
+		"validation error": {
+			err: getValidationErr(t, `{"type": "EQ", "args": ["name", "shirt"]}`),
+			//language=JSON
+			expected: `{
+				"errors": [
+					{
+						"status": "400",
+						"title": "Bad Request",
+						"detail": "error validating filter: unknown field [name] specified in search filter, allowed fields are [status]",
+						"source": {"parameter": "filter"},
+						"meta": {"filter": "eq(\"name\",\"shirt\")"}
+					}
+				]
+			}`,
+		},
+		"validation error without filter": {
+			err: NewValidationErr(fmt.Errorf("invalid")),
+			//language=JSON
+			expected: `{
+				"errors": [
+					{"status": "400", "title": "Bad Request", "detail": "error validating filter: invalid", "source": {"parameter": "filter"}}
+				]
+			}`,
+		},
+		"parsing error": {
+			err: parsingErr,
+			//language=JSON
+			expected: `{
+				"errors": [
+					{
+						"status": "500",
+						"title": "Internal Server Error",
+						"detail": "could not parse filter: error parsing decoded filter: unexpected end of JSON input unexpected end of JSON input"
+					}
+				]
+			}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			b, err := json.Marshal(NewJsonApiErrors(tc.err))
+
+			// Verify
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(b))
+		})
+	}
+}
+
+func TestNewJsonApiErrorsReportsTheNodeThatFailedValidation(t *testing.T) {
+	// Fixture Setup
+	err := getValidationErr(t, `{"type": "AND", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "EQ", "args": ["name", "shirt"]}]}`)
+
+	// Execute SUT
+	jsonApiErrors := NewJsonApiErrors(err)
+	problem := NewProblemDetails(err)
+
+	// Verify
+	require.Len(t, jsonApiErrors.Errors, 1)
+	require.Equal(t, &JsonApiErrorMeta{Filter: `eq("name","shirt")`}, jsonApiErrors.Errors[0].Meta)
+	require.Equal(t, `eq("name","shirt")`, problem.Filter)
+}
+
+func TestNewProblemDetails(t *testing.T) {
+	_, parsingErr := GetAst(`{"type": `)
+
+	testCases := map[string]struct {
+		err      error
+		expected string
+	}{
+		"validation error": {
+			err: getValidationErr(t, `{"type": "EQ", "args": ["name", "shirt"]}`),
+			//language=JSON
+			expected: `{
+				"type": "about:blank",
+				"title": "Bad Request",
+				"status": 400,
+				"detail": "error validating filter: unknown field [name] specified in search filter, allowed fields are [status]",
+				"filter": "eq(\"name\",\"shirt\")"
+			}`,
+		},
+		"parsing error": {
+			err: parsingErr,
+			//language=JSON
+			expected: `{
+				"type": "about:blank",
+				"title": "Internal Server Error",
+				"status": 500,
+				"detail": "could not parse filter: error parsing decoded filter: unexpected end of JSON input unexpected end of JSON input"
+			}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			b, err := json.Marshal(NewProblemDetails(tc.err))
+
+			// Verify
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(b))
+		})
+	}
+}
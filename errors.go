@@ -1,6 +1,9 @@
 package epsearchast
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type ParsingErr struct {
 	err error
@@ -17,15 +20,64 @@ func NewParsingErr(err error) ParsingErr {
 }
 
 type ValidationErr struct {
-	err error
+	err  error
+	node *AstNode
 }
 
 func (ve ValidationErr) Error() string {
 	return ve.err.Error()
 }
 
+// Filter returns the filter of the node that was invalid (rendered with AsFilter), or an empty string if it isn't known.
+func (ve ValidationErr) Filter() string {
+	if ve.node == nil {
+		return ""
+	}
+
+	return ve.node.AsFilter()
+}
+
+// Node returns the node that was invalid, which is the whole AST if the problem isn't with a single node (e.g., it's too complex), or nil if it isn't known.
+func (ve ValidationErr) Node() *AstNode {
+	return ve.node
+}
+
 func NewValidationErr(err error) ValidationErr {
 	return ValidationErr{
 		err: fmt.Errorf("error validating filter: %w", err),
 	}
 }
+
+// newValidationErrForAst returns a ValidationErr for the AST, if the error came from a single node (see newNodeErr), that node is reported instead.
+func newValidationErrForAst(a *AstNode, err error) ValidationErr {
+	ve := NewValidationErr(err)
+	ve.node = a
+
+	ne := nodeErr{}
+	if errors.As(err, &ne) {
+		ve.node = ne.node
+	}
+
+	return ve
+}
+
+// nodeErr is an error with the node that caused it, so that a ValidationErr can report the node rather than the whole AST.
+type nodeErr struct {
+	err  error
+	node *AstNode
+}
+
+func (ne nodeErr) Error() string {
+	return ne.err.Error()
+}
+
+func (ne nodeErr) Unwrap() error {
+	return ne.err
+}
+
+func newNodeErr(a *AstNode, err error) nodeErr {
+	return nodeErr{
+		err:  err,
+		node: a,
+	}
+}
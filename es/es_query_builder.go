@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/elasticpath/epcc-search-ast-helper"
 )
@@ -24,10 +25,108 @@ type DefaultEsQueryBuilder struct {
 	// The regular expression can have capture groups that will be used as replacements in the subquery keys and values.
 	NestedFieldToQuery map[string]NestedReplacement
 
+	// OrderedNestedFieldToQuery is like NestedFieldToQuery, but patterns are checked in order and the first one that matches is used, which lets you give
+	// overlapping patterns a priority. It is checked before NestedFieldToQuery, where a field matching more than one pattern is an error.
+	OrderedNestedFieldToQuery []NestedFieldPattern
+
+	// The compiled patterns from OrderedNestedFieldToQuery and NestedFieldToQuery, set by MustCompile, otherwise the patterns are compiled for each predicate.
+	compiledNestedFields []compiledNestedField
+
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/parent-join.html
+	// JoinFieldToQuery is a keyed map that takes as a key a regular expression for an attribute that is stored in a related document (e.g., a child SKU of a product),
+	// in the same format as NestedFieldToQuery. The value is information about how to generate a has_child or has_parent query for it.
+	// Predicates that are AND-ed together on the same relation are grouped into one query, so they must all match the same related document.
+	JoinFieldToQuery map[string]JoinReplacement
+
+	// The compiled patterns from JoinFieldToQuery sorted by pattern, set by MustCompile, otherwise the patterns are compiled for each predicate.
+	compiledJoinFields []compiledJoinField
+
 	// The default value for fuzziness
 	// https://opensearch.org/docs/latest/query-dsl/term/fuzzy/
 	// Default value is treated as zero
 	DefaultFuzziness string
+
+	// NullSemantics controls what is_null matches. Elasticsearch does not index null values or empty arrays
+	// (https://www.elastic.co/guide/en/elasticsearch/reference/current/null-value.html), so an exists query cannot tell explicit nulls,
+	// missing fields and empty arrays apart, and only epsearchast.NullOrMissingOrEmptyArray (the default) is supported.
+	NullSemantics epsearchast.NullSemanticsConfig
+
+	// FieldTypes controls how values are validated and converted (with epsearchast.Convert) before being sent to Elasticsearch, fields not in this map are sent as strings.
+	// The keys here should be field names from the filter (after processing from NestedFieldToQuery).
+	FieldTypes map[string]epsearchast.FieldType
+
+	// UseFilterContext places predicates that don't need to contribute to relevance (everything except text) in bool.filter instead of bool.must,
+	// so they don't affect scoring and can be cached (https://www.elastic.co/guide/en/elasticsearch/reference/current/query-filter-context.html).
+	// AND nodes with only filters become a pure filter bool, and OR nodes with only filters are wrapped in bool.filter.
+	UseFilterContext bool
+
+	// Flatten merges nested conjunctions and disjunctions (e.g., AND(AND(a,b),c) generates a single bool with three clauses), and removes bool queries
+	// that only wrap a single clause, this keeps deeply nested filters under the depth and clause limits of Elasticsearch.
+	Flatten bool
+
+	// FieldToTextStrategy controls the query (and its options) used for text() on a field, the keys here should be field names from the filter
+	// (after processing from NestedFieldToQuery), the key * can be used to configure text(*,...).
+	// If a field is not in this map, DefaultTextStrategy is used.
+	FieldToTextStrategy map[string]*TextStrategy
+
+	// The strategy to use for text() on fields not in FieldToTextStrategy, the zero value generates a match_bool_prefix query.
+	DefaultTextStrategy TextStrategy
+
+	// UseTermsSetForContainsAll generates a single terms_set query (https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-terms-set-query.html)
+	// for contains_all that must match all the values, instead of a bool query with a term query for each value, which keeps large lists under the clause limits.
+	// For nested fields, all the values must be in the same nested object.
+	UseTermsSetForContainsAll bool
+}
+
+type TextQueryType string
+
+const (
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-match-bool-prefix-query.html
+	TextQueryMatchBoolPrefix TextQueryType = "match_bool_prefix"
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-match-query-phrase.html
+	TextQueryMatchPhrase TextQueryType = "match_phrase"
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-multi-match-query.html
+	TextQueryMultiMatch TextQueryType = "multi_match"
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-simple-query-string-query.html
+	TextQuerySimpleQueryString TextQueryType = "simple_query_string"
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-combined-fields-query.html
+	TextQueryCombinedFields TextQueryType = "combined_fields"
+)
+
+type TextStrategy struct {
+	// The query to use, if empty match_bool_prefix is used.
+	QueryType TextQueryType
+
+	// The fields to search, which may include boosts (e.g., name^3), if empty the Text field from OpTypeToFieldNames is used.
+	// For match_bool_prefix and match_phrase, which only support a single field, a query is generated for each field and at least one must match.
+	Fields []string
+
+	// The boolean logic used to interpret the terms in the query (and, or), if empty and is used. Not supported by match_phrase.
+	Operator string
+
+	// The minimum number of terms that must match (https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-minimum-should-match.html). Not supported by match_phrase.
+	MinimumShouldMatch string
+
+	// The analyzer to use for the query instead of the one in the mapping. Not supported by combined_fields.
+	Analyzer string
+
+	// The fuzziness to use instead of DefaultFuzziness. Only supported by match_bool_prefix and multi_match.
+	Fuzziness string
+}
+
+type NestedFieldPattern struct {
+	// A regular expression for the field in the same format as the keys in NestedFieldToQuery.
+	Pattern string
+
+	NestedReplacement
+}
+
+type compiledNestedField struct {
+	pattern     *regexp.Regexp
+	replacement NestedReplacement
+
+	// Whether the pattern came from OrderedNestedFieldToQuery, and the first match should be used.
+	ordered bool
 }
 
 type NestedReplacement struct {
@@ -39,6 +138,32 @@ type NestedReplacement struct {
 	Subqueries map[string]Replacement
 }
 
+type JoinQueryType string
+
+const (
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-has-child-query.html
+	JoinHasChild JoinQueryType = "has_child"
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-has-parent-query.html
+	JoinHasParent JoinQueryType = "has_parent"
+)
+
+type compiledJoinField struct {
+	pattern     *regexp.Regexp
+	replacement JoinReplacement
+}
+
+type JoinReplacement struct {
+	// The query to use, has_child matches documents that have a child that matches, and has_parent matches documents whose parent matches.
+	QueryType JoinQueryType
+
+	// The relation in the join field, for has_child this is the child relation (type), for has_parent it is the parent relation (parent_type).
+	Relation string
+
+	// The field to search in the related document, named capture groups in the parent map will be replaced (e.g., a field ^skus\.(?P<field>.+)$ can use $field).
+	// If empty the field is used as is. The resulting field can be a nested field.
+	Field string
+}
+
 type Replacement struct {
 	// The value we should search for, we can use the named capture groups from the parent regex as replacements, also the special value $value is available
 	Value string
@@ -65,76 +190,237 @@ type OperatorTypeToMultiFieldName struct {
 
 	// The field name for wild card fields
 	Wildcard string
+
+	// The null_value the field is mapped with (https://www.elastic.co/guide/en/elasticsearch/reference/current/null-value.html), if set, is_null will search for this value
+	// in the equality field instead of checking the field doesn't exist. The value is converted with FieldTypes.
+	NullValue string
 }
 
 // MustValidate will ensure that the configuration of the query builder is correct and if not, panics. It simplifies safe initialization of the variable.
 func (d DefaultEsQueryBuilder) MustValidate() {
-	for k := range d.NestedFieldToQuery {
-		re := regexp.MustCompile(k)
+	if err := d.DefaultTextStrategy.validate(); err != nil {
+		panic(fmt.Sprintf("Invalid default text strategy: %v", err))
+	}
+
+	for k, v := range d.FieldToTextStrategy {
+		if v == nil {
+			continue
+		}
 
-		if k[0] != '^' {
-			panic(fmt.Sprintf("All nested fields must be anchored to the start of the string (e.g., start with a ^), [%s] does not", k))
+		if err := v.validate(); err != nil {
+			panic(fmt.Sprintf("Invalid text strategy for field [%s]: %v", k, err))
 		}
+	}
+
+	if err := d.NullSemantics.ValidateSupported("Elasticsearch", epsearchast.NullOrMissingOrEmptyArray, epsearchast.ExplicitNullOnly); err != nil {
+		panic(fmt.Sprintf("Invalid null semantics: %v", err))
+	}
 
-		if k[len(k)-1] != '$' {
-			panic(fmt.Sprintf("All nested fields must be anchored at the end of the string (e.g., end in an $), [%s] does not", k))
+	for k, s := range d.NullSemantics.FieldOverrides {
+		if s == epsearchast.ExplicitNullOnly && !strings.HasPrefix(k, "^") && d.GetFieldMapping(k).NullValue == "" {
+			panic(fmt.Sprintf("Invalid null semantics: null semantics %s are not supported in Elasticsearch for field [%s] unless it has a NullValue", s, k))
 		}
+	}
 
-		var groupKeys []string
-		for _, v := range re.SubexpNames() {
-			if v == "value" {
-				panic(fmt.Sprintf("Named capture group 'value' is reserved for the replacement value, [%s] cannot use this", k))
+	for k, v := range d.OpTypeToFieldNames {
+		if v == nil || v.NullValue == "" {
+			continue
+		}
+
+		if fieldType, ok := d.FieldTypes[k]; ok {
+			if err := epsearchast.ValidateValue(fieldType, v.NullValue); err != nil {
+				panic(fmt.Sprintf("NullValue for field [%s] is not valid for its type: %v", k, err))
 			}
-			groupKeys = append(groupKeys, v)
 		}
+	}
+
+	for _, v := range d.OrderedNestedFieldToQuery {
+		mustValidateNestedReplacement(v.Pattern, v.NestedReplacement)
+	}
+
+	for k, v := range d.NestedFieldToQuery {
+		mustValidateNestedReplacement(k, v)
+	}
+
+	for k, v := range d.JoinFieldToQuery {
+		mustValidateJoinReplacement(k, v)
+	}
+}
+
+// MustCompile validates the configuration with MustValidate, and returns a copy of the query builder with the nested field, join field and null semantics patterns compiled.
+// The nested field, join field and null semantics configuration must not be changed after this is called.
+func (d DefaultEsQueryBuilder) MustCompile() DefaultEsQueryBuilder {
+	d.MustValidate()
+
+	d.compiledNestedFields = d.compileNestedFields()
+	d.compiledJoinFields = d.compileJoinFields()
+	d.NullSemantics = d.NullSemantics.MustCompile()
+
+	return d
+}
+
+// getNestedFields returns the nested field patterns, first those from OrderedNestedFieldToQuery in order, followed by NestedFieldToQuery sorted by pattern.
+func (d DefaultEsQueryBuilder) getNestedFields() []compiledNestedField {
+	if d.compiledNestedFields != nil {
+		return d.compiledNestedFields
+	}
+
+	return d.compileNestedFields()
+}
+
+func (d DefaultEsQueryBuilder) compileNestedFields() []compiledNestedField {
+	compiled := make([]compiledNestedField, 0, len(d.OrderedNestedFieldToQuery)+len(d.NestedFieldToQuery))
+
+	for _, v := range d.OrderedNestedFieldToQuery {
+		compiled = append(compiled, compiledNestedField{
+			pattern:     regexp.MustCompile(v.Pattern),
+			replacement: v.NestedReplacement,
+			ordered:     true,
+		})
+	}
+
+	patterns := make([]string, 0, len(d.NestedFieldToQuery))
+	for k := range d.NestedFieldToQuery {
+		patterns = append(patterns, k)
+	}
+
+	sort.Strings(patterns)
+
+	for _, k := range patterns {
+		compiled = append(compiled, compiledNestedField{
+			pattern:     regexp.MustCompile(k),
+			replacement: d.NestedFieldToQuery[k],
+		})
+	}
 
-		// We need to resolve keys in decreasing order of length
-		// So that if we substitute templates with their replacement in consistent order.
-		// E.g., if you have templates $user=foo and $username=bar, "$user and $username" needs to resolve to
-		// "foo and bar" not "foo and fooname", which if you replace the string user first, is what you get.
-		sortByDecreasingLength(groupKeys)
+	return compiled
+}
+
+// getJoinFields returns the join field patterns sorted by pattern.
+func (d DefaultEsQueryBuilder) getJoinFields() []compiledJoinField {
+	if d.compiledJoinFields != nil {
+		return d.compiledJoinFields
+	}
 
-		if d.NestedFieldToQuery[k].Path == "" {
-			panic(fmt.Sprintf("Path must be set for nested field [%s]", k))
+	return d.compileJoinFields()
+}
+
+func (d DefaultEsQueryBuilder) compileJoinFields() []compiledJoinField {
+	patterns := make([]string, 0, len(d.JoinFieldToQuery))
+	for k := range d.JoinFieldToQuery {
+		patterns = append(patterns, k)
+	}
+
+	sort.Strings(patterns)
+
+	compiled := make([]compiledJoinField, 0, len(patterns))
+	for _, k := range patterns {
+		compiled = append(compiled, compiledJoinField{
+			pattern:     regexp.MustCompile(k),
+			replacement: d.JoinFieldToQuery[k],
+		})
+	}
+
+	return compiled
+}
+
+func mustValidateNestedReplacement(k string, v NestedReplacement) {
+	re := regexp.MustCompile(k)
+
+	if !strings.HasPrefix(k, "^") {
+		panic(fmt.Sprintf("All nested fields must be anchored to the start of the string (e.g., start with a ^), [%s] does not", k))
+	}
+
+	if !strings.HasSuffix(k, "$") {
+		panic(fmt.Sprintf("All nested fields must be anchored at the end of the string (e.g., end in an $), [%s] does not", k))
+	}
+
+	var groupKeys []string
+	for _, name := range re.SubexpNames() {
+		if name == "value" {
+			panic(fmt.Sprintf("Named capture group 'value' is reserved for the replacement value, [%s] cannot use this", k))
 		}
+		groupKeys = append(groupKeys, name)
+	}
+
+	// We need to resolve keys in decreasing order of length
+	// So that if we substitute templates with their replacement in consistent order.
+	// E.g., if you have templates $user=foo and $username=bar, "$user and $username" needs to resolve to
+	// "foo and bar" not "foo and fooname", which if you replace the string user first, is what you get.
+	sortByDecreasingLength(groupKeys)
+
+	if v.Path == "" {
+		panic(fmt.Sprintf("Path must be set for nested field [%s]", k))
+	}
+
+	if len(v.Subqueries) < 1 {
+		panic(fmt.Sprintf("Subqueries must be set for nested field [%s]", k))
+	}
 
-		if len(d.NestedFieldToQuery[k].Subqueries) < 1 {
-			panic(fmt.Sprintf("Subqueries must be set for nested field [%s]", k))
+	for sK, sV := range v.Subqueries {
+		if strings.Contains(sK, "$value") {
+			// This exists for 3 reasons:
+			// 1. In the case of in, it's undefined what it would be since there are multiple values.
+			// 2. It would in theory be a form of injection since the users could supply anything and look at any field.
+			//   *  Other things shouldn't have this property because you should be validating fields match patterns.
+			// 3. I didn't implement this so removing this panic only defers a problem from start up, to runtime.
+			panic(fmt.Sprintf("You cannot use $value as replacement in a key in [%s]", sK))
 		}
 
-		for sK, sV := range d.NestedFieldToQuery[k].Subqueries {
-			if strings.Contains(sK, "$value") {
-				// This exists for 3 reasons:
-				// 1. In the case of in, it's undefined what it would be since there are multiple values.
-				// 2. It would in theory be a form of injection since the users could supply anything and look at any field.
-				//   *  Other things shouldn't have this property because you should be validating fields match patterns.
-				// 3. I didn't implement this so removing this panic only defers a problem from start up, to runtime.
-				panic(fmt.Sprintf("You cannot use $value as replacement in a key in [%s]", sK))
+		sqField := sK
+		sqValue := sV.Value
+
+		for _, group := range groupKeys {
+			if group == "" {
+				continue
 			}
+			sqField = strings.ReplaceAll(sqField, "$"+group, "")
+			sqValue = strings.ReplaceAll(sqValue, "$"+group, "")
+		}
 
-			sqField := sK
-			sqValue := sV.Value
+		sqValue = strings.ReplaceAll(sqValue, "$value", "")
 
-			for _, group := range groupKeys {
-				if group == "" {
-					continue
-				}
-				sqField = strings.ReplaceAll(sqField, "$"+group, "")
-				sqValue = strings.ReplaceAll(sqValue, "$"+group, "")
-			}
+		if strings.Contains(sqField, "$") {
+			panic(fmt.Sprintf("Not all templates replaced in nested field [%s] key [%s], after replacement left over with: %s ", k, sK, sqField))
+		}
 
-			sqValue = strings.ReplaceAll(sqValue, "$value", "")
+		if strings.Contains(sqValue, "$") {
+			panic(fmt.Sprintf("Not all templates replaced in nested field [%s] key [%s] with value [%s], after replacement left over with: %s", k, sK, sV.Value, sqValue))
+		}
 
-			if strings.Contains(sqField, "$") {
-				panic(fmt.Sprintf("Not all templates replaced in nested field [%s] key [%s], after replacement left over with: %s ", k, sK, sqField))
-			}
+	}
+}
 
-			if strings.Contains(sqValue, "$") {
-				panic(fmt.Sprintf("Not all templates replaced in nested field [%s] key [%s] with value [%s], after replacement left over with: %s", k, sK, sV.Value, sqValue))
-			}
+func mustValidateJoinReplacement(k string, v JoinReplacement) {
+	re := regexp.MustCompile(k)
+
+	if !strings.HasPrefix(k, "^") {
+		panic(fmt.Sprintf("All join fields must be anchored to the start of the string (e.g., start with a ^), [%s] does not", k))
+	}
+
+	if !strings.HasSuffix(k, "$") {
+		panic(fmt.Sprintf("All join fields must be anchored at the end of the string (e.g., end in an $), [%s] does not", k))
+	}
 
+	switch v.QueryType {
+	case JoinHasChild, JoinHasParent:
+	default:
+		panic(fmt.Sprintf("Unknown query type [%s] for join field [%s]", v.QueryType, k))
+	}
+
+	if v.Relation == "" {
+		panic(fmt.Sprintf("Relation must be set for join field [%s]", k))
+	}
+
+	groupMap := map[string]string{}
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			groupMap[name] = name
 		}
+	}
 
+	if field, _ := applyPatternGroupsToFieldNameAndValue(v.Field, "", groupMap); strings.Contains(field, "$") {
+		panic(fmt.Sprintf("Not all templates replaced in join field [%s] with field [%s], after replacement left over with: %s", k, v.Field, field))
 	}
 }
 
@@ -154,34 +440,295 @@ func sortByDecreasingLength(groupKeys []string) {
 var _ epsearchast.SemanticReducer[JsonObject] = (*DefaultEsQueryBuilder)(nil)
 
 func (d DefaultEsQueryBuilder) PostVisitAnd(rs []*JsonObject) (*JsonObject, error) {
+	if !d.UseFilterContext && !d.Flatten {
+		if len(d.JoinFieldToQuery) > 0 && len(rs) > 1 {
+			grouped, err := d.groupJoinQueries(rs)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(grouped) == 1 {
+				return grouped[0], nil
+			}
+
+			rs = grouped
+		}
+
+		return &JsonObject{
+			"bool": map[string]any{
+				"must": rs,
+			},
+		}, nil
+	}
+
+	var musts, filters []*JsonObject
+
+	for _, r := range rs {
+		if d.Flatten {
+			if childMusts, childFilters, ok := getConjunctionClauses(*r); ok {
+				musts = append(musts, childMusts...)
+				filters = append(filters, childFilters...)
+				continue
+			}
+		}
+
+		musts = append(musts, r)
+	}
+
+	if len(d.JoinFieldToQuery) > 0 {
+		// This happens after flattening, so that join queries in nested conjunctions are grouped too, filters come first so the order of clauses doesn't change.
+		grouped, err := d.groupJoinQueries(append(filters, musts...))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(grouped) == 1 {
+			return grouped[0], nil
+		}
+
+		musts, filters = grouped, nil
+	}
+
+	if d.UseFilterContext {
+		var scoring []*JsonObject
+
+		for _, r := range musts {
+			if isScoringQuery(*r) {
+				scoring = append(scoring, r)
+			} else {
+				filters = append(filters, r)
+			}
+		}
+
+		musts = scoring
+	}
+
+	if d.Flatten && len(musts) == 1 && len(filters) == 0 {
+		// A bool with a single must clause matches and scores the same as the clause.
+		return musts[0], nil
+	}
+
+	b := map[string]any{}
+
+	if len(musts) > 0 {
+		b["must"] = musts
+	}
+
+	if len(filters) > 0 {
+		b["filter"] = filters
+	}
+
 	return &JsonObject{
-		"bool": map[string]any{
-			"must": rs,
-		},
+		"bool": b,
 	}, nil
 }
 
+// groupJoinQueries combines join queries on the same relation into one join query, so that all the predicates must match the same related document.
+func (d DefaultEsQueryBuilder) groupJoinQueries(rs []*JsonObject) ([]*JsonObject, error) {
+	var grouped []*JsonObject
+	var groups [][]*JsonObject
+	groupIdx := map[string]int{}
+
+	for _, r := range rs {
+		queryType, relation, inner, ok := getJoinQuery(*r)
+		if !ok {
+			grouped = append(grouped, r)
+			groups = append(groups, nil)
+			continue
+		}
+
+		key := queryType + "/" + relation
+		if idx, ok := groupIdx[key]; ok {
+			groups[idx] = append(groups[idx], inner)
+			continue
+		}
+
+		groupIdx[key] = len(grouped)
+		grouped = append(grouped, r)
+		groups = append(groups, []*JsonObject{inner})
+	}
+
+	for i, inners := range groups {
+		if len(inners) < 2 {
+			continue
+		}
+
+		queryType, relation, _, _ := getJoinQuery(*grouped[i])
+
+		inner, err := d.PostVisitAnd(inners)
+		if err != nil {
+			return nil, err
+		}
+
+		grouped[i] = buildJoinQuery(JoinQueryType(queryType), relation, inner)
+	}
+
+	return grouped, nil
+}
+
+// getJoinQuery returns the query type, relation, and query of q, if q is a has_child or has_parent query.
+func getJoinQuery(q JsonObject) (string, string, *JsonObject, bool) {
+	if len(q) != 1 {
+		return "", "", nil, false
+	}
+
+	for k, v := range q {
+		relationKey := "type"
+		if k == string(JoinHasParent) {
+			relationKey = "parent_type"
+		} else if k != string(JoinHasChild) {
+			return "", "", nil, false
+		}
+
+		body, ok := v.(map[string]any)
+		if !ok || len(body) != 2 {
+			return "", "", nil, false
+		}
+
+		relation, ok := body[relationKey].(string)
+		if !ok {
+			return "", "", nil, false
+		}
+
+		inner, ok := body["query"].(*JsonObject)
+		if !ok {
+			return "", "", nil, false
+		}
+
+		return k, relation, inner, true
+	}
+
+	return "", "", nil, false
+}
+
+func buildJoinQuery(queryType JoinQueryType, relation string, q *JsonObject) *JsonObject {
+	relationKey := "type"
+	if queryType == JoinHasParent {
+		relationKey = "parent_type"
+	}
+
+	return &JsonObject{
+		string(queryType): map[string]any{
+			relationKey: relation,
+			"query":     q,
+		},
+	}
+}
+
 func (d DefaultEsQueryBuilder) PostVisitOr(rs []*JsonObject) (*JsonObject, error) {
+	if d.Flatten {
+		var shoulds []*JsonObject
+
+		// Disjunctions that have been wrapped in filter context can only be merged if the result will also be in filter context.
+		unwrapFilters := true
+		for _, r := range rs {
+			if isScoringQuery(*r) {
+				unwrapFilters = false
+			}
+		}
+
+		for _, r := range rs {
+			if childShoulds, ok := getDisjunctionClauses(*r, unwrapFilters); ok {
+				shoulds = append(shoulds, childShoulds...)
+			} else {
+				shoulds = append(shoulds, r)
+			}
+		}
+
+		rs = shoulds
+	}
+
+	var or *JsonObject
+
+	if d.Flatten && len(rs) == 1 {
+		or = rs[0]
+	} else {
+		or = &JsonObject{
+			"bool": map[string]any{
+				"should": rs,
+				// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-minimum-should-match.html
+				"minimum_should_match": 1,
+			},
+		}
+	}
+
+	if !d.UseFilterContext || isScoringQuery(*or) {
+		return or, nil
+	}
+
 	return &JsonObject{
 		"bool": map[string]any{
-			"should": rs,
-			// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-minimum-should-match.html
-			"minimum_should_match": 1,
+			"filter": []*JsonObject{or},
 		},
 	}, nil
 }
 
+// getConjunctionClauses returns the must and filter clauses of q, if q is a bool query that only contains must and filter clauses (i.e., it came from PostVisitAnd).
+func getConjunctionClauses(q JsonObject) ([]*JsonObject, []*JsonObject, bool) {
+	b, ok := getBoolQuery(q)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var musts, filters []*JsonObject
+
+	for k, v := range b {
+		clauses, ok := v.([]*JsonObject)
+		if !ok {
+			return nil, nil, false
+		}
+
+		switch k {
+		case "must":
+			musts = clauses
+		case "filter":
+			filters = clauses
+		default:
+			return nil, nil, false
+		}
+	}
+
+	return musts, filters, true
+}
+
+// getDisjunctionClauses returns the should clauses of q, if q is a bool query that only contains should clauses where one must match (i.e., it came from PostVisitOr).
+// If unwrapFilters is set, disjunctions that have been wrapped in filter context are also unwrapped.
+func getDisjunctionClauses(q JsonObject, unwrapFilters bool) ([]*JsonObject, bool) {
+	if musts, filters, ok := getConjunctionClauses(q); unwrapFilters && ok && len(musts) == 0 && len(filters) == 1 {
+		q = *filters[0]
+	}
+
+	b, ok := getBoolQuery(q)
+	if !ok || len(b) != 2 || b["minimum_should_match"] != 1 {
+		return nil, false
+	}
+
+	shoulds, ok := b["should"].([]*JsonObject)
+
+	return shoulds, ok
+}
+
+func getBoolQuery(q JsonObject) (map[string]any, bool) {
+	if len(q) != 1 {
+		return nil, false
+	}
+
+	b, ok := q["bool"].(map[string]any)
+
+	return b, ok
+}
+
 func (d DefaultEsQueryBuilder) VisitIn(args ...string) (*JsonObject, error) {
 	b := d.GetTermsQueryBuilderForEqualityField()
 
-	return d.buildQueryWithBuilder(b, args...)
+	return d.buildQueryWithBuilder("in", b, args...)
 }
 
 func (d DefaultEsQueryBuilder) GetTermsQueryBuilderForEqualityField() func(args ...string) *JsonObject {
 	return func(args ...string) *JsonObject {
 		return &JsonObject{
 			"terms": map[string]any{
-				d.GetFieldMapping(args[0]).Equality: args[1:],
+				d.GetFieldMapping(args[0]).Equality: d.ConvertValues(args[0], args[1:]...),
 			},
 		}
 	}
@@ -190,14 +737,14 @@ func (d DefaultEsQueryBuilder) GetTermsQueryBuilderForEqualityField() func(args
 func (d DefaultEsQueryBuilder) VisitEq(first, second string) (*JsonObject, error) {
 	b := d.GetTermQueryBuilderForEqualityField()
 
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("eq", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetTermQueryBuilderForEqualityField() func(args ...string) *JsonObject {
 	return func(args ...string) *JsonObject {
 		return &JsonObject{
 			"term": map[string]any{
-				d.GetFieldMapping(args[0]).Equality: args[1],
+				d.GetFieldMapping(args[0]).Equality: d.ConvertValue(args[0], args[1]),
 			},
 		}
 	}
@@ -206,22 +753,28 @@ func (d DefaultEsQueryBuilder) GetTermQueryBuilderForEqualityField() func(args .
 func (d DefaultEsQueryBuilder) VisitContains(first, second string) (*JsonObject, error) {
 	b := d.GetTermQueryBuilderForArrayField()
 
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("contains", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) VisitContainsAny(args ...string) (*JsonObject, error) {
 	b := d.GetTermsQueryBuilderForArrayField()
 
-	return d.buildQueryWithBuilder(b, args...)
+	return d.buildQueryWithBuilder("contains_any", b, args...)
 }
 
 func (d DefaultEsQueryBuilder) VisitContainsAll(args ...string) (*JsonObject, error) {
+	if d.UseTermsSetForContainsAll {
+		b := d.GetTermsSetQueryBuilderForArrayField()
+
+		return d.buildQueryWithBuilder("contains_all", b, args...)
+	}
+
 	// Build individual term queries for each value
 	b := d.GetTermQueryBuilderForArrayField()
 
 	var termQueries []*JsonObject
 	for _, value := range args[1:] {
-		query, err := d.buildQueryWithBuilder(b, args[0], value)
+		query, err := d.buildQueryWithBuilder("contains_all", b, args[0], value)
 		if err != nil {
 			return nil, err
 		}
@@ -229,18 +782,14 @@ func (d DefaultEsQueryBuilder) VisitContainsAll(args ...string) (*JsonObject, er
 	}
 
 	// Wrap in a bool query with must clause
-	return &JsonObject{
-		"bool": map[string]any{
-			"must": termQueries,
-		},
-	}, nil
+	return d.PostVisitAnd(termQueries)
 }
 
 func (d DefaultEsQueryBuilder) GetTermQueryBuilderForArrayField() func(args ...string) *JsonObject {
 	return func(args ...string) *JsonObject {
 		return &JsonObject{
 			"term": map[string]any{
-				d.GetFieldMapping(args[0]).Array: args[1],
+				d.GetFieldMapping(args[0]).Array: d.ConvertValue(args[0], args[1]),
 			},
 		}
 	}
@@ -250,44 +799,274 @@ func (d DefaultEsQueryBuilder) GetTermsQueryBuilderForArrayField() func(args ...
 	return func(args ...string) *JsonObject {
 		return &JsonObject{
 			"terms": map[string]any{
-				d.GetFieldMapping(args[0]).Array: args[1:],
+				d.GetFieldMapping(args[0]).Array: d.ConvertValues(args[0], args[1:]...),
+			},
+		}
+	}
+}
+
+// GetTermsSetQueryBuilderForArrayField returns a builder that generates a terms_set query where all the values must match.
+func (d DefaultEsQueryBuilder) GetTermsSetQueryBuilderForArrayField() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		// The number of terms that must match is the number of terms in the query, so duplicates need to be removed.
+		var terms []any
+		seen := map[any]bool{}
+
+		for _, v := range d.ConvertValues(args[0], args[1:]...) {
+			if !seen[v] {
+				seen[v] = true
+				terms = append(terms, v)
+			}
+		}
+
+		return &JsonObject{
+			"terms_set": map[string]any{
+				d.GetFieldMapping(args[0]).Array: map[string]any{
+					"terms": terms,
+					"minimum_should_match_script": map[string]any{
+						"source": "params.num_terms",
+					},
+				},
 			},
 		}
 	}
 }
 
 func (d DefaultEsQueryBuilder) VisitText(first, second string) (*JsonObject, error) {
-	b := d.BuildMatchBoolPrefixQuery()
+	b := d.GetTextQueryBuilder()
 
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("text", b, first, second)
 }
 
-func (d DefaultEsQueryBuilder) BuildMatchBoolPrefixQuery() func(args ...string) *JsonObject {
+// GetTextQueryBuilder returns a builder that generates the query configured for the field in FieldToTextStrategy.
+func (d DefaultEsQueryBuilder) GetTextQueryBuilder() func(args ...string) *JsonObject {
 	return func(args ...string) *JsonObject {
+		s := d.GetTextStrategy(args[0])
+
+		switch s.QueryType {
+		case TextQueryMultiMatch:
+			return d.BuildMultiMatchQuery()(args...)
+		case TextQuerySimpleQueryString:
+			return d.BuildSimpleQueryStringQuery()(args...)
+		case TextQueryCombinedFields:
+			return d.BuildCombinedFieldsQuery()(args...)
+		}
+
+		if len(s.Fields) == 0 {
+			return d.buildSingleFieldTextQuery(d.GetFieldMapping(args[0]).Text, args[1], s)
+		}
 
-		f := d.DefaultFuzziness
+		// These queries only support one field, so we need to generate a query for each.
+		queries := make([]*JsonObject, 0, len(s.Fields))
+		for _, f := range s.Fields {
+			queries = append(queries, d.buildSingleFieldTextQuery(f, args[1], s))
+		}
 
-		if f == "" {
-			f = "0"
+		if len(queries) == 1 {
+			return queries[0]
 		}
 
 		return &JsonObject{
-			"match_bool_prefix": map[string]any{
-				d.GetFieldMapping(args[0]).Text: map[string]any{
-					"query":     args[1],
-					"operator":  "and",
-					"fuzziness": f,
-				},
+			"bool": map[string]any{
+				"should":               queries,
+				"minimum_should_match": 1,
 			},
 		}
 	}
 }
 
+// GetTextStrategy returns the strategy to use for text() on the field.
+func (d DefaultEsQueryBuilder) GetTextStrategy(f string) TextStrategy {
+	if s, ok := d.FieldToTextStrategy[f]; ok && s != nil {
+		return *s
+	}
+
+	return d.DefaultTextStrategy
+}
+
+func (d DefaultEsQueryBuilder) buildSingleFieldTextQuery(field string, query string, s TextStrategy) *JsonObject {
+	if s.QueryType == TextQueryMatchPhrase {
+		return d.buildMatchPhraseQuery(field, query, s)
+	}
+
+	return d.buildMatchBoolPrefixQuery(field, query, s)
+}
+
+func (d DefaultEsQueryBuilder) BuildMatchBoolPrefixQuery() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		return d.buildMatchBoolPrefixQuery(d.GetFieldMapping(args[0]).Text, args[1], d.GetTextStrategy(args[0]))
+	}
+}
+
+func (d DefaultEsQueryBuilder) buildMatchBoolPrefixQuery(field string, query string, s TextStrategy) *JsonObject {
+	f := s.getFuzziness(d.DefaultFuzziness)
+
+	if f == "" {
+		f = "0"
+	}
+
+	options := map[string]any{
+		"query":     query,
+		"operator":  s.getOperator(),
+		"fuzziness": f,
+	}
+
+	s.addCommonOptions(options, "minimum_should_match", "analyzer")
+
+	return &JsonObject{
+		"match_bool_prefix": map[string]any{
+			field: options,
+		},
+	}
+}
+
+func (d DefaultEsQueryBuilder) BuildMatchPhraseQuery() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		return d.buildMatchPhraseQuery(d.GetFieldMapping(args[0]).Text, args[1], d.GetTextStrategy(args[0]))
+	}
+}
+
+func (d DefaultEsQueryBuilder) buildMatchPhraseQuery(field string, query string, s TextStrategy) *JsonObject {
+	options := map[string]any{
+		"query": query,
+	}
+
+	s.addCommonOptions(options, "analyzer")
+
+	return &JsonObject{
+		"match_phrase": map[string]any{
+			field: options,
+		},
+	}
+}
+
+func (d DefaultEsQueryBuilder) BuildMultiMatchQuery() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		s := d.GetTextStrategy(args[0])
+
+		options := map[string]any{
+			"query":    args[1],
+			"fields":   d.getTextFields(args[0], s),
+			"operator": s.getOperator(),
+		}
+
+		if f := s.getFuzziness(d.DefaultFuzziness); f != "" {
+			options["fuzziness"] = f
+		}
+
+		s.addCommonOptions(options, "minimum_should_match", "analyzer")
+
+		return &JsonObject{
+			"multi_match": options,
+		}
+	}
+}
+
+func (d DefaultEsQueryBuilder) BuildSimpleQueryStringQuery() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		s := d.GetTextStrategy(args[0])
+
+		options := map[string]any{
+			"query":            args[1],
+			"fields":           d.getTextFields(args[0], s),
+			"default_operator": s.getOperator(),
+		}
+
+		s.addCommonOptions(options, "minimum_should_match", "analyzer")
+
+		return &JsonObject{
+			"simple_query_string": options,
+		}
+	}
+}
+
+func (d DefaultEsQueryBuilder) BuildCombinedFieldsQuery() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		s := d.GetTextStrategy(args[0])
+
+		options := map[string]any{
+			"query":    args[1],
+			"fields":   d.getTextFields(args[0], s),
+			"operator": s.getOperator(),
+		}
+
+		s.addCommonOptions(options, "minimum_should_match")
+
+		return &JsonObject{
+			"combined_fields": options,
+		}
+	}
+}
+
+func (d DefaultEsQueryBuilder) getTextFields(f string, s TextStrategy) []string {
+	if len(s.Fields) > 0 {
+		return s.Fields
+	}
+
+	return []string{d.GetFieldMapping(f).Text}
+}
+
+func (t TextStrategy) getOperator() string {
+	if t.Operator == "" {
+		return "and"
+	}
+
+	return t.Operator
+}
+
+func (t TextStrategy) getFuzziness(defaultFuzziness string) string {
+	if t.Fuzziness == "" {
+		return defaultFuzziness
+	}
+
+	return t.Fuzziness
+}
+
+// addCommonOptions adds the supported options that have been set to the query options.
+func (t TextStrategy) addCommonOptions(options map[string]any, supported ...string) {
+	for _, o := range supported {
+		switch {
+		case o == "minimum_should_match" && t.MinimumShouldMatch != "":
+			options[o] = t.MinimumShouldMatch
+		case o == "analyzer" && t.Analyzer != "":
+			options[o] = t.Analyzer
+		}
+	}
+}
+
+func (t TextStrategy) validate() error {
+	switch t.QueryType {
+	case "", TextQueryMatchBoolPrefix, TextQueryMultiMatch:
+	case TextQueryMatchPhrase:
+		if t.Operator != "" || t.MinimumShouldMatch != "" || t.Fuzziness != "" {
+			return fmt.Errorf("match_phrase does not support operator, minimum_should_match or fuzziness")
+		}
+	case TextQuerySimpleQueryString:
+		if t.Fuzziness != "" {
+			return fmt.Errorf("simple_query_string does not support fuzziness")
+		}
+	case TextQueryCombinedFields:
+		if t.Fuzziness != "" || t.Analyzer != "" {
+			return fmt.Errorf("combined_fields does not support fuzziness or analyzer")
+		}
+	default:
+		return fmt.Errorf("unknown query type %s", t.QueryType)
+	}
+
+	switch t.Operator {
+	case "", "and", "or", "AND", "OR":
+	default:
+		return fmt.Errorf("unknown operator %s", t.Operator)
+	}
+
+	return nil
+}
+
 // Useful doc: https://www.elastic.co/guide/en/elasticsearch/reference/7.17/query-dsl-range-query.html
 func (d DefaultEsQueryBuilder) VisitLe(first, second string) (*JsonObject, error) {
 	b := d.GetLteRangeQueryBuilder()
 
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("le", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetLteRangeQueryBuilder() func(args ...string) *JsonObject {
@@ -297,7 +1076,7 @@ func (d DefaultEsQueryBuilder) GetLteRangeQueryBuilder() func(args ...string) *J
 func (d DefaultEsQueryBuilder) VisitLt(first, second string) (*JsonObject, error) {
 	b := d.GetLtRangeQueryBuilder()
 
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("lt", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetLtRangeQueryBuilder() func(args ...string) *JsonObject {
@@ -306,7 +1085,7 @@ func (d DefaultEsQueryBuilder) GetLtRangeQueryBuilder() func(args ...string) *Js
 
 func (d DefaultEsQueryBuilder) VisitGe(first, second string) (*JsonObject, error) {
 	b := d.GetGteRangeQueryBuilder()
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("ge", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetGteRangeQueryBuilder() func(args ...string) *JsonObject {
@@ -315,7 +1094,7 @@ func (d DefaultEsQueryBuilder) GetGteRangeQueryBuilder() func(args ...string) *J
 
 func (d DefaultEsQueryBuilder) VisitGt(first, second string) (*JsonObject, error) {
 	b := d.GetGtRangeQueryBuilder()
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("gt", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetGtRangeQueryBuilder() func(args ...string) *JsonObject {
@@ -324,11 +1103,19 @@ func (d DefaultEsQueryBuilder) GetGtRangeQueryBuilder() func(args ...string) *Js
 
 func (d DefaultEsQueryBuilder) GetRangeQueryBuilder(op string) func(args ...string) *JsonObject {
 	return func(args ...string) *JsonObject {
+		r := map[string]any{
+			op: d.ConvertValue(args[0], args[1]),
+		}
+
+		if d.FieldTypes[args[0]] == epsearchast.Date {
+			// Dates are always sent in RFC 3339 format, so we don't depend on the format in the mapping
+			// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-range-query.html#range-query-date-math-rounding
+			r["format"] = "strict_date_optional_time"
+		}
+
 		return &JsonObject{
 			"range": map[string]any{
-				d.GetFieldMapping(args[0]).Relational: map[string]any{
-					op: args[1],
-				},
+				d.GetFieldMapping(args[0]).Relational: r,
 			},
 		}
 	}
@@ -336,12 +1123,12 @@ func (d DefaultEsQueryBuilder) GetRangeQueryBuilder(op string) func(args ...stri
 
 func (d DefaultEsQueryBuilder) VisitLike(first, second string) (*JsonObject, error) {
 	b := d.GetCaseSensitiveWildcardQueryBuilder()
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("like", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) VisitILike(first, second string) (*JsonObject, error) {
 	b := d.GetCaseInsensitiveWildcardQueryBuilder()
-	return d.buildQueryWithBuilder(b, first, second)
+	return d.buildQueryWithBuilder("ilike", b, first, second)
 }
 
 func (d DefaultEsQueryBuilder) GetCaseInsensitiveWildcardQueryBuilder() func(args ...string) *JsonObject {
@@ -358,8 +1145,106 @@ func (d DefaultEsQueryBuilder) GetCaseInsensitiveWildcardQueryBuilder() func(arg
 }
 
 func (d DefaultEsQueryBuilder) VisitIsNull(first string) (*JsonObject, error) {
-	b := d.GetMustNotExistQueryBuilder()
-	return d.buildQueryWithBuilder(b, first)
+	switch s := d.NullSemantics.ForField(first); s {
+	case epsearchast.DefaultNullSemantics, epsearchast.NullOrMissingOrEmptyArray:
+		// For nested fields, we need to match documents where no element has the field, rather than documents where some element doesn't have it,
+		// so we negate a nested query for elements that have the field. Join fields are the same, no related document can have the field.
+		joinQuery, ok, err := d.processJoinFieldToQuery("is_null", d.GetExistsQueryBuilder(), first)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return &JsonObject{
+				"bool": map[string]any{
+					"must_not": joinQuery,
+				},
+			}, nil
+		}
+
+		nestedQuery, ok, err := d.processNestedFieldToQuery("is_null", d.GetExistsQueryBuilder(), first)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return &JsonObject{
+				"bool": map[string]any{
+					"must_not": nestedQuery,
+				},
+			}, nil
+		}
+
+		b := d.GetIsNullQueryBuilder(s)
+		return d.buildQueryWithBuilder("is_null", b, first)
+	case epsearchast.ExplicitNullOnly:
+		// Only fields mapped with a null_value index explicit nulls.
+		if d.GetFieldMapping(first).NullValue != "" {
+			b := d.GetNullValueQueryBuilder()
+			return d.buildQueryWithBuilder("is_null", b, first)
+		}
+
+		return nil, fmt.Errorf("null semantics %s are not supported in Elasticsearch for field [%s] unless it has a NullValue", s, first)
+	default:
+		return nil, fmt.Errorf("null semantics %s are not supported in Elasticsearch for field [%s]", s, first)
+	}
+}
+
+func (d DefaultEsQueryBuilder) GetIsNullQueryBuilder(s epsearchast.NullSemantics) func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		if d.GetFieldMapping(args[0]).NullValue == "" {
+			return d.GetMustNotExistQueryBuilder()(args...)
+		}
+
+		if s == epsearchast.NullOrMissingOrEmptyArray {
+			return &JsonObject{
+				"bool": map[string]any{
+					"must_not": d.GetExistsQueryBuilder()(args...),
+				},
+			}
+		}
+
+		return d.GetNullValueQueryBuilder()(args...)
+	}
+}
+
+// GetNullValueQueryBuilder returns a builder that matches the null_value of the field (i.e., the field is explicitly null).
+func (d DefaultEsQueryBuilder) GetNullValueQueryBuilder() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		m := d.GetFieldMapping(args[0])
+
+		return &JsonObject{
+			"term": map[string]any{
+				m.Equality: d.ConvertValue(args[0], m.NullValue),
+			},
+		}
+	}
+}
+
+// GetExistsQueryBuilder returns a builder that matches fields that have a value, if the field has a NullValue, explicit nulls are excluded.
+func (d DefaultEsQueryBuilder) GetExistsQueryBuilder() func(args ...string) *JsonObject {
+	return func(args ...string) *JsonObject {
+		m := d.GetFieldMapping(args[0])
+
+		exists := &JsonObject{
+			"exists": map[string]any{
+				"field": m.Equality,
+			},
+		}
+
+		if m.NullValue == "" {
+			return exists
+		}
+
+		return &JsonObject{
+			"bool": map[string]any{
+				"filter":   []*JsonObject{exists},
+				"must_not": []*JsonObject{d.GetNullValueQueryBuilder()(args...)},
+			},
+		}
+	}
 }
 
 func (d DefaultEsQueryBuilder) GetCaseSensitiveWildcardQueryBuilder() func(args ...string) *JsonObject {
@@ -410,6 +1295,7 @@ func (d DefaultEsQueryBuilder) GetFieldMapping(f string) *OperatorTypeToMultiFie
 			Text:       v.Text,
 			Array:      v.Array,
 			Wildcard:   v.Wildcard,
+			NullValue:  v.NullValue,
 		}
 
 		if o.Equality == "" {
@@ -436,6 +1322,98 @@ func (d DefaultEsQueryBuilder) GetFieldMapping(f string) *OperatorTypeToMultiFie
 	return o
 }
 
+// scoringQueryTypes are the queries that contribute to relevance and belong in bool.must even with UseFilterContext.
+var scoringQueryTypes = map[string]bool{
+	"match_bool_prefix":   true,
+	"match":               true,
+	"match_phrase":        true,
+	"multi_match":         true,
+	"simple_query_string": true,
+	"combined_fields":     true,
+}
+
+// isScoringQuery returns true if the query contains a full text query that should contribute to scoring.
+func isScoringQuery(q map[string]any) bool {
+	for k, v := range q {
+		if scoringQueryTypes[k] {
+			return true
+		}
+
+		switch k {
+		case "filter", "must_not":
+			// Clauses in these are executed in filter context and never contribute to the score.
+			continue
+		}
+
+		switch c := v.(type) {
+		case JsonObject:
+			if isScoringQuery(c) {
+				return true
+			}
+		case map[string]any:
+			if isScoringQuery(c) {
+				return true
+			}
+		case []*JsonObject:
+			for _, e := range c {
+				if e != nil && isScoringQuery(*e) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// ValidateArgs checks the arguments for the operator against FieldTypes, values must be convertible to the type of the field,
+// and like(), ilike() and text() are only supported on string fields.
+func (d DefaultEsQueryBuilder) ValidateArgs(operator string, args ...string) error {
+	fieldType, ok := d.FieldTypes[args[0]]
+	if !ok {
+		return nil
+	}
+
+	switch operator {
+	case "like", "ilike", "text":
+		if fieldType != epsearchast.String {
+			return fmt.Errorf("%s() operator is only supported for string fields, and [%s] is not a string", operator, args[0])
+		}
+		return nil
+	case "is_null":
+		return nil
+	default:
+		return epsearchast.ValidateAllValues(fieldType, args[1:]...)
+	}
+}
+
+// ConvertValue converts the value to the type configured for the field in FieldTypes, values should already have been validated.
+func (d DefaultEsQueryBuilder) ConvertValue(fieldName string, v string) any {
+	fieldType, ok := d.FieldTypes[fieldName]
+	if !ok {
+		return v
+	}
+
+	newV, _ := epsearchast.Convert(fieldType, v)
+
+	if t, ok := newV.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	return newV
+}
+
+// ConvertValues converts all the values with ConvertValue.
+func (d DefaultEsQueryBuilder) ConvertValues(fieldName string, v ...string) []any {
+	newV := make([]any, 0, len(v))
+
+	for _, value := range v {
+		newV = append(newV, d.ConvertValue(fieldName, value))
+	}
+
+	return newV
+}
+
 func (d DefaultEsQueryBuilder) EscapeWildcardString(s string) string {
 	str := strings.ReplaceAll(s, "?", `\?`)
 	str = strings.ReplaceAll(str, "*", `\*`)
@@ -451,8 +1429,23 @@ func (d DefaultEsQueryBuilder) EscapeWildcardString(s string) string {
 	return str
 }
 
-func (d DefaultEsQueryBuilder) buildQueryWithBuilder(b func(args ...string) *JsonObject, args ...string) (*JsonObject, error) {
-	nestedQuery, ok, err := d.processNestedFieldToQuery(b, args...)
+func (d DefaultEsQueryBuilder) buildQueryWithBuilder(operator string, b func(args ...string) *JsonObject, args ...string) (*JsonObject, error) {
+	joinQuery, ok, err := d.processJoinFieldToQuery(operator, b, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return joinQuery, nil
+	}
+
+	return d.buildFieldQueryWithBuilder(operator, b, args...)
+}
+
+// buildFieldQueryWithBuilder builds the query for a field in the document (i.e., after JoinFieldToQuery has been processed).
+func (d DefaultEsQueryBuilder) buildFieldQueryWithBuilder(operator string, b func(args ...string) *JsonObject, args ...string) (*JsonObject, error) {
+	nestedQuery, ok, err := d.processNestedFieldToQuery(operator, b, args...)
 
 	if err != nil {
 		return nil, err
@@ -462,23 +1455,69 @@ func (d DefaultEsQueryBuilder) buildQueryWithBuilder(b func(args ...string) *Jso
 		return nestedQuery, nil
 	}
 
+	if err := d.ValidateArgs(operator, args...); err != nil {
+		return nil, err
+	}
+
 	return b(args...), nil
 }
 
+// processJoinFieldToQuery converts a request for a field that is stored in a related document (e.g., eq(skus.sku_code,foo)) into a has_child or has_parent query
+// that searches the field in the related document.
+func (d DefaultEsQueryBuilder) processJoinFieldToQuery(operator string, builder func(args ...string) *JsonObject, args ...string) (*JsonObject, bool, error) {
+	if len(args) < 1 {
+		return nil, false, fmt.Errorf("no arguments provided")
+	}
+
+	var joinQuery *JsonObject = nil
+
+	numMatches := 0
+	for _, f := range d.getJoinFields() {
+		if !f.pattern.MatchString(args[0]) {
+			continue
+		}
+
+		numMatches++
+
+		v := f.replacement
+
+		field := args[0]
+		if v.Field != "" {
+			field, _ = applyPatternGroupsToFieldNameAndValue(v.Field, "", extractNamedGroupsFromSearchField(f.pattern, args[0]))
+		}
+
+		replacedArgs := append([]string{field}, args[1:]...)
+
+		q, err := d.buildFieldQueryWithBuilder(operator, builder, replacedArgs...)
+		if err != nil {
+			return nil, false, err
+		}
+
+		joinQuery = buildJoinQuery(v.QueryType, v.Relation, q)
+	}
+
+	if numMatches > 1 {
+		return nil, false, fmt.Errorf("found more than one join field for %s", args[0])
+	}
+
+	return joinQuery, numMatches == 1, nil
+}
+
 // processNestedFieldToQuery converts a request for a field that is embedded in an ES nested object into an AND query that indexes into the object by the ID, and then searches the field
 // in a nutshell, we can't query eq(field[0].foo, bar), we need to do eq(field.foo, bar):eq(field.id,0). In ES we also need to wrap this in another nested object.
 // builder essentially takes the arguments and returns the subquery, it changes whether or not we need to build a match, term, range or other ES query.
-func (d DefaultEsQueryBuilder) processNestedFieldToQuery(builder func(args ...string) *JsonObject, args ...string) (*JsonObject, bool, error) {
+func (d DefaultEsQueryBuilder) processNestedFieldToQuery(operator string, builder func(args ...string) *JsonObject, args ...string) (*JsonObject, bool, error) {
 
 	var nestedQuery *JsonObject = nil
 
-	numMatches := 0
-	for k, v := range d.NestedFieldToQuery {
-		pattern := regexp.MustCompile(k)
+	if len(args) < 1 {
+		return nil, false, fmt.Errorf("no arguments provided")
+	}
 
-		if len(args) < 1 {
-			return nil, false, fmt.Errorf("no arguments provided")
-		}
+	numMatches := 0
+	for _, nf := range d.getNestedFields() {
+		pattern := nf.pattern
+		v := nf.replacement
 
 		searchField := args[0]
 		if pattern.MatchString(searchField) {
@@ -523,8 +1562,16 @@ func (d DefaultEsQueryBuilder) processNestedFieldToQuery(builder func(args ...st
 						replacedArgs = append(replacedArgs, sqValue)
 					}
 
+					if err := d.ValidateArgs("eq", replacedArgs[0], replacedArgs[1]); err != nil {
+						return nil, false, err
+					}
+
 					musts = append(musts, d.GetTermQueryBuilderForEqualityField()(replacedArgs...))
 				} else {
+					if err := d.ValidateArgs(operator, replacedArgs...); err != nil {
+						return nil, false, err
+					}
+
 					musts = append(musts, builder(replacedArgs...))
 				}
 
@@ -541,6 +1588,10 @@ func (d DefaultEsQueryBuilder) processNestedFieldToQuery(builder func(args ...st
 				},
 			}
 
+			if nf.ordered {
+				// The first ordered pattern that matches wins.
+				break
+			}
 		}
 	}
 
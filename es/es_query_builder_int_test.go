@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"maps"
 	"net/http"
 	"os"
 	"testing"
@@ -41,6 +42,8 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 			"array_field":           []string{"a", "b"},
 			"nullable_string_field": nil,
 			"text_field":            "Developers like IDEs",
+			"number_field":          5,
+			"date_field":            "2024-01-01T00:00:00Z",
 			"key_value_field": []map[string]any{
 				{
 					"alpha":       "a",
@@ -63,6 +66,8 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 			"array_field":           []string{"c", "d"},
 			"nullable_string_field": "yay",
 			"text_field":            "I like Development Environments",
+			"number_field":          10.5,
+			"date_field":            "2024-06-15T00:00:00Z",
 			"key_value_field": []map[string]any{
 				{
 					"alpha":       "b",
@@ -93,6 +98,8 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 			"string_field": "test3",
 			"array_field":  []string{"c"},
 			"text_field":   "Vim is the best",
+			"number_field": 100,
+			"date_field":   "2024-12-31T00:00:00Z",
 		},
 	}
 
@@ -1024,6 +1031,81 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 					}`,
 			count: 0,
 		},
+		{
+			// Test text(*) expands to the fields configured in FieldToTextStrategy
+			//language=JSON
+			filter: `{
+						"type": "TEXT",
+						"args": ["*", "vim"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "TEXT",
+						"args": ["*", "test2"]
+					}`,
+			count: 1,
+		},
+		{
+			// Test is_null on a nested field matches documents where no element has the field (the second and third documents have no element a)
+			//language=JSON
+			filter: `{
+						"type": "IS_NULL",
+						"args": ["key_value_field.a.description"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IS_NULL",
+						"args": ["key_value_field.c.description"]
+					}`,
+			count: 1,
+		},
+		{
+			// Test numeric comparison (lexicographically "9" > "10.5")
+			//language=JSON
+			filter: `{
+						"type": "GT",
+						"args": ["number_field", "9"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["number_field", "10.5"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IN",
+						"args": ["number_field", "5", "100"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "LT",
+						"args": ["date_field", "2024-06-01"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "GE",
+						"args": ["date_field", "2024-06-15T00:00:00Z"]
+					}`,
+			count: 2,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1081,6 +1163,17 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 					},
 				},
 				DefaultFuzziness: "AUTO",
+				FieldTypes: map[string]epsearchast.FieldType{
+					"number_field": epsearchast.Float64,
+					"date_field":   epsearchast.Date,
+				},
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"*": {
+						QueryType: TextQueryMultiMatch,
+						Fields:    []string{"text_field", "string_field"},
+						Fuzziness: "0",
+					},
+				},
 			}
 
 			query, err := epsearchast.SemanticReduceAst(ast, qb)
@@ -1103,14 +1196,341 @@ func TestSmokeTestElasticSearchWithFilters(t *testing.T) {
 	}
 }
 
+func TestSmokeTestElasticSearchIsNullWithNullSemantics(t *testing.T) {
+	// This is the same logical data set used in the Postgres and Mongo tests.
+	documents := []map[string]any{
+		{
+			"string_field": "explicit_null",
+			"array_field":  nil,
+		},
+		{
+			"string_field": "has_value",
+			"array_field":  []string{"a"},
+		},
+		{
+			"string_field": "missing",
+		},
+		{
+			"string_field": "empty_array",
+			"array_field":  []string{},
+		},
+	}
+
+	var testCases = []struct {
+		semantics epsearchast.NullSemantics
+		count     int64
+		supported bool
+	}{
+		{epsearchast.ExplicitNullOnly, 1, false},
+		{epsearchast.MissingOnly, 1, false},
+		{epsearchast.NullOrMissing, 2, false},
+		{epsearchast.NullOrMissingOrEmptyArray, 3, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			var indexName = "test_index"
+			err := deleteIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to delete index: %v", err)
+			}
+
+			err = createIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to create index: %v", err)
+			}
+
+			err = insertDocuments(indexName, documents)
+			if err != nil {
+				t.Fatalf("Failed to insert documents: %v", err)
+			}
+
+			ast, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["array_field"]}`)
+			if err != nil {
+				t.Fatalf("Failed to parse filter: %v", err)
+			}
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+
+			if !tc.supported {
+				if err == nil {
+					t.Fatalf("Expected an error for unsupported null semantics %s", tc.semantics)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to reduce AST: %v", err)
+			}
+
+			count, err := countDocuments(indexName, query)
+			if err != nil {
+				t.Fatalf("Failed to query Elasticsearch: %v", err)
+			}
+
+			if count != tc.count {
+				txt, _ := json.MarshalIndent(query, "", "  ")
+				t.Errorf("Expected count %d, but got %d with query\n%s", tc.count, count, txt)
+			}
+		})
+	}
+}
+
+func TestSmokeTestElasticSearchIsNullWithNullValue(t *testing.T) {
+	documents := []map[string]any{
+		{
+			"string_field":           "explicit_null",
+			"null_value_array_field": nil,
+		},
+		{
+			"string_field":           "has_value",
+			"null_value_array_field": []string{"a"},
+		},
+		{
+			"string_field": "missing",
+		},
+		{
+			"string_field":           "empty_array",
+			"null_value_array_field": []string{},
+		},
+	}
+
+	var testCases = []struct {
+		semantics epsearchast.NullSemantics
+		count     int64
+	}{
+		{epsearchast.DefaultNullSemantics, 1},
+		{epsearchast.ExplicitNullOnly, 1},
+		{epsearchast.NullOrMissingOrEmptyArray, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			var indexName = "test_index"
+			err := deleteIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to delete index: %v", err)
+			}
+
+			err = createIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to create index: %v", err)
+			}
+
+			err = insertDocuments(indexName, documents)
+			if err != nil {
+				t.Fatalf("Failed to insert documents: %v", err)
+			}
+
+			ast, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["null_value_array_field"]}`)
+			if err != nil {
+				t.Fatalf("Failed to parse filter: %v", err)
+			}
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+					"null_value_array_field": {
+						NullValue: "NULL",
+					},
+				},
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+			if err != nil {
+				t.Fatalf("Failed to reduce AST: %v", err)
+			}
+
+			count, err := countDocuments(indexName, query)
+			if err != nil {
+				t.Fatalf("Failed to query Elasticsearch: %v", err)
+			}
+
+			if count != tc.count {
+				txt, _ := json.MarshalIndent(query, "", "  ")
+				t.Errorf("Expected count %d, but got %d with query\n%s", tc.count, count, txt)
+			}
+		})
+	}
+}
+
+func TestSmokeTestElasticSearchContainsAllWithTermsSet(t *testing.T) {
+	documents := []map[string]any{
+		{
+			"array_field": []string{"a", "b"},
+		},
+		{
+			"array_field": []string{"c", "d"},
+		},
+		{
+			"array_field": []string{"c"},
+		},
+	}
+
+	var testCases = []struct {
+		filter string
+		count  int64
+	}{
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "a", "b"]}`, 1},
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "c"]}`, 2},
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "d", "c"]}`, 1},
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "c", "c"]}`, 2},
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "a", "c"]}`, 0},
+		{`{"type": "CONTAINS_ALL", "args": ["array_field", "a", "b", "c"]}`, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			var indexName = "test_index"
+			err := deleteIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to delete index: %v", err)
+			}
+
+			err = createIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to create index: %v", err)
+			}
+
+			err = insertDocuments(indexName, documents)
+			if err != nil {
+				t.Fatalf("Failed to insert documents: %v", err)
+			}
+
+			ast, err := epsearchast.GetAst(tc.filter)
+			if err != nil {
+				t.Fatalf("Failed to parse filter: %v", err)
+			}
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				UseTermsSetForContainsAll: true,
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+			if err != nil {
+				t.Fatalf("Failed to reduce AST: %v", err)
+			}
+
+			count, err := countDocuments(indexName, query)
+			if err != nil {
+				t.Fatalf("Failed to query Elasticsearch: %v", err)
+			}
+
+			if count != tc.count {
+				txt, _ := json.MarshalIndent(query, "", "  ")
+				t.Errorf("Expected count %d, but got %d with query\n%s", tc.count, count, txt)
+			}
+		})
+	}
+}
+
+func TestSmokeTestElasticSearchJoinFields(t *testing.T) {
+	documents := []map[string]any{
+		{"_id": "p1", "_routing": "p1", "string_field": "p1", "join_field": "product"},
+		{"_id": "p2", "_routing": "p2", "string_field": "p2", "join_field": "product"},
+		{"_id": "s1", "_routing": "p1", "array_field": []string{"red"}, "number_field": 5, "join_field": map[string]any{"name": "sku", "parent": "p1"}},
+		{"_id": "s2", "_routing": "p1", "array_field": []string{"blue"}, "number_field": 0, "join_field": map[string]any{"name": "sku", "parent": "p1"}},
+		{"_id": "s3", "_routing": "p2", "array_field": []string{"blue"}, "number_field": 3, "join_field": map[string]any{"name": "sku", "parent": "p2"}},
+	}
+
+	var testCases = []struct {
+		filter string
+		count  int64
+	}{
+		{`{"type": "EQ", "args": ["skus.number_field", "5"]}`, 1},
+		{`{"type": "CONTAINS", "args": ["skus.array_field", "blue"]}`, 2},
+		// Both predicates must match the same sku, so the first product doesn't match
+		{`{"type": "AND", "children": [{"type": "CONTAINS", "args": ["skus.array_field", "blue"]}, {"type": "GT", "args": ["skus.number_field", "1"]}]}`, 1},
+		{`{"type": "AND", "children": [{"type": "CONTAINS", "args": ["skus.array_field", "red"]}, {"type": "CONTAINS", "args": ["skus.array_field", "blue"]}]}`, 0},
+		{`{"type": "EQ", "args": ["product.string_field", "p1"]}`, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			var indexName = "test_index"
+			err := deleteIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to delete index: %v", err)
+			}
+
+			err = createIndex(indexName)
+			if err != nil {
+				t.Fatalf("Failed to create index: %v", err)
+			}
+
+			err = insertDocuments(indexName, documents)
+			if err != nil {
+				t.Fatalf("Failed to insert documents: %v", err)
+			}
+
+			ast, err := epsearchast.GetAst(tc.filter)
+			if err != nil {
+				t.Fatalf("Failed to parse filter: %v", err)
+			}
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				JoinFieldToQuery: map[string]JoinReplacement{
+					`^skus\.(?P<field>[a-z_]+)$`: {
+						QueryType: JoinHasChild,
+						Relation:  "sku",
+						Field:     "$field",
+					},
+					`^product\.(?P<field>[a-z_]+)$`: {
+						QueryType: JoinHasParent,
+						Relation:  "product",
+						Field:     "$field",
+					},
+				},
+				FieldTypes: map[string]epsearchast.FieldType{
+					"number_field": epsearchast.Float64,
+				},
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+			if err != nil {
+				t.Fatalf("Failed to reduce AST: %v", err)
+			}
+
+			count, err := countDocuments(indexName, query)
+			if err != nil {
+				t.Fatalf("Failed to query Elasticsearch: %v", err)
+			}
+
+			if count != tc.count {
+				txt, _ := json.MarshalIndent(query, "", "  ")
+				t.Errorf("Expected count %d, but got %d with query\n%s", tc.count, count, txt)
+			}
+		})
+	}
+}
+
 func insertDocuments(index string, documents []map[string]any) error {
 	for _, doc := range documents {
+		url := fmt.Sprintf("%s/%s/_doc", esBaseURL, index)
+
+		// Documents that are related with the join field need an id, and children need to be routed to the same shard as their parent.
+		if id, ok := doc["_id"]; ok {
+			url = fmt.Sprintf("%s/%s/_doc/%s?routing=%s", esBaseURL, index, id, doc["_routing"])
+
+			doc = maps.Clone(doc)
+			delete(doc, "_id")
+			delete(doc, "_routing")
+		}
+
 		body, err := json.Marshal(doc)
 		if err != nil {
 			return err
 		}
 
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/%s/_doc", esBaseURL, index), bytes.NewBuffer(body))
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 		if err != nil {
 			return err
 		}
@@ -1205,6 +1625,22 @@ func createIndex(indexName string) error {
 					"type":     "text",
 					"analyzer": "english", // Enables stemming
 				},
+				"number_field": map[string]any{
+					"type": "double",
+				},
+				"null_value_array_field": map[string]any{
+					"type":       "keyword",
+					"null_value": "NULL",
+				},
+				"date_field": map[string]any{
+					"type": "date",
+				},
+				"join_field": map[string]any{
+					"type": "join",
+					"relations": map[string]any{
+						"product": "sku",
+					},
+				},
 				"key_value_field": map[string]any{
 					"type": "nested",
 					"properties": map[string]any{
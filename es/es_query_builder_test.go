@@ -807,6 +807,116 @@ func TestSimpleBinaryTextOperatorGeneratesCorrectQueryWithFuzzinessSetting(t *te
 	require.Equal(t, expectedJson, string(queryJson))
 }
 
+func TestSimpleBinaryTextOperatorGeneratesCorrectQueryWithTextStrategy(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		field        string
+		expectedJson string
+	}{
+		{
+			name:         "default strategy",
+			field:        "description",
+			expectedJson: `{"match_bool_prefix":{"description":{"fuzziness":"AUTO","operator":"and","query":"red shoes"}}}`,
+		},
+		{
+			name:         "match_bool_prefix with options",
+			field:        "name",
+			expectedJson: `{"match_bool_prefix":{"name.text":{"analyzer":"english","fuzziness":"1","minimum_should_match":"75%","operator":"or","query":"red shoes"}}}`,
+		},
+		{
+			name:         "match_phrase",
+			field:        "title",
+			expectedJson: `{"match_phrase":{"title":{"analyzer":"standard","query":"red shoes"}}}`,
+		},
+		{
+			name:         "multi_match",
+			field:        "summary",
+			expectedJson: `{"multi_match":{"fields":["summary","summary.english^2"],"fuzziness":"AUTO","operator":"and","query":"red shoes"}}`,
+		},
+		{
+			name:         "simple_query_string",
+			field:        "body",
+			expectedJson: `{"simple_query_string":{"default_operator":"or","fields":["body"],"minimum_should_match":"2","query":"red shoes"}}`,
+		},
+		{
+			name:         "combined_fields",
+			field:        "article",
+			expectedJson: `{"combined_fields":{"fields":["article.title","article.body"],"operator":"and","query":"red shoes"}}`,
+		},
+		{
+			name:         "wildcard field expands to configured fields",
+			field:        "*",
+			expectedJson: `{"bool":{"minimum_should_match":1,"should":[{"match_phrase":{"name":{"query":"red shoes"}}},{"match_phrase":{"description":{"query":"red shoes"}}}]}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(fmt.Sprintf(`{"type": "TEXT", "args": ["%s", "red shoes"]}`, tc.field))
+			require.NoError(t, err)
+
+			qb := DefaultEsQueryBuilder{
+				DefaultFuzziness: "AUTO",
+				OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+					"name": {
+						Text: "name.text",
+					},
+				},
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"name":    {Operator: "or", MinimumShouldMatch: "75%", Analyzer: "english", Fuzziness: "1"},
+					"title":   {QueryType: TextQueryMatchPhrase, Analyzer: "standard"},
+					"summary": {QueryType: TextQueryMultiMatch, Fields: []string{"summary", "summary.english^2"}},
+					"body":    {QueryType: TextQuerySimpleQueryString, Operator: "or", MinimumShouldMatch: "2"},
+					"article": {QueryType: TextQueryCombinedFields, Fields: []string{"article.title", "article.body"}},
+					"*":       {QueryType: TextQueryMatchPhrase, Fields: []string{"name", "description"}},
+				},
+			}
+
+			qb.MustValidate()
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[JsonObject](qb))
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.Marshal(query)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(queryJson))
+		})
+	}
+}
+
+func TestMustValidatePanicsWhenTextStrategyIsInvalid(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		strategy TextStrategy
+	}{
+		{"unknown query type", TextStrategy{QueryType: "query_string"}},
+		{"unknown operator", TextStrategy{Operator: "xor"}},
+		{"match_phrase with operator", TextStrategy{QueryType: TextQueryMatchPhrase, Operator: "or"}},
+		{"simple_query_string with fuzziness", TextStrategy{QueryType: TextQuerySimpleQueryString, Fuzziness: "AUTO"}},
+		{"combined_fields with analyzer", TextStrategy{QueryType: TextQueryCombinedFields, Analyzer: "english"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Fixture Setup
+			qb := DefaultEsQueryBuilder{
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"name": &tc.strategy,
+				},
+			}
+
+			// Execute SUT & Verification
+			assert.Panics(t, func() {
+				qb.MustValidate()
+			})
+		})
+	}
+}
+
 func TestSimpleUnaryIsNullOperatorGeneratesCorrectQuery(t *testing.T) {
 	//Fixture Setup
 	//language=JSON
@@ -889,6 +999,213 @@ func TestSimpleUnaryIsNullOperatorGeneratesCorrectQueryWithFieldOverride(t *test
 	require.Equal(t, expectedJson, string(queryJson))
 }
 
+func TestSimpleUnaryIsNullOperatorGeneratesErrorWithUnsupportedNullSemantics(t *testing.T) {
+	for _, semantics := range []epsearchast.NullSemantics{epsearchast.ExplicitNullOnly, epsearchast.MissingOnly, epsearchast.NullOrMissing} {
+		t.Run(semantics.String(), func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			jsonTxt := `{
+  "type": "IS_NULL",
+  "args": [
+    "sort_order"
+  ]
+}
+`
+
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					FieldOverrides: map[string]epsearchast.NullSemantics{
+						"sort_order": semantics,
+					},
+				},
+			}
+
+			// Execute SUT
+			_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.ErrorContains(t, err, "are not supported in Elasticsearch")
+		})
+	}
+}
+
+func TestSimpleUnaryIsNullOperatorGeneratesCorrectQueryWithNullValue(t *testing.T) {
+	var testCases = []struct {
+		semantics    epsearchast.NullSemantics
+		expectedJson string
+	}{
+		{epsearchast.DefaultNullSemantics, `{"term":{"sort_order":-1}}`},
+		{epsearchast.ExplicitNullOnly, `{"term":{"sort_order":-1}}`},
+		{epsearchast.NullOrMissingOrEmptyArray, `{"bool":{"must_not":{"bool":{"filter":[{"exists":{"field":"sort_order"}}],"must_not":[{"term":{"sort_order":-1}}]}}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			jsonTxt := `{"type": "IS_NULL", "args": ["sort_order"]}`
+
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			qb := DefaultEsQueryBuilder{
+				OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+					"sort_order": {
+						NullValue: "-1",
+					},
+				},
+				FieldTypes: map[string]epsearchast.FieldType{
+					"sort_order": epsearchast.Int64,
+				},
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+			}
+
+			qb.MustValidate()
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[JsonObject](qb))
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.Marshal(query)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(queryJson))
+		})
+	}
+}
+
+func TestSimpleUnaryIsNullOperatorGeneratesErrorWithExplicitNullOnlyAndNoNullValue(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["sort_order"]}`)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			Default: epsearchast.ExplicitNullOnly,
+		},
+	}
+
+	// Execute SUT
+	_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.ErrorContains(t, err, "unless it has a NullValue")
+}
+
+func TestSimpleUnaryIsNullOperatorGeneratesCorrectQueryForNestedField(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		opTypeToFieldNames map[string]*OperatorTypeToMultiFieldName
+		expectedJson       string
+	}{
+		{
+			name:         "without null value",
+			expectedJson: `{"bool":{"must_not":{"nested":{"path":"nested","query":{"bool":{"must":[{"exists":{"field":"nested.description"}},{"term":{"nested.id":"abc"}}]}}}}}}`,
+		},
+		{
+			name: "with null value",
+			opTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+				"nested.description": {
+					Equality:  "nested.description.keyword",
+					NullValue: "NULL",
+				},
+			},
+			expectedJson: `{"bool":{"must_not":{"nested":{"path":"nested","query":{"bool":{"must":[` +
+				`{"bool":{"filter":[{"exists":{"field":"nested.description.keyword"}}],"must_not":[{"term":{"nested.description.keyword":"NULL"}}]}},` +
+				`{"term":{"nested.id":"abc"}}]}}}}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			jsonTxt := `{"type": "IS_NULL", "args": ["nested[abc].description"]}`
+
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				OpTypeToFieldNames: tc.opTypeToFieldNames,
+				NestedFieldToQuery: map[string]NestedReplacement{
+					`^nested\[(?P<id>[a-z]+)\]\.(?P<attr>[a-z]+)$`: {
+						Path: "nested",
+						Subqueries: map[string]Replacement{
+							"nested.id":    {Value: "$id", ForceEQ: true},
+							"nested.$attr": {Value: "$value"},
+						},
+					},
+				},
+			}
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, qb)
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.Marshal(query)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(queryJson))
+		})
+	}
+}
+
+func TestMustValidatePanicsWhenNullValueIsInvalidForFieldType(t *testing.T) {
+	// Fixture Setup
+	qb := DefaultEsQueryBuilder{
+		OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+			"sort_order": {
+				NullValue: "NULL",
+			},
+		},
+		FieldTypes: map[string]epsearchast.FieldType{
+			"sort_order": epsearchast.Int64,
+		},
+	}
+
+	// Execute SUT & Verification
+	assert.Panics(t, func() {
+		qb.MustValidate()
+	})
+}
+
+func TestMustValidatePanicsWithUnsupportedNullSemantics(t *testing.T) {
+	var testCases = map[string]struct {
+		nullSemantics epsearchast.NullSemanticsConfig
+		expected      string
+	}{
+		"unsupported default": {
+			nullSemantics: epsearchast.NullSemanticsConfig{Default: epsearchast.NullOrMissing},
+			expected:      "Invalid null semantics: null semantics null_or_missing are not supported in Elasticsearch",
+		},
+		"explicit null only without null value": {
+			nullSemantics: epsearchast.NullSemanticsConfig{FieldOverrides: map[string]epsearchast.NullSemantics{"status": epsearchast.ExplicitNullOnly}},
+			expected:      "Invalid null semantics: null semantics explicit_null_only are not supported in Elasticsearch for field [status] unless it has a NullValue",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			qb := DefaultEsQueryBuilder{
+				NullSemantics: tc.nullSemantics,
+			}
+
+			// Execute SUT & Verification
+			assert.PanicsWithValue(t, tc.expected, func() {
+				qb.MustValidate()
+			})
+		})
+	}
+}
+
 func TestMustValidateDoesNotPanicOnEmptyObject(t *testing.T) {
 	// Fixture Setup
 	qb := DefaultEsQueryBuilder{}
@@ -929,6 +1246,20 @@ func TestMustValidatePanicsWhenRegexDoesNotHaveStartAnchor(t *testing.T) {
 
 }
 
+func TestMustValidatePanicsWhenRegexIsEmpty(t *testing.T) {
+	// Fixture Setup
+	qb := DefaultEsQueryBuilder{NestedFieldToQuery: map[string]NestedReplacement{
+		"": {
+			Path: "foo",
+		},
+	}}
+
+	// Execute SUT & Verification
+	assert.PanicsWithValue(t, "All nested fields must be anchored to the start of the string (e.g., start with a ^), [] does not", func() {
+		qb.MustValidate()
+	})
+}
+
 func TestMustValidatePanicsWhenRegexDoesNotHaveEndAnchor(t *testing.T) {
 	// Fixture Setup
 	qb := DefaultEsQueryBuilder{NestedFieldToQuery: map[string]NestedReplacement{
@@ -1242,14 +1573,1167 @@ func TestMultipleReplacementFieldsInNestedObjectAreSortedInDeterministicOrder(t
 
 }
 
-type LowerCaseEmail struct {
-	DefaultEsQueryBuilder
+func TestFieldTypesGeneratesCorrectQuery(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		filter       string
+		expectedJson string
+	}{
+		{
+			name: "int64 range",
+			//language=JSON
+			filter:       `{"type": "GT", "args": ["quantity", "10"]}`,
+			expectedJson: `{"range":{"quantity":{"gt":10}}}`,
+		},
+		{
+			name: "float64 range",
+			//language=JSON
+			filter:       `{"type": "LE", "args": ["price", "10.5"]}`,
+			expectedJson: `{"range":{"price":{"lte":10.5}}}`,
+		},
+		{
+			name: "date range",
+			//language=JSON
+			filter:       `{"type": "GE", "args": ["created_at", "2024-01-01"]}`,
+			expectedJson: `{"range":{"created_at":{"format":"strict_date_optional_time","gte":"2024-01-01T00:00:00Z"}}}`,
+		},
+		{
+			name: "boolean term",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["active", "true"]}`,
+			expectedJson: `{"term":{"active":true}}`,
+		},
+		{
+			name: "int64 terms",
+			//language=JSON
+			filter:       `{"type": "IN", "args": ["quantity", "1", "2"]}`,
+			expectedJson: `{"terms":{"quantity":[1,2]}}`,
+		},
+		{
+			name: "int64 contains_any",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ANY", "args": ["quantity", "1", "2"]}`,
+			expectedJson: `{"terms":{"quantity":[1,2]}}`,
+		},
+		{
+			name: "string field",
+			//language=JSON
+			filter:       `{"type": "LIKE", "args": ["status", "live*"]}`,
+			expectedJson: `{"wildcard":{"status":{"case_insensitive":false,"value":"live*"}}}`,
+		},
+		{
+			name: "untyped field",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["sku", "true"]}`,
+			expectedJson: `{"term":{"sku":"true"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				FieldTypes: map[string]epsearchast.FieldType{
+					"quantity":   epsearchast.Int64,
+					"price":      epsearchast.Float64,
+					"active":     epsearchast.Boolean,
+					"created_at": epsearchast.Date,
+					"status":     epsearchast.String,
+				},
+			}
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, qb)
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.Marshal(query)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(queryJson))
+		})
+	}
 }
 
-func (l *LowerCaseEmail) VisitEq(first, second string) (*JsonObject, error) {
-	if first == "email" {
-		return DefaultEsQueryBuilder.VisitEq(l.DefaultEsQueryBuilder, first, strings.ToLower(second))
-	} else {
-		return DefaultEsQueryBuilder.VisitEq(l.DefaultEsQueryBuilder, first, second)
+func TestFieldTypesGeneratesErrorWhenValueCantBeConverted(t *testing.T) {
+	var testCases = []struct {
+		filter        string
+		expectedError string
+	}{
+		//language=JSON
+		{`{"type": "GT", "args": ["quantity", "ten"]}`, "invalid value for int64"},
+		//language=JSON
+		{`{"type": "EQ", "args": ["active", "yes"]}`, "invalid value for boolean"},
+		//language=JSON
+		{`{"type": "IN", "args": ["quantity", "1", "two"]}`, "invalid value for int64"},
+		//language=JSON
+		{`{"type": "CONTAINS_ALL", "args": ["quantity", "1", "two"]}`, "invalid value for int64"},
+		//language=JSON
+		{`{"type": "LT", "args": ["created_at", "yesterday"]}`, "invalid value for date"},
+		//language=JSON
+		{`{"type": "LIKE", "args": ["quantity", "1*"]}`, "like() operator is only supported for string fields"},
+		//language=JSON
+		{`{"type": "ILIKE", "args": ["created_at", "2024*"]}`, "ilike() operator is only supported for string fields"},
+		//language=JSON
+		{`{"type": "TEXT", "args": ["active", "true"]}`, "text() operator is only supported for string fields"},
+		//language=JSON
+		{`{"type": "EQ", "args": ["nested[abc].quantity", "ten"]}`, "invalid value for int64"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				FieldTypes: map[string]epsearchast.FieldType{
+					"quantity":        epsearchast.Int64,
+					"active":          epsearchast.Boolean,
+					"created_at":      epsearchast.Date,
+					"nested.quantity": epsearchast.Int64,
+				},
+				NestedFieldToQuery: map[string]NestedReplacement{
+					`^nested\[(?P<id>[a-z]+)\]\.quantity$`: {
+						Path: "nested",
+						Subqueries: map[string]Replacement{
+							"nested.id":       {Value: "$id", ForceEQ: true},
+							"nested.quantity": {Value: "$value"},
+						},
+					},
+				},
+			}
+
+			// Execute SUT
+			_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestFieldTypesInNestedFieldGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{"type": "GE", "args": ["nested[abc].quantity", "5"]}`
+
+	expectedJson := `{"nested":{"path":"nested","query":{"bool":{"must":[{"term":{"nested.id":"abc"}},{"range":{"nested.quantity":{"gte":5}}}]}}}}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	qb := DefaultEsQueryBuilder{
+		FieldTypes: map[string]epsearchast.FieldType{
+			"nested.quantity": epsearchast.Int64,
+		},
+		NestedFieldToQuery: map[string]NestedReplacement{
+			`^nested\[(?P<id>[a-z]+)\]\.quantity$`: {
+				Path: "nested",
+				Subqueries: map[string]Replacement{
+					"nested.id":       {Value: "$id", ForceEQ: true},
+					"nested.quantity": {Value: "$value"},
+				},
+			},
+		},
+	}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[JsonObject](qb))
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestFilterContextAndGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{"type": "TEXT", "args": ["name", "shoes"]},
+			{"type": "EQ", "args": ["status", "live"]},
+			{
+				"type": "OR",
+				"children": [
+					{"type": "GT", "args": ["price", "10"]},
+					{"type": "IS_NULL", "args": ["price"]}
+				]
+			}
+		]
+	}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "filter": [
+      {
+        "term": {
+          "status": "live"
+        }
+      },
+      {
+        "bool": {
+          "filter": [
+            {
+              "bool": {
+                "minimum_should_match": 1,
+                "should": [
+                  {
+                    "range": {
+                      "price": {
+                        "gt": "10"
+                      }
+                    }
+                  },
+                  {
+                    "bool": {
+                      "must_not": {
+                        "exists": {
+                          "field": "price"
+                        }
+                      }
+                    }
+                  }
+                ]
+              }
+            }
+          ]
+        }
+      }
+    ],
+    "must": [
+      {
+        "match_bool_prefix": {
+          "name": {
+            "fuzziness": "0",
+            "operator": "and",
+            "query": "shoes"
+          }
+        }
+      }
+    ]
+  }
+}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+		UseFilterContext: true,
+	}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestFilterContextAndWithOnlyFiltersGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{"type": "EQ", "args": ["status", "live"]},
+			{"type": "LT", "args": ["price", "10"]}
+		]
+	}`
+
+	expectedJson := `{"bool":{"filter":[{"term":{"status":"live"}},{"range":{"price":{"lt":"10"}}}]}}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+		UseFilterContext: true,
+	}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestFilterContextOrWithTextGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "OR",
+		"children": [
+			{"type": "TEXT", "args": ["name", "shoes"]},
+			{"type": "EQ", "args": ["status", "live"]}
+		]
+	}`
+
+	expectedJson := `{"bool":{"minimum_should_match":1,"should":[{"match_bool_prefix":{"name":{"fuzziness":"0","operator":"and","query":"shoes"}}},{"term":{"status":"live"}}]}}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+		UseFilterContext: true,
+	}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestFilterContextKeepsNestedTextInMust(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{"type": "TEXT", "args": ["nested[abc].description", "shoes"]},
+			{"type": "EQ", "args": ["nested[abc].status", "live"]}
+		]
+	}`
+
+	expectedJson := `{"bool":{` +
+		`"filter":[{"nested":{"path":"nested","query":{"bool":{"must":[{"term":{"nested.status":"live"}},{"term":{"nested.id":"abc"}}]}}}}],` +
+		`"must":[{"nested":{"path":"nested","query":{"bool":{"must":[{"match_bool_prefix":{"nested.description":{"fuzziness":"0","operator":"and","query":"shoes"}}},{"term":{"nested.id":"abc"}}]}}}}]}}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+		UseFilterContext: true,
+		NestedFieldToQuery: map[string]NestedReplacement{
+			`^nested\[(?P<id>[a-z]+)\]\.(?P<attr>[a-z]+)$`: {
+				Path: "nested",
+				Subqueries: map[string]Replacement{
+					"nested.id":    {Value: "$id", ForceEQ: true},
+					"nested.$attr": {Value: "$value"},
+				},
+			},
+		},
+	}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestFlattenMergesNestedBoolQueries(t *testing.T) {
+	var testCases = []struct {
+		name             string
+		useFilterContext bool
+		filter           string
+		expectedJson     string
+	}{
+		{
+			name: "nested and/or",
+			//language=JSON
+			filter: `{
+				"type": "AND",
+				"children": [
+					{"type": "AND", "children": [{"type": "EQ", "args": ["a", "1"]}, {"type": "EQ", "args": ["b", "2"]}]},
+					{"type": "OR", "children": [{"type": "EQ", "args": ["c", "3"]}, {"type": "OR", "children": [{"type": "EQ", "args": ["d", "4"]}, {"type": "EQ", "args": ["e", "5"]}]}]},
+					{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]}
+				]
+			}`,
+			expectedJson: `{"bool":{"must":[` +
+				`{"term":{"a":"1"}},{"term":{"b":"2"}},` +
+				`{"bool":{"minimum_should_match":1,"should":[{"term":{"c":"3"}},{"term":{"d":"4"}},{"term":{"e":"5"}}]}},` +
+				`{"term":{"tags":"red"}},{"term":{"tags":"blue"}}]}}`,
+		},
+		{
+			name:             "nested and/or with filter context",
+			useFilterContext: true,
+			//language=JSON
+			filter: `{
+				"type": "AND",
+				"children": [
+					{"type": "AND", "children": [{"type": "TEXT", "args": ["name", "shoes"]}, {"type": "EQ", "args": ["b", "2"]}]},
+					{"type": "OR", "children": [{"type": "EQ", "args": ["c", "3"]}, {"type": "OR", "children": [{"type": "EQ", "args": ["d", "4"]}, {"type": "EQ", "args": ["e", "5"]}]}]}
+				]
+			}`,
+			expectedJson: `{"bool":{"filter":[` +
+				`{"term":{"b":"2"}},` +
+				`{"bool":{"minimum_should_match":1,"should":[{"term":{"c":"3"}},{"term":{"d":"4"}},{"term":{"e":"5"}}]}}` +
+				`],"must":[{"match_bool_prefix":{"name":{"fuzziness":"0","operator":"and","query":"shoes"}}}]}}`,
+		},
+		{
+			name:             "or with text keeps filter context of nested or",
+			useFilterContext: true,
+			//language=JSON
+			filter: `{
+				"type": "OR",
+				"children": [
+					{"type": "TEXT", "args": ["name", "shoes"]},
+					{"type": "OR", "children": [{"type": "EQ", "args": ["d", "4"]}, {"type": "EQ", "args": ["e", "5"]}]}
+				]
+			}`,
+			expectedJson: `{"bool":{"minimum_should_match":1,"should":[` +
+				`{"match_bool_prefix":{"name":{"fuzziness":"0","operator":"and","query":"shoes"}}},` +
+				`{"bool":{"filter":[{"bool":{"minimum_should_match":1,"should":[{"term":{"d":"4"}},{"term":{"e":"5"}}]}}]}}]}}`,
+		},
+		{
+			name: "single value contains_all",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ALL", "args": ["tags", "red"]}`,
+			expectedJson: `{"term":{"tags":"red"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[JsonObject] = DefaultEsQueryBuilder{
+				UseFilterContext: tc.useFilterContext,
+				Flatten:          true,
+			}
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, qb)
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.Marshal(query)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(queryJson))
+		})
+	}
+}
+
+type LowerCaseEmail struct {
+	DefaultEsQueryBuilder
+}
+
+func (l *LowerCaseEmail) VisitEq(first, second string) (*JsonObject, error) {
+	if first == "email" {
+		return DefaultEsQueryBuilder.VisitEq(l.DefaultEsQueryBuilder, first, strings.ToLower(second))
+	} else {
+		return DefaultEsQueryBuilder.VisitEq(l.DefaultEsQueryBuilder, first, second)
+	}
+}
+
+func TestOrderedNestedFieldToQueryUsesFirstMatchingPattern(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "EQ",
+	"args": ["locales.FR.name", "chat"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "nested": {
+    "path": "locales",
+    "query": {
+      "bool": {
+        "must": [
+          {
+            "term": {
+              "locales.fr_name": "chat"
+            }
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		OrderedNestedFieldToQuery: []NestedFieldPattern{
+			{
+				Pattern: `^locales\.FR\.name$`,
+				NestedReplacement: NestedReplacement{
+					Path: "locales",
+					Subqueries: map[string]Replacement{
+						"locales.fr_name": {Value: "$value"},
+					},
+				},
+			},
+			{
+				Pattern: `^locales\.(?P<locale>[A-Z]+)\.(?P<attr>[a-z]+)$`,
+				NestedReplacement: NestedReplacement{
+					Path: "locales",
+					Subqueries: map[string]Replacement{
+						"locales.locale": {Value: "$locale", ForceEQ: true},
+						"locales.$attr":  {Value: "$value"},
+					},
+				},
+			},
+		},
+		NestedFieldToQuery: map[string]NestedReplacement{
+			`^locales\.(?P<locale>[A-Z]+)\.name$`: {
+				Path: "locales",
+				Subqueries: map[string]Replacement{
+					"locales.name": {Value: "$value"},
+				},
+			},
+		},
+	}.MustCompile()
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestOrderedNestedFieldToQueryFallsThroughToLaterPatterns(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "EQ",
+	"args": ["locales.DE.name", "Katze"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "nested": {
+    "path": "locales",
+    "query": {
+      "bool": {
+        "must": [
+          {
+            "term": {
+              "locales.name": "Katze"
+            }
+          },
+          {
+            "term": {
+              "locales.locale": "DE"
+            }
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		OrderedNestedFieldToQuery: []NestedFieldPattern{
+			{
+				Pattern: `^locales\.FR\.name$`,
+				NestedReplacement: NestedReplacement{
+					Path: "locales",
+					Subqueries: map[string]Replacement{
+						"locales.fr_name": {Value: "$value"},
+					},
+				},
+			},
+			{
+				Pattern: `^locales\.(?P<locale>[A-Z]+)\.(?P<attr>[a-z]+)$`,
+				NestedReplacement: NestedReplacement{
+					Path: "locales",
+					Subqueries: map[string]Replacement{
+						"locales.locale": {Value: "$locale", ForceEQ: true},
+						"locales.$attr":  {Value: "$value"},
+					},
+				},
+			},
+		},
+	}.MustCompile()
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestMustCompileGeneratesSameQueryAsUncompiledBuilder(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "AND",
+	"children": [
+		{
+			"type": "EQ",
+			"args": ["variants[0].colour", "red"]
+		},
+		{
+			"type": "GT",
+			"args": ["variants[1].size", "5"]
+		}
+	]
+}`
+
+	qb := DefaultEsQueryBuilder{NestedFieldToQuery: map[string]NestedReplacement{
+		`^variants\[(?P<idx>\d+)\]\.(?P<attr>[a-z]+)$`: {
+			Path: "variants",
+			Subqueries: map[string]Replacement{
+				"variants.idx":   {Value: "$idx", ForceEQ: true},
+				"variants.$attr": {Value: "$value"},
+			},
+		},
+	}}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	expectedQuery, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb.MustCompile())
+	require.NoError(t, err)
+
+	// Verification
+	require.Equal(t, expectedQuery, query)
+}
+
+func TestNestedFieldToQueryStillErrorsWhenMoreThanOneUnorderedPatternMatches(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "EQ",
+	"args": ["locales.FR.name", "chat"]
+}`
+
+	qb := DefaultEsQueryBuilder{
+		NestedFieldToQuery: map[string]NestedReplacement{
+			`^locales\.FR\.name$`: {
+				Path: "locales",
+				Subqueries: map[string]Replacement{
+					"locales.fr_name": {Value: "$value"},
+				},
+			},
+			`^locales\.(?P<locale>[A-Z]+)\.name$`: {
+				Path: "locales",
+				Subqueries: map[string]Replacement{
+					"locales.name": {Value: "$value"},
+				},
+			},
+		},
+	}.MustCompile()
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	_, err = epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+
+	// Verification
+	require.ErrorContains(t, err, "found more than one nested field for locales.FR.name")
+}
+
+func TestMustValidatePanicsWhenOrderedNestedFieldDoesNotHaveStartAnchor(t *testing.T) {
+	// Fixture Setup
+	qb := DefaultEsQueryBuilder{OrderedNestedFieldToQuery: []NestedFieldPattern{
+		{
+			Pattern: "test$",
+			NestedReplacement: NestedReplacement{
+				Path: "foo",
+			},
+		},
+	}}
+
+	// Execute SUT & Verification
+	assert.PanicsWithValue(t, "All nested fields must be anchored to the start of the string (e.g., start with a ^), [test$] does not", func() {
+		qb.MustCompile()
+	})
+}
+
+func TestContainsAllWithTermsSetGeneratesSingleQuery(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "CONTAINS_ALL",
+	"args": ["sizes", "1", "2", "01"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "terms_set": {
+    "sizes.keyword": {
+      "minimum_should_match_script": {
+        "source": "params.num_terms"
+      },
+      "terms": [
+        1,
+        2
+      ]
+    }
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		UseTermsSetForContainsAll: true,
+		OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+			"sizes": {Array: "sizes.keyword"},
+		},
+		FieldTypes: map[string]epsearchast.FieldType{
+			"sizes": epsearchast.Int64,
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestContainsAllWithTermsSetGeneratesCorrectQueryForNestedField(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "CONTAINS_ALL",
+	"args": ["variants[0].tags", "red", "large"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "nested": {
+    "path": "variants",
+    "query": {
+      "bool": {
+        "must": [
+          {
+            "terms_set": {
+              "variants.tags": {
+                "minimum_should_match_script": {
+                  "source": "params.num_terms"
+                },
+                "terms": [
+                  "red",
+                  "large"
+                ]
+              }
+            }
+          },
+          {
+            "term": {
+              "variants.idx": "0"
+            }
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		UseTermsSetForContainsAll: true,
+		NestedFieldToQuery: map[string]NestedReplacement{
+			`^variants\[(?P<idx>\d+)\]\.(?P<attr>[a-z]+)$`: {
+				Path: "variants",
+				Subqueries: map[string]Replacement{
+					"variants.idx":   {Value: "$idx", ForceEQ: true},
+					"variants.$attr": {Value: "$value"},
+				},
+			},
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestJoinFieldToQueryGroupsPredicatesOnTheSameRelation(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "AND",
+	"children": [
+		{
+			"type": "EQ",
+			"args": ["skus.colour", "red"]
+		},
+		{
+			"type": "EQ",
+			"args": ["status", "live"]
+		},
+		{
+			"type": "GT",
+			"args": ["skus.stock", "0"]
+		}
+	]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "must": [
+      {
+        "has_child": {
+          "query": {
+            "bool": {
+              "must": [
+                {
+                  "term": {
+                    "colour": "red"
+                  }
+                },
+                {
+                  "range": {
+                    "stock": {
+                      "gt": 0
+                    }
+                  }
+                }
+              ]
+            }
+          },
+          "type": "sku"
+        }
+      },
+      {
+        "term": {
+          "status": "live"
+        }
+      }
+    ]
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		JoinFieldToQuery: map[string]JoinReplacement{
+			`^skus\.(?P<field>[a-z_]+)$`: {
+				QueryType: JoinHasChild,
+				Relation:  "sku",
+				Field:     "$field",
+			},
+		},
+		FieldTypes: map[string]epsearchast.FieldType{
+			"stock": epsearchast.Int64,
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestJoinFieldToQueryGroupsPredicatesOnTheSameRelationInNestedConjunctionsWhenFlattening(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "AND",
+	"children": [
+		{
+			"type": "AND",
+			"children": [
+				{
+					"type": "EQ",
+					"args": ["skus.colour", "red"]
+				},
+				{
+					"type": "EQ",
+					"args": ["status", "live"]
+				}
+			]
+		},
+		{
+			"type": "GT",
+			"args": ["skus.stock", "0"]
+		}
+	]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "must": [
+      {
+        "has_child": {
+          "query": {
+            "bool": {
+              "must": [
+                {
+                  "term": {
+                    "colour": "red"
+                  }
+                },
+                {
+                  "range": {
+                    "stock": {
+                      "gt": 0
+                    }
+                  }
+                }
+              ]
+            }
+          },
+          "type": "sku"
+        }
+      },
+      {
+        "term": {
+          "status": "live"
+        }
+      }
+    ]
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		Flatten: true,
+		JoinFieldToQuery: map[string]JoinReplacement{
+			`^skus\.(?P<field>[a-z_]+)$`: {
+				QueryType: JoinHasChild,
+				Relation:  "sku",
+				Field:     "$field",
+			},
+		},
+		FieldTypes: map[string]epsearchast.FieldType{
+			"stock": epsearchast.Int64,
+		},
+	}.MustCompile()
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestJoinFieldToQueryGeneratesHasParentQuery(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "OR",
+	"children": [
+		{
+			"type": "EQ",
+			"args": ["product.brand", "acme"]
+		},
+		{
+			"type": "EQ",
+			"args": ["product.brand", "globex"]
+		}
+	]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "minimum_should_match": 1,
+    "should": [
+      {
+        "has_parent": {
+          "parent_type": "product",
+          "query": {
+            "term": {
+              "brand": "acme"
+            }
+          }
+        }
+      },
+      {
+        "has_parent": {
+          "parent_type": "product",
+          "query": {
+            "term": {
+              "brand": "globex"
+            }
+          }
+        }
+      }
+    ]
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		JoinFieldToQuery: map[string]JoinReplacement{
+			`^product\.(?P<field>[a-z_]+)$`: {
+				QueryType: JoinHasParent,
+				Relation:  "product",
+				Field:     "$field",
+			},
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestIsNullOnJoinFieldMatchesDocumentsWithNoRelatedDocumentWithTheField(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "IS_NULL",
+	"args": ["skus.colour"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "must_not": {
+      "has_child": {
+        "query": {
+          "exists": {
+            "field": "colour"
+          }
+        },
+        "type": "sku"
+      }
+    }
+  }
+}`
+
+	qb := DefaultEsQueryBuilder{
+		JoinFieldToQuery: map[string]JoinReplacement{
+			`^skus\.(?P<field>[a-z_]+)$`: {
+				QueryType: JoinHasChild,
+				Relation:  "sku",
+				Field:     "$field",
+			},
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Verification
+	queryJson, err := json.MarshalIndent(query, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestMustValidatePanicsWithInvalidJoinFieldToQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pattern  string
+		join     JoinReplacement
+		expected string
+	}{
+		{
+			name:     "missing start anchor",
+			pattern:  `skus\.colour$`,
+			join:     JoinReplacement{QueryType: JoinHasChild, Relation: "sku"},
+			expected: `All join fields must be anchored to the start of the string (e.g., start with a ^), [skus\.colour$] does not`,
+		},
+		{
+			name:     "empty pattern",
+			pattern:  "",
+			join:     JoinReplacement{QueryType: JoinHasChild, Relation: "sku"},
+			expected: `All join fields must be anchored to the start of the string (e.g., start with a ^), [] does not`,
+		},
+		{
+			name:     "unknown query type",
+			pattern:  `^skus\.colour$`,
+			join:     JoinReplacement{QueryType: "has_sibling", Relation: "sku"},
+			expected: `Unknown query type [has_sibling] for join field [^skus\.colour$]`,
+		},
+		{
+			name:     "missing relation",
+			pattern:  `^skus\.colour$`,
+			join:     JoinReplacement{QueryType: JoinHasChild},
+			expected: `Relation must be set for join field [^skus\.colour$]`,
+		},
+		{
+			name:     "template not replaced",
+			pattern:  `^skus\.(?P<field>[a-z_]+)$`,
+			join:     JoinReplacement{QueryType: JoinHasChild, Relation: "sku", Field: "$attr"},
+			expected: `Not all templates replaced in join field [^skus\.(?P<field>[a-z_]+)$] with field [$attr], after replacement left over with: $attr`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Fixture Setup
+			qb := DefaultEsQueryBuilder{
+				JoinFieldToQuery: map[string]JoinReplacement{
+					tc.pattern: tc.join,
+				},
+			}
+
+			// Execute SUT & Verification
+			assert.PanicsWithValue(t, tc.expected, func() {
+				qb.MustValidate()
+			})
+		})
 	}
 }
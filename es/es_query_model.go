@@ -0,0 +1,885 @@
+package astes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Query is a typed Elasticsearch query, it marshals to the same JSON that DefaultEsQueryBuilder generates.
+//
+// Query types that aren't part of the model (e.g., generated by overridden builders) are returned as RawQuery.
+type Query interface {
+	json.Marshaler
+
+	// ToJsonObject returns the query in the format DefaultEsQueryBuilder uses.
+	ToJsonObject() JsonObject
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-bool-query.html
+type BoolQuery struct {
+	Must    []Query
+	Filter  []Query
+	Should  []Query
+	MustNot []Query
+
+	// Only set for disjunctions (i.e., when Should is set)
+	MinimumShouldMatch *int
+
+	// Some queries use a single object for must_not instead of an array, this is kept so that the JSON doesn't change.
+	mustNotObject bool
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-term-query.html
+type TermQuery struct {
+	Field string
+	Value any
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-terms-query.html
+type TermsQuery struct {
+	Field  string
+	Values []any
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-terms-set-query.html
+type TermsSetQuery struct {
+	Field                    string
+	Terms                    []any
+	MinimumShouldMatchScript string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-range-query.html
+// Only bounds that are not nil are included.
+type RangeQuery struct {
+	Field  string
+	Gt     any
+	Gte    any
+	Lt     any
+	Lte    any
+	Format string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-wildcard-query.html
+type WildcardQuery struct {
+	Field           string
+	Value           string
+	CaseInsensitive bool
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-exists-query.html
+type ExistsQuery struct {
+	Field string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-nested-query.html
+type NestedQuery struct {
+	Path  string
+	Query Query
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-has-child-query.html
+type HasChildQuery struct {
+	Type  string
+	Query Query
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-has-parent-query.html
+type HasParentQuery struct {
+	ParentType string
+	Query      Query
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-match-bool-prefix-query.html
+type MatchBoolPrefixQuery struct {
+	Field              string
+	Query              string
+	Operator           string
+	Fuzziness          string
+	MinimumShouldMatch string
+	Analyzer           string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-match-query-phrase.html
+type MatchPhraseQuery struct {
+	Field    string
+	Query    string
+	Analyzer string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-multi-match-query.html
+type MultiMatchQuery struct {
+	Query              string
+	Fields             []string
+	Operator           string
+	Fuzziness          string
+	MinimumShouldMatch string
+	Analyzer           string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-simple-query-string-query.html
+type SimpleQueryStringQuery struct {
+	Query              string
+	Fields             []string
+	DefaultOperator    string
+	MinimumShouldMatch string
+	Analyzer           string
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-combined-fields-query.html
+type CombinedFieldsQuery struct {
+	Query              string
+	Fields             []string
+	Operator           string
+	MinimumShouldMatch string
+}
+
+// RawQuery is a query that doesn't have a typed representation, it is marshalled as is.
+type RawQuery JsonObject
+
+var (
+	_ Query = (*BoolQuery)(nil)
+	_ Query = (*TermQuery)(nil)
+	_ Query = (*TermsQuery)(nil)
+	_ Query = (*TermsSetQuery)(nil)
+	_ Query = (*RangeQuery)(nil)
+	_ Query = (*WildcardQuery)(nil)
+	_ Query = (*ExistsQuery)(nil)
+	_ Query = (*NestedQuery)(nil)
+	_ Query = (*HasChildQuery)(nil)
+	_ Query = (*HasParentQuery)(nil)
+	_ Query = (*MatchBoolPrefixQuery)(nil)
+	_ Query = (*MatchPhraseQuery)(nil)
+	_ Query = (*MultiMatchQuery)(nil)
+	_ Query = (*SimpleQueryStringQuery)(nil)
+	_ Query = (*CombinedFieldsQuery)(nil)
+	_ Query = (*RawQuery)(nil)
+)
+
+func (q *BoolQuery) ToJsonObject() JsonObject {
+	b := map[string]any{}
+
+	if len(q.Must) > 0 {
+		b["must"] = toJsonObjects(q.Must)
+	}
+
+	if len(q.Filter) > 0 {
+		b["filter"] = toJsonObjects(q.Filter)
+	}
+
+	if len(q.Should) > 0 {
+		b["should"] = toJsonObjects(q.Should)
+	}
+
+	if q.mustNotObject && len(q.MustNot) == 1 {
+		mustNot := q.MustNot[0].ToJsonObject()
+		b["must_not"] = &mustNot
+	} else if len(q.MustNot) > 0 {
+		b["must_not"] = toJsonObjects(q.MustNot)
+	}
+
+	if q.MinimumShouldMatch != nil {
+		b["minimum_should_match"] = *q.MinimumShouldMatch
+	}
+
+	return JsonObject{
+		"bool": b,
+	}
+}
+
+func (q *TermQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"term": map[string]any{
+			q.Field: q.Value,
+		},
+	}
+}
+
+func (q *TermsQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"terms": map[string]any{
+			q.Field: q.Values,
+		},
+	}
+}
+
+func (q *TermsSetQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"terms_set": map[string]any{
+			q.Field: map[string]any{
+				"terms": q.Terms,
+				"minimum_should_match_script": map[string]any{
+					"source": q.MinimumShouldMatchScript,
+				},
+			},
+		},
+	}
+}
+
+func (q *RangeQuery) ToJsonObject() JsonObject {
+	r := map[string]any{}
+
+	for k, v := range map[string]any{"gt": q.Gt, "gte": q.Gte, "lt": q.Lt, "lte": q.Lte} {
+		if v != nil {
+			r[k] = v
+		}
+	}
+
+	if q.Format != "" {
+		r["format"] = q.Format
+	}
+
+	return JsonObject{
+		"range": map[string]any{
+			q.Field: r,
+		},
+	}
+}
+
+func (q *WildcardQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"wildcard": map[string]any{
+			q.Field: map[string]any{
+				"value":            q.Value,
+				"case_insensitive": q.CaseInsensitive,
+			},
+		},
+	}
+}
+
+func (q *ExistsQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"exists": map[string]any{
+			"field": q.Field,
+		},
+	}
+}
+
+func (q *NestedQuery) ToJsonObject() JsonObject {
+	return JsonObject{
+		"nested": JsonObject{
+			"path":  q.Path,
+			"query": q.Query.ToJsonObject(),
+		},
+	}
+}
+
+func (q *HasChildQuery) ToJsonObject() JsonObject {
+	inner := q.Query.ToJsonObject()
+	return *buildJoinQuery(JoinHasChild, q.Type, &inner)
+}
+
+func (q *HasParentQuery) ToJsonObject() JsonObject {
+	inner := q.Query.ToJsonObject()
+	return *buildJoinQuery(JoinHasParent, q.ParentType, &inner)
+}
+
+func (q *MatchBoolPrefixQuery) ToJsonObject() JsonObject {
+	options := map[string]any{
+		"query":     q.Query,
+		"operator":  q.Operator,
+		"fuzziness": q.Fuzziness,
+	}
+
+	addStringOptions(options, "minimum_should_match", q.MinimumShouldMatch, "analyzer", q.Analyzer)
+
+	return JsonObject{
+		"match_bool_prefix": map[string]any{
+			q.Field: options,
+		},
+	}
+}
+
+func (q *MatchPhraseQuery) ToJsonObject() JsonObject {
+	options := map[string]any{
+		"query": q.Query,
+	}
+
+	addStringOptions(options, "analyzer", q.Analyzer)
+
+	return JsonObject{
+		"match_phrase": map[string]any{
+			q.Field: options,
+		},
+	}
+}
+
+func (q *MultiMatchQuery) ToJsonObject() JsonObject {
+	options := map[string]any{
+		"query":    q.Query,
+		"fields":   q.Fields,
+		"operator": q.Operator,
+	}
+
+	addStringOptions(options, "fuzziness", q.Fuzziness, "minimum_should_match", q.MinimumShouldMatch, "analyzer", q.Analyzer)
+
+	return JsonObject{
+		"multi_match": options,
+	}
+}
+
+func (q *SimpleQueryStringQuery) ToJsonObject() JsonObject {
+	options := map[string]any{
+		"query":            q.Query,
+		"fields":           q.Fields,
+		"default_operator": q.DefaultOperator,
+	}
+
+	addStringOptions(options, "minimum_should_match", q.MinimumShouldMatch, "analyzer", q.Analyzer)
+
+	return JsonObject{
+		"simple_query_string": options,
+	}
+}
+
+func (q *CombinedFieldsQuery) ToJsonObject() JsonObject {
+	options := map[string]any{
+		"query":    q.Query,
+		"fields":   q.Fields,
+		"operator": q.Operator,
+	}
+
+	addStringOptions(options, "minimum_should_match", q.MinimumShouldMatch)
+
+	return JsonObject{
+		"combined_fields": options,
+	}
+}
+
+func (q *RawQuery) ToJsonObject() JsonObject {
+	return JsonObject(*q)
+}
+
+func (q *BoolQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *TermQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *TermsQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *TermsSetQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *RangeQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *WildcardQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *ExistsQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *NestedQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *HasChildQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *HasParentQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *MatchBoolPrefixQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *MatchPhraseQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *MultiMatchQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *SimpleQueryStringQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *CombinedFieldsQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.ToJsonObject())
+}
+
+func (q *RawQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(JsonObject(*q))
+}
+
+func toJsonObjects(qs []Query) []*JsonObject {
+	objs := make([]*JsonObject, 0, len(qs))
+
+	for _, q := range qs {
+		o := q.ToJsonObject()
+		objs = append(objs, &o)
+	}
+
+	return objs
+}
+
+// addStringOptions adds the options (name, value pairs) that are not empty.
+func addStringOptions(options map[string]any, nameValues ...string) {
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		if nameValues[i+1] != "" {
+			options[nameValues[i]] = nameValues[i+1]
+		}
+	}
+}
+
+// ParseQuery converts a query generated by DefaultEsQueryBuilder into the typed model. Query types that aren't part of the model (e.g., from an overridden builder)
+// are returned as a RawQuery, but a query type in the model that has an unexpected format is an error, rather than silently losing its type.
+//
+// To get the typed model for a filter, reduce the AST with a query builder and call ParseQuery once on the result.
+func ParseQuery(o JsonObject) (Query, error) {
+	return queryParser{}.parse(o)
+}
+
+// errUnexpectedFormat is returned when a query type in the model doesn't have the format that DefaultEsQueryBuilder generates.
+var errUnexpectedFormat = errors.New("unexpected format")
+
+type queryParser struct{}
+
+func (p queryParser) parsePointer(o *JsonObject) (Query, error) {
+	if o == nil {
+		return nil, errUnexpectedFormat
+	}
+
+	return p.parse(*o)
+}
+
+func (p queryParser) parse(o JsonObject) (Query, error) {
+	if len(o) == 1 {
+		for k, v := range o {
+			q, err := p.parseQueryType(k, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s query: %w", k, err)
+			}
+
+			if q != nil {
+				return q, nil
+			}
+		}
+	}
+
+	r := RawQuery(o)
+	return &r, nil
+}
+
+// parseQueryType returns the typed query for the type and body, or nil if the type isn't part of the model.
+func (p queryParser) parseQueryType(queryType string, v any) (Query, error) {
+	body, ok := asMap(v)
+	if !ok {
+		if isModelQueryType(queryType) {
+			return nil, errUnexpectedFormat
+		}
+
+		return nil, nil
+	}
+
+	switch queryType {
+	case "bool":
+		return p.parseBoolQuery(body)
+	case "nested":
+		return p.parseNestedQuery(body)
+	case string(JoinHasChild), string(JoinHasParent):
+		return p.parseJoinQuery(JoinQueryType(queryType), body)
+	}
+
+	var q Query
+
+	switch queryType {
+	case "term":
+		if field, value, ok := getSingleEntry(body); ok {
+			q = &TermQuery{Field: field, Value: value}
+		}
+	case "terms":
+		if field, value, ok := getSingleEntry(body); ok {
+			if values, ok := value.([]any); ok {
+				q = &TermsQuery{Field: field, Values: values}
+			}
+		}
+	case "terms_set":
+		q, ok = parseTermsSetQuery(body)
+	case "range":
+		q, ok = parseRangeQuery(body)
+	case "wildcard":
+		q, ok = parseWildcardQuery(body)
+	case "exists":
+		if options, ok := getStringOptions(body, []string{"field"}, nil); ok {
+			q = &ExistsQuery{Field: options["field"]}
+		}
+	case "match_bool_prefix":
+		if field, value, ok := getSingleEntry(body); ok {
+			if options, ok := getStringOptions(value, []string{"query", "operator", "fuzziness"}, []string{"minimum_should_match", "analyzer"}); ok {
+				q = &MatchBoolPrefixQuery{
+					Field:              field,
+					Query:              options["query"],
+					Operator:           options["operator"],
+					Fuzziness:          options["fuzziness"],
+					MinimumShouldMatch: options["minimum_should_match"],
+					Analyzer:           options["analyzer"],
+				}
+			}
+		}
+	case "match_phrase":
+		if field, value, ok := getSingleEntry(body); ok {
+			if options, ok := getStringOptions(value, []string{"query"}, []string{"analyzer"}); ok {
+				q = &MatchPhraseQuery{Field: field, Query: options["query"], Analyzer: options["analyzer"]}
+			}
+		}
+	case "multi_match":
+		if fields, ok := getFields(body); ok {
+			if options, ok := getStringOptions(body, []string{"query", "operator"}, []string{"fuzziness", "minimum_should_match", "analyzer"}, "fields"); ok {
+				q = &MultiMatchQuery{
+					Query:              options["query"],
+					Fields:             fields,
+					Operator:           options["operator"],
+					Fuzziness:          options["fuzziness"],
+					MinimumShouldMatch: options["minimum_should_match"],
+					Analyzer:           options["analyzer"],
+				}
+			}
+		}
+	case "simple_query_string":
+		if fields, ok := getFields(body); ok {
+			if options, ok := getStringOptions(body, []string{"query", "default_operator"}, []string{"minimum_should_match", "analyzer"}, "fields"); ok {
+				q = &SimpleQueryStringQuery{
+					Query:              options["query"],
+					Fields:             fields,
+					DefaultOperator:    options["default_operator"],
+					MinimumShouldMatch: options["minimum_should_match"],
+					Analyzer:           options["analyzer"],
+				}
+			}
+		}
+	case "combined_fields":
+		if fields, ok := getFields(body); ok {
+			if options, ok := getStringOptions(body, []string{"query", "operator"}, []string{"minimum_should_match"}, "fields"); ok {
+				q = &CombinedFieldsQuery{
+					Query:              options["query"],
+					Fields:             fields,
+					Operator:           options["operator"],
+					MinimumShouldMatch: options["minimum_should_match"],
+				}
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	if q == nil {
+		return nil, errUnexpectedFormat
+	}
+
+	return q, nil
+}
+
+// isModelQueryType returns true if the query type has a typed representation.
+func isModelQueryType(queryType string) bool {
+	switch queryType {
+	case "bool", "term", "terms", "terms_set", "range", "wildcard", "exists", "nested", string(JoinHasChild), string(JoinHasParent),
+		"match_bool_prefix", "match_phrase", "multi_match", "simple_query_string", "combined_fields":
+		return true
+	}
+
+	return false
+}
+
+func (p queryParser) parseBoolQuery(body map[string]any) (Query, error) {
+	q := &BoolQuery{}
+
+	for k, v := range body {
+		if k == "minimum_should_match" {
+			msm, ok := v.(int)
+			if !ok {
+				return nil, fmt.Errorf("%w of minimum_should_match", errUnexpectedFormat)
+			}
+
+			q.MinimumShouldMatch = &msm
+			continue
+		}
+
+		var clauses []Query
+
+		switch c := v.(type) {
+		case []*JsonObject:
+			if len(c) == 0 {
+				return nil, fmt.Errorf("%w of %s, there are no clauses", errUnexpectedFormat, k)
+			}
+
+			for _, o := range c {
+				clause, err := p.parsePointer(o)
+				if err != nil {
+					return nil, err
+				}
+
+				clauses = append(clauses, clause)
+			}
+		default:
+			if k != "must_not" {
+				return nil, fmt.Errorf("%w of %s", errUnexpectedFormat, k)
+			}
+
+			var clause Query
+			var err error
+
+			if o, ok := c.(*JsonObject); ok {
+				clause, err = p.parsePointer(o)
+			} else if o, ok := asMap(c); ok {
+				clause, err = p.parse(o)
+			} else {
+				err = fmt.Errorf("%w of must_not", errUnexpectedFormat)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			clauses = []Query{clause}
+			q.mustNotObject = true
+		}
+
+		switch k {
+		case "must":
+			q.Must = clauses
+		case "filter":
+			q.Filter = clauses
+		case "should":
+			q.Should = clauses
+		case "must_not":
+			q.MustNot = clauses
+		default:
+			return nil, fmt.Errorf("unknown clause %s", k)
+		}
+	}
+
+	return q, nil
+}
+
+func (p queryParser) parseNestedQuery(body map[string]any) (Query, error) {
+	path, ok := body["path"].(string)
+	if !ok || len(body) != 2 {
+		return nil, errUnexpectedFormat
+	}
+
+	inner, err := p.parseInnerQuery(body["query"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &NestedQuery{Path: path, Query: inner}, nil
+}
+
+func (p queryParser) parseJoinQuery(queryType JoinQueryType, body map[string]any) (Query, error) {
+	relationKey := "type"
+	if queryType == JoinHasParent {
+		relationKey = "parent_type"
+	}
+
+	relation, ok := body[relationKey].(string)
+	if !ok || len(body) != 2 {
+		return nil, errUnexpectedFormat
+	}
+
+	inner, err := p.parseInnerQuery(body["query"])
+	if err != nil {
+		return nil, err
+	}
+
+	if queryType == JoinHasParent {
+		return &HasParentQuery{ParentType: relation, Query: inner}, nil
+	}
+
+	return &HasChildQuery{Type: relation, Query: inner}, nil
+}
+
+func (p queryParser) parseInnerQuery(v any) (Query, error) {
+	if o, ok := v.(*JsonObject); ok {
+		return p.parsePointer(o)
+	}
+
+	o, ok := asMap(v)
+	if !ok {
+		return nil, fmt.Errorf("%w of query", errUnexpectedFormat)
+	}
+
+	return p.parse(o)
+}
+
+func parseTermsSetQuery(body map[string]any) (Query, bool) {
+	field, value, ok := getSingleEntry(body)
+	if !ok {
+		return nil, false
+	}
+
+	ts, ok := asMap(value)
+	if !ok || len(ts) != 2 {
+		return nil, false
+	}
+
+	terms, ok := ts["terms"].([]any)
+	if !ok {
+		return nil, false
+	}
+
+	script, ok := getStringOptions(ts["minimum_should_match_script"], []string{"source"}, nil)
+	if !ok {
+		return nil, false
+	}
+
+	return &TermsSetQuery{Field: field, Terms: terms, MinimumShouldMatchScript: script["source"]}, true
+}
+
+func parseRangeQuery(body map[string]any) (Query, bool) {
+	field, value, ok := getSingleEntry(body)
+	if !ok {
+		return nil, false
+	}
+
+	r, ok := asMap(value)
+	if !ok {
+		return nil, false
+	}
+
+	q := &RangeQuery{Field: field}
+
+	for k, v := range r {
+		if v == nil {
+			return nil, false
+		}
+
+		switch k {
+		case "gt":
+			q.Gt = v
+		case "gte":
+			q.Gte = v
+		case "lt":
+			q.Lt = v
+		case "lte":
+			q.Lte = v
+		case "format":
+			f, ok := v.(string)
+			if !ok || f == "" {
+				return nil, false
+			}
+
+			q.Format = f
+		default:
+			return nil, false
+		}
+	}
+
+	return q, true
+}
+
+func parseWildcardQuery(body map[string]any) (Query, bool) {
+	field, value, ok := getSingleEntry(body)
+	if !ok {
+		return nil, false
+	}
+
+	w, ok := asMap(value)
+	if !ok || len(w) != 2 {
+		return nil, false
+	}
+
+	v, ok := w["value"].(string)
+	if !ok {
+		return nil, false
+	}
+
+	ci, ok := w["case_insensitive"].(bool)
+	if !ok {
+		return nil, false
+	}
+
+	return &WildcardQuery{Field: field, Value: v, CaseInsensitive: ci}, true
+}
+
+// asMap returns v as a map if it is one of the map types used to build queries.
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case JsonObject:
+		return m, true
+	case *JsonObject:
+		if m == nil {
+			return nil, false
+		}
+
+		return *m, true
+	}
+
+	return nil, false
+}
+
+// getSingleEntry returns the key and value of a map with exactly one entry, which is how most queries are keyed by field.
+func getSingleEntry(m map[string]any) (string, any, bool) {
+	if len(m) != 1 {
+		return "", nil, false
+	}
+
+	for k, v := range m {
+		return k, v, true
+	}
+
+	return "", nil, false
+}
+
+// getStringOptions returns the options as strings, if all the required options are present, and there are no options other than the required, optional, and ignored ones.
+func getStringOptions(v any, required []string, optional []string, ignored ...string) (map[string]string, bool) {
+	m, ok := asMap(v)
+	if !ok {
+		return nil, false
+	}
+
+	options := map[string]string{}
+
+	for _, k := range required {
+		s, ok := m[k].(string)
+		if !ok {
+			return nil, false
+		}
+
+		options[k] = s
+	}
+
+	for _, k := range optional {
+		if _, present := m[k]; !present {
+			continue
+		}
+
+		s, ok := m[k].(string)
+		if !ok || s == "" {
+			return nil, false
+		}
+
+		options[k] = s
+	}
+
+	if len(m) != len(options)+len(ignored) {
+		return nil, false
+	}
+
+	for _, k := range ignored {
+		if _, present := m[k]; !present {
+			return nil, false
+		}
+	}
+
+	return options, true
+}
+
+func getFields(m map[string]any) ([]string, bool) {
+	fields, ok := m["fields"].([]string)
+	return fields, ok
+}
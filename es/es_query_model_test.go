@@ -0,0 +1,382 @@
+package astes
+
+import (
+	"encoding/json"
+	"testing"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryGeneratesTypedQueryFromDefaultEsQueryBuilder(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "AND",
+	"children": [
+		{
+			"type": "EQ",
+			"args": ["status", "live"]
+		},
+		{
+			"type": "GT",
+			"args": ["price", "5"]
+		}
+	]
+}`
+
+	qb := DefaultEsQueryBuilder{
+		FieldTypes: map[string]epsearchast.FieldType{
+			"price": epsearchast.Int64,
+		},
+	}
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	obj, err := epsearchast.SemanticReduceAst[JsonObject](astNode, qb)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := ParseQuery(*obj)
+	require.NoError(t, err)
+
+	// Verification
+	require.Equal(t, &BoolQuery{
+		Must: []Query{
+			&TermQuery{Field: "status", Value: "live"},
+			&RangeQuery{Field: "price", Gt: int64(5)},
+		},
+	}, query)
+}
+
+func TestTypedQueryCanBeModifiedWithoutTypeAssertionsOnMaps(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+	"type": "EQ",
+	"args": ["status", "live"]
+}`
+
+	//language=JSON
+	expectedJson := `{
+  "bool": {
+    "filter": [
+      {
+        "term": {
+          "tenant_id": "42"
+        }
+      }
+    ],
+    "must": [
+      {
+        "term": {
+          "status": "live"
+        }
+      }
+    ]
+  }
+}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	obj, err := epsearchast.SemanticReduceAst[JsonObject](astNode, DefaultEsQueryBuilder{})
+	require.NoError(t, err)
+
+	query, err := ParseQuery(*obj)
+	require.NoError(t, err)
+
+	// Execute SUT
+	withTenant := &BoolQuery{
+		Must:   []Query{query},
+		Filter: []Query{&TermQuery{Field: "tenant_id", Value: "42"}},
+	}
+
+	// Verification
+	queryJson, err := json.MarshalIndent(withTenant, "", "  ")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(queryJson))
+}
+
+func TestParseQueryReturnsRawQueryForUnknownQueries(t *testing.T) {
+	// Fixture Setup
+	o := JsonObject{
+		"geo_distance": map[string]any{
+			"distance": "10km",
+			"location": "drm3btev3e86",
+		},
+	}
+
+	// Execute SUT
+	query, err := ParseQuery(o)
+
+	// Verification
+	require.NoError(t, err)
+
+	raw, ok := query.(*RawQuery)
+	require.True(t, ok)
+	require.Equal(t, RawQuery(o), *raw)
+}
+
+func TestParseQueryReturnsErrorForUnexpectedFormat(t *testing.T) {
+	var testCases = map[string]struct {
+		query    JsonObject
+		expected string
+	}{
+		"term with two fields": {
+			query:    JsonObject{"term": map[string]any{"a": 1, "b": 2}},
+			expected: "invalid term query: unexpected format",
+		},
+		"range with unknown bound": {
+			query:    JsonObject{"range": map[string]any{"price": map[string]any{"above": 5}}},
+			expected: "invalid range query: unexpected format",
+		},
+		"exists with a string body": {
+			query:    JsonObject{"exists": "price"},
+			expected: "invalid exists query: unexpected format",
+		},
+		"bool with unknown clause": {
+			query:    JsonObject{"bool": map[string]any{"must": []*JsonObject{{"exists": map[string]any{"field": "a"}}}, "boost": 2}},
+			expected: "invalid bool query: unexpected format of boost",
+		},
+		"clause with unexpected format": {
+			query:    JsonObject{"bool": map[string]any{"must": []*JsonObject{{"wildcard": map[string]any{"slug": "shirt*"}}}}},
+			expected: "invalid bool query: invalid wildcard query: unexpected format",
+		},
+		"nested without a path": {
+			query:    JsonObject{"nested": JsonObject{"query": JsonObject{"exists": map[string]any{"field": "a"}}}},
+			expected: "invalid nested query: unexpected format",
+		},
+		"has_child with unexpected query": {
+			query:    JsonObject{"has_child": map[string]any{"type": "sku", "query": "eq"}},
+			expected: "invalid has_child query: unexpected format of query",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			query, err := ParseQuery(tc.query)
+
+			// Verification
+			require.EqualError(t, err, tc.expected)
+			require.Nil(t, query)
+		})
+	}
+}
+
+func TestParseQueryReturnsErrorForOverriddenQueryBuilderWithUnexpectedFormat(t *testing.T) {
+	// Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "live"]}`)
+	require.NoError(t, err)
+
+	obj, err := epsearchast.SemanticReduceAst[JsonObject](astNode, termWithBoostEsQueryBuilder{})
+	require.NoError(t, err)
+
+	// Execute SUT
+	_, err = ParseQuery(*obj)
+
+	// Verification
+	require.EqualError(t, err, "invalid term query: unexpected format")
+}
+
+// termWithBoostEsQueryBuilder generates term queries in a format that isn't part of the model.
+type termWithBoostEsQueryBuilder struct {
+	DefaultEsQueryBuilder
+}
+
+func (b termWithBoostEsQueryBuilder) VisitEq(first, second string) (*JsonObject, error) {
+	return &JsonObject{"term": map[string]any{first: map[string]any{"value": second, "boost": 2}, "_name": "eq"}}, nil
+}
+
+func TestParseQueryGeneratesSameJsonAsDefaultEsQueryBuilder(t *testing.T) {
+	nested := map[string]NestedReplacement{
+		`^variants\[(?P<idx>\d+)\]\.(?P<attr>[a-z]+)$`: {
+			Path: "variants",
+			Subqueries: map[string]Replacement{
+				"variants.idx":   {Value: "$idx", ForceEQ: true},
+				"variants.$attr": {Value: "$value"},
+			},
+		},
+	}
+
+	configs := map[string]DefaultEsQueryBuilder{
+		"default": {
+			NestedFieldToQuery: nested,
+		},
+		"terms set": {
+			NestedFieldToQuery:        nested,
+			UseTermsSetForContainsAll: true,
+		},
+		"joins": {
+			NestedFieldToQuery: nested,
+			JoinFieldToQuery: map[string]JoinReplacement{
+				`^tags$`:   {QueryType: JoinHasChild, Relation: "tag"},
+				`^status$`: {QueryType: JoinHasParent, Relation: "product", Field: "product_status"},
+			},
+		},
+		"filter context and flatten": {
+			NestedFieldToQuery: nested,
+			UseFilterContext:   true,
+			Flatten:            true,
+			DefaultFuzziness:   "AUTO",
+		},
+		"field types and null values": {
+			NestedFieldToQuery: nested,
+			FieldTypes: map[string]epsearchast.FieldType{
+				"price":      epsearchast.Float64,
+				"created_at": epsearchast.Date,
+			},
+			OpTypeToFieldNames: map[string]*OperatorTypeToMultiFieldName{
+				"status": {NullValue: "NULL"},
+			},
+			NullSemantics: epsearchast.NullSemanticsConfig{
+				Default: epsearchast.NullOrMissingOrEmptyArray,
+			},
+		},
+		"text strategies": {
+			FieldToTextStrategy: map[string]*TextStrategy{
+				"name":        {QueryType: TextQueryMatchPhrase, Analyzer: "english"},
+				"description": {QueryType: TextQueryMatchBoolPrefix, Fields: []string{"description", "description.ngram"}, MinimumShouldMatch: "2"},
+				"sku":         {QueryType: TextQuerySimpleQueryString, Operator: "or"},
+				"brand":       {QueryType: TextQueryCombinedFields, Fields: []string{"brand", "brand.raw"}},
+				"*":           {QueryType: TextQueryMultiMatch, Fields: []string{"name^3", "description"}, Fuzziness: "1"},
+			},
+		},
+	}
+
+	//language=JSON
+	jsonTxt := `{
+	"type": "AND",
+	"children": [
+		{
+			"type": "OR",
+			"children": [
+				{"type": "EQ", "args": ["status", "live"]},
+				{"type": "IN", "args": ["status", "draft", "pending"]}
+			]
+		},
+		{"type": "GE", "args": ["price", "1.5"]},
+		{"type": "LT", "args": ["created_at", "2024-01-01T00:00:00Z"]},
+		{"type": "LIKE", "args": ["slug", "shirt*"]},
+		{"type": "ILIKE", "args": ["slug", "*Blue"]},
+		{"type": "CONTAINS", "args": ["tags", "sale"]},
+		{"type": "CONTAINS_ANY", "args": ["tags", "new", "hot"]},
+		{"type": "CONTAINS_ALL", "args": ["tags", "a", "b"]},
+		{"type": "CONTAINS_ALL", "args": ["variants[2].tags", "a", "b"]},
+		{"type": "IS_NULL", "args": ["status"]},
+		{"type": "IS_NULL", "args": ["variants[0].colour"]},
+		{"type": "EQ", "args": ["variants[1].size", "large"]},
+		{"type": "TEXT", "args": ["name", "shirt"]},
+		{"type": "TEXT", "args": ["description", "cotton shirt"]},
+		{"type": "TEXT", "args": ["sku", "abc"]},
+		{"type": "TEXT", "args": ["brand", "acme"]},
+		{"type": "TEXT", "args": ["*", "blue"]},
+		{"type": "TEXT", "args": ["other", "blue"]}
+	]
+}`
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			expectedQuery, err := epsearchast.SemanticReduceAst[JsonObject](astNode, config)
+			require.NoError(t, err)
+
+			expectedJson, err := json.MarshalIndent(expectedQuery, "", "  ")
+			require.NoError(t, err)
+
+			// Execute SUT
+			query, err := ParseQuery(*expectedQuery)
+			require.NoError(t, err)
+
+			// Verification
+			queryJson, err := json.MarshalIndent(query, "", "  ")
+			require.NoError(t, err)
+
+			require.Equal(t, string(expectedJson), string(queryJson))
+			require.False(t, containsRawQuery(query), "all queries should be typed")
+		})
+	}
+}
+
+func TestParseQueryRoundTripsEveryOperator(t *testing.T) {
+	var configs = map[string]DefaultEsQueryBuilder{
+		"default": {},
+		"nested": {
+			NestedFieldToQuery: map[string]NestedReplacement{
+				`^(?P<field>[a-z_]+)$`: {
+					Path:       "items",
+					Subqueries: map[string]Replacement{"items.$field": {Value: "$value"}, "items.kind": {Value: "product", ForceEQ: true}},
+				},
+			},
+		},
+		"joins": {
+			JoinFieldToQuery: map[string]JoinReplacement{`^(?P<field>[a-z_]+)$`: {QueryType: JoinHasParent, Relation: "product"}},
+		},
+		"null values and terms set": {
+			OpTypeToFieldNames:        map[string]*OperatorTypeToMultiFieldName{"status": {NullValue: "NULL"}},
+			UseTermsSetForContainsAll: true,
+			UseFilterContext:          true,
+		},
+	}
+
+	filters := []string{
+		`eq(status,live)`, `in(status,live,draft)`, `lt(price,5)`, `le(price,5)`, `gt(price,5)`, `ge(price,5)`,
+		`like(slug,shirt*)`, `ilike(slug,*shirt)`, `contains(tags,a)`, `contains_any(tags,a,b)`, `contains_all(tags,a,b)`,
+		`text(name,shirt)`, `is_null(status)`, `eq(status,live)|is_null(status)`,
+	}
+
+	for name, config := range configs {
+		for _, filter := range filters {
+			t.Run(name+" "+filter, func(t *testing.T) {
+				// Fixture Setup
+				astNode, err := epsearchast.ParseFilter(filter)
+				require.NoError(t, err)
+
+				obj, err := epsearchast.SemanticReduceAst[JsonObject](astNode, config)
+				require.NoError(t, err)
+
+				query, err := ParseQuery(*obj)
+				require.NoError(t, err)
+
+				// Execute SUT
+				reparsed, err := ParseQuery(query.ToJsonObject())
+
+				// Verification
+				require.NoError(t, err)
+				require.Equal(t, query, reparsed)
+				require.False(t, containsRawQuery(reparsed), "all queries should be typed")
+			})
+		}
+	}
+}
+
+func containsRawQuery(q Query) bool {
+	switch q := q.(type) {
+	case *RawQuery:
+		return true
+	case *NestedQuery:
+		return containsRawQuery(q.Query)
+	case *HasChildQuery:
+		return containsRawQuery(q.Query)
+	case *HasParentQuery:
+		return containsRawQuery(q.Query)
+	case *BoolQuery:
+		for _, clauses := range [][]Query{q.Must, q.Filter, q.Should, q.MustNot} {
+			for _, c := range clauses {
+				if containsRawQuery(c) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
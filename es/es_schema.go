@@ -0,0 +1,61 @@
+package astes
+
+import (
+	"regexp"
+
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+)
+
+// EsQueryBuilderFromSchema returns a query builder with the field types, multi-fields, nested fields and joins from the schema, for an AST that has had the
+// Es mapping applied.
+//
+// The schema doesn't describe null semantics or text strategies, so NullSemantics, FieldToTextStrategy and the other options can be set on the result
+// (before calling MustCompile).
+func EsQueryBuilderFromSchema(s *astschema.Schema) DefaultEsQueryBuilder {
+	opTypeToFieldNames := map[string]*OperatorTypeToMultiFieldName{}
+	nestedFieldToQuery := map[string]NestedReplacement{}
+	joinFieldToQuery := map[string]JoinReplacement{}
+
+	for name, field := range s.Fields {
+		if field.Es == nil {
+			continue
+		}
+
+		mapped := s.MappedName(name, astschema.Es)
+
+		// The schema has field names rather than patterns, so each pattern only matches the field.
+		pattern := "^" + regexp.QuoteMeta(mapped) + "$"
+
+		if mf := field.Es.MultiFields; mf != nil {
+			opTypeToFieldNames[mapped] = &OperatorTypeToMultiFieldName{
+				Equality:   mf.Equality,
+				Relational: mf.Relational,
+				Text:       mf.Text,
+				Array:      mf.Array,
+				Wildcard:   mf.Wildcard,
+				NullValue:  mf.NullValue,
+			}
+		}
+
+		if n := field.Es.Nested; n != nil {
+			subqueries := map[string]Replacement{mapped: {Value: "$value"}}
+
+			for f, v := range n.Filter {
+				subqueries[f] = Replacement{Value: v, ForceEQ: true}
+			}
+
+			nestedFieldToQuery[pattern] = NestedReplacement{Path: n.Path, Subqueries: subqueries}
+		}
+
+		if j := field.Es.Join; j != nil {
+			joinFieldToQuery[pattern] = JoinReplacement{QueryType: JoinQueryType(j.Type), Relation: j.Relation}
+		}
+	}
+
+	return DefaultEsQueryBuilder{
+		OpTypeToFieldNames: opTypeToFieldNames,
+		NestedFieldToQuery: nestedFieldToQuery,
+		JoinFieldToQuery:   joinFieldToQuery,
+		FieldTypes:         s.MappedFieldTypes(astschema.Es),
+	}
+}
@@ -0,0 +1,139 @@
+package astes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEsQueryBuilderFromSchemaUsesFieldTypesAndMultiFields(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  status:
+    operators: [eq]
+    aliases: [state]
+  total:
+    operators: [gt]
+    type: int64
+    es:
+      field: meta.total
+      multi_fields:
+        relational: meta.total.numeric
+  name:
+    operators: [like]
+    es:
+      multi_fields:
+        wildcard: name.wildcard
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(state,paid):gt(total,5):like(name,Red*)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyAliases(ast)
+	require.NoError(t, err)
+
+	ast, err = s.ApplyMapping(ast, astschema.Es)
+	require.NoError(t, err)
+
+	//language=JSON
+	expected := `{
+		"bool": {
+			"must": [
+				{"term": {"status": "paid"}},
+				{"range": {"meta.total.numeric": {"gt": 5}}},
+				{"wildcard": {"name.wildcard": {"value": "Red*", "case_insensitive": false}}}
+			]
+		}
+	}`
+
+	// Execute SUT
+	qb := EsQueryBuilderFromSchema(s)
+	query, err := epsearchast.SemanticReduceAst(ast, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.JSONEq(t, expected, string(queryJson))
+	require.Equal(t, &OperatorTypeToMultiFieldName{Relational: "meta.total.numeric"}, qb.OpTypeToFieldNames["meta.total"])
+}
+
+func TestEsQueryBuilderFromSchemaUsesNestedFieldsAndJoins(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  item_sku:
+    operators: [eq]
+    es:
+      field: items.sku
+      nested:
+        path: items
+        filter:
+          items.kind: product
+  sku_price:
+    operators: [gt]
+    type: int64
+    es:
+      field: skus.price
+      join:
+        type: has_child
+        relation: sku
+      nested:
+        path: skus
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(item_sku,abc):gt(sku_price,5)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyMapping(ast, astschema.Es)
+	require.NoError(t, err)
+
+	//language=JSON
+	expected := `{
+		"bool": {
+			"must": [
+				{
+					"nested": {
+						"path": "items",
+						"query": {"bool": {"must": [{"term": {"items.kind": "product"}}, {"term": {"items.sku": "abc"}}]}}
+					}
+				},
+				{
+					"has_child": {
+						"type": "sku",
+						"query": {
+							"nested": {
+								"path": "skus",
+								"query": {"bool": {"must": [{"range": {"skus.price": {"gt": 5}}}]}}
+							}
+						}
+					}
+				}
+			]
+		}
+	}`
+
+	// Execute SUT
+	qb := EsQueryBuilderFromSchema(s).MustCompile()
+	query, err := epsearchast.SemanticReduceAst(ast, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	queryJson, err := json.Marshal(query)
+	require.NoError(t, err)
+
+	require.JSONEq(t, expected, string(queryJson))
+}
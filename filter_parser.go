@@ -0,0 +1,182 @@
+package epsearchast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFilter converts a filter in the format generated by AsFilter (e.g., eq(status,paid):(gt(price,5)|is_null(price))) to an AstNode, returning an error otherwise.
+//
+// A : is an AND, a | is an OR (AND takes precedence), and parentheses group filters. Arguments can be quoted with " (and a quote inside escaped with \"),
+// and whitespace around a quoted argument is ignored, otherwise the argument is everything up to the next , or ) including spaces.
+//
+// Like GetAst, if the Error is a ParsingErr the filter isn't syntactically valid, and if it's a ValidationErr the filter is syntactically valid but not a valid AST.
+func ParseFilter(filter string) (*AstNode, error) {
+	p := filterParser{s: filter}
+
+	astNode, err := p.parseOr()
+	if err == nil && p.skipWhitespace() < len(p.s) {
+		err = p.unexpected("end of filter")
+	}
+
+	if err != nil {
+		return nil, NewParsingErr(err)
+	}
+
+	if err := astNode.checkValid(); err != nil {
+		return nil, newValidationErrForAst(astNode, fmt.Errorf("(%s): %w", astNode.AsFilter(), err))
+	}
+
+	return astNode, nil
+}
+
+type filterParser struct {
+	s   string
+	pos int
+}
+
+func (p *filterParser) parseOr() (*AstNode, error) {
+	return p.parseConjunction("OR", '|', p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (*AstNode, error) {
+	return p.parseConjunction("AND", ':', p.parseTerm)
+}
+
+func (p *filterParser) parseConjunction(nodeType string, separator byte, parseChild func() (*AstNode, error)) (*AstNode, error) {
+	var children []*AstNode
+
+	for {
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, child)
+
+		if p.skipWhitespace() >= len(p.s) || p.s[p.pos] != separator {
+			break
+		}
+
+		p.pos++
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return &AstNode{NodeType: nodeType, Children: children}, nil
+}
+
+func (p *filterParser) parseTerm() (*AstNode, error) {
+	if p.skipWhitespace() >= len(p.s) {
+		return nil, p.unexpected("operator or (")
+	}
+
+	if p.s[p.pos] == '(' {
+		p.pos++
+
+		astNode, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+
+		return astNode, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || (p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') || (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z')) {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return nil, p.unexpected("operator or (")
+	}
+
+	astNode := &AstNode{NodeType: strings.ToUpper(p.s[start:p.pos])}
+
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+
+	for {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		astNode.Args = append(astNode.Args, arg)
+
+		if p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+
+		return astNode, nil
+	}
+}
+
+func (p *filterParser) parseArg() (string, error) {
+	start := p.pos
+
+	// Whitespace is only skipped around quoted arguments, unquoted arguments keep their spaces.
+	if p.skipWhitespace() < len(p.s) && p.s[p.pos] == '"' {
+		sb := strings.Builder{}
+
+		for p.pos++; p.pos < len(p.s); p.pos++ {
+			switch {
+			case p.s[p.pos] == '\\' && p.pos+1 < len(p.s) && p.s[p.pos+1] == '"':
+				sb.WriteByte('"')
+				p.pos++
+			case p.s[p.pos] == '"':
+				p.pos++
+				p.skipWhitespace()
+				return sb.String(), nil
+			default:
+				sb.WriteByte(p.s[p.pos])
+			}
+		}
+
+		return "", fmt.Errorf("unterminated quoted argument")
+	}
+
+	p.pos = start
+	for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != ')' {
+		p.pos++
+	}
+
+	return p.s[start:p.pos], nil
+}
+
+func (p *filterParser) expect(c byte) error {
+	if p.skipWhitespace() >= len(p.s) || p.s[p.pos] != c {
+		return p.unexpected(fmt.Sprintf("%q", c))
+	}
+
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) skipWhitespace() int {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+
+	return p.pos
+}
+
+func (p *filterParser) unexpected(expected string) error {
+	if p.pos >= len(p.s) {
+		return fmt.Errorf("expected %s but found end of filter", expected)
+	}
+
+	return fmt.Errorf("expected %s at position %d but found %q", expected, p.pos, p.s[p.pos])
+}
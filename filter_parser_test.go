@@ -0,0 +1,190 @@
+package epsearchast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterReturnsAst(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected *AstNode
+	}{
+		{`eq(status,paid)`, &AstNode{NodeType: "EQ", Args: []string{"status", "paid"}}},
+		{`eq("status","paid")`, &AstNode{NodeType: "EQ", Args: []string{"status", "paid"}}},
+		{`eq(name,Red Shirt)`, &AstNode{NodeType: "EQ", Args: []string{"name", "Red Shirt"}}},
+		{`eq(name,"a, (b) \"c\"")`, &AstNode{NodeType: "EQ", Args: []string{"name", `a, (b) "c"`}}},
+		{`eq(a, "x")`, &AstNode{NodeType: "EQ", Args: []string{"a", "x"}}},
+		{`eq(a,"x" )`, &AstNode{NodeType: "EQ", Args: []string{"a", "x"}}},
+		{`eq( "a" , " x " )`, &AstNode{NodeType: "EQ", Args: []string{"a", " x "}}},
+		{`eq(a, x )`, &AstNode{NodeType: "EQ", Args: []string{"a", " x "}}},
+		{`in(status,paid,pending,)`, &AstNode{NodeType: "IN", Args: []string{"status", "paid", "pending", ""}}},
+		{`is_null(status)`, &AstNode{NodeType: "IS_NULL", Args: []string{"status"}}},
+		{`CONTAINS_ALL(tags,a,b)`, &AstNode{NodeType: "CONTAINS_ALL", Args: []string{"tags", "a", "b"}}},
+		{
+			`eq(a,1):eq(b,2):eq(c,3)`,
+			&AstNode{NodeType: "AND", Children: []*AstNode{
+				{NodeType: "EQ", Args: []string{"a", "1"}},
+				{NodeType: "EQ", Args: []string{"b", "2"}},
+				{NodeType: "EQ", Args: []string{"c", "3"}},
+			}},
+		},
+		{
+			`eq(a,1):eq(b,2)|eq(c,3)`,
+			&AstNode{NodeType: "OR", Children: []*AstNode{
+				{NodeType: "AND", Children: []*AstNode{
+					{NodeType: "EQ", Args: []string{"a", "1"}},
+					{NodeType: "EQ", Args: []string{"b", "2"}},
+				}},
+				{NodeType: "EQ", Args: []string{"c", "3"}},
+			}},
+		},
+		{
+			` eq(a,1) : ( eq(b,2) | eq(c,3) ) `,
+			&AstNode{NodeType: "AND", Children: []*AstNode{
+				{NodeType: "EQ", Args: []string{"a", "1"}},
+				{NodeType: "OR", Children: []*AstNode{
+					{NodeType: "EQ", Args: []string{"b", "2"}},
+					{NodeType: "EQ", Args: []string{"c", "3"}},
+				}},
+			}},
+		},
+		{`((eq(a,1)))`, &AstNode{NodeType: "EQ", Args: []string{"a", "1"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			astNode, err := ParseFilter(tc.filter)
+
+			// Verify
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, astNode)
+		})
+	}
+}
+
+func TestParseFilterIsTheInverseOfAsFilter(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{"type": "EQ", "args": ["name", "a \"quoted\", (value)"]},
+			{
+				"type": "OR",
+				"children": [
+					{"type": "IN", "args": ["status", "paid", "pending"]},
+					{"type": "IS_NULL", "args": ["status"]},
+					{
+						"type": "AND",
+						"children": [
+							{"type": "GE", "args": ["price", "5"]},
+							{"type": "TEXT", "args": ["*", "red shirt"]}
+						]
+					}
+				]
+			}
+		]
+	}`
+
+	expected, err := GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	astNode, err := ParseFilter(expected.AsFilter())
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, expected.AsFilter(), astNode.AsFilter())
+	require.Equal(t, expected, astNode)
+}
+
+func TestParseFilterIsTheInverseOfAsFilterWithSpacesAroundQuotedArguments(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{"type": "EQ", "args": ["name", " padded \"value\" "]},
+			{
+				"type": "OR",
+				"children": [
+					{"type": "IN", "args": ["status", "paid", "pending"]},
+					{"type": "TEXT", "args": ["*", "red shirt"]}
+				]
+			}
+		]
+	}`
+
+	expected, err := GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	filter := strings.NewReplacer(`("`, `( "`, `","`, `" ,  "`, `")`, `"	)`).Replace(expected.AsFilter())
+
+	// Execute SUT
+	astNode, err := ParseFilter(filter)
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, expected, astNode)
+}
+
+func TestParseFilterReturnsParsingErrorForInvalidSyntax(t *testing.T) {
+	testCases := map[string]string{
+		"":                   "expected operator or ( but found end of filter",
+		"eq":                 `expected '(' but found end of filter`,
+		"eq(a,b":             `expected ')' but found end of filter`,
+		`eq(a,"b`:            "unterminated quoted argument",
+		"eq(a,b):":           "expected operator or ( but found end of filter",
+		"eq(a,b)|":           "expected operator or ( but found end of filter",
+		"(eq(a,b)":           `expected ')' but found end of filter`,
+		"eq(a,b))":           `expected end of filter at position 7 but found ')'`,
+		"eq(a,b) eq(c,d)":    `expected end of filter at position 8 but found 'e'`,
+		"1eq(a,b)":           `expected operator or ( at position 0 but found '1'`,
+		`eq(a,"b"c)`:         `expected ')' at position 8 but found 'c'`,
+		"eq(a,b):&gt(c,d)":   `expected operator or ( at position 8 but found '&'`,
+		"eq(a,b):(eq(c,d)|)": `expected operator or ( at position 17 but found ')'`,
+	}
+
+	for filter, expectedErr := range testCases {
+		t.Run(filter, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			astNode, err := ParseFilter(filter)
+
+			// Verify
+			require.EqualError(t, err, "could not parse filter: "+expectedErr)
+			require.ErrorAs(t, err, &ParsingErr{})
+			require.Nil(t, astNode)
+		})
+	}
+}
+
+func TestParseFilterReturnsValidationErrorForInvalidAst(t *testing.T) {
+	testCases := map[string]string{
+		"foo(a,b)":     "error validating filter: (foo(\"a\",\"b\")): unsupported operator foo()",
+		"eq(a)":        "error validating filter: (eq(\"a\")): operator eq should have exactly 2 arguments",
+		"in(a)":        "error validating filter: (in(\"a\")): insufficient number of arguments to in",
+		"is_null(a,b)": "error validating filter: (is_null(\"a\",\"b\")): operator is_null should have exactly 1 argument",
+	}
+
+	for filter, expectedErr := range testCases {
+		t.Run(filter, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			astNode, err := ParseFilter(filter)
+
+			// Verify
+			require.EqualError(t, err, expectedErr)
+			require.ErrorAs(t, err, &ValidationErr{})
+			require.Nil(t, astNode)
+		})
+	}
+}
@@ -15,10 +15,35 @@ type SubQuery struct {
 	Args []interface{}
 }
 
-type DefaultGormQueryBuilder struct{}
+type DefaultGormQueryBuilder struct {
+	// NullSemantics controls what is_null matches. SQL has no notion of a missing column, a NULL represents both an explicit null and a missing value,
+	// so epsearchast.ExplicitNullOnly and epsearchast.MissingOnly are not supported.
+	// epsearchast.NullOrMissingOrEmptyArray additionally matches empty Postgres arrays in ArrayColumns.
+	NullSemantics epsearchast.NullSemanticsConfig
+
+	// ArrayColumns are the columns (after aliases have been processed) that are Postgres arrays. cardinality() is an error on any other column,
+	// so epsearchast.NullOrMissingOrEmptyArray only checks for an empty array in these columns, and is the same as epsearchast.NullOrMissing otherwise.
+	ArrayColumns map[string]bool
+}
 
 var _ epsearchast.SemanticReducer[SubQuery] = (*DefaultGormQueryBuilder)(nil)
 
+// MustValidate will ensure that the configuration of the query builder is correct and if not, panics. It simplifies safe initialization of the variable.
+func (g DefaultGormQueryBuilder) MustValidate() {
+	if err := g.NullSemantics.ValidateSupported("SQL", epsearchast.NullOrMissing, epsearchast.NullOrMissingOrEmptyArray); err != nil {
+		panic(fmt.Sprintf("Invalid null semantics: %v", err))
+	}
+}
+
+// MustCompile validates the configuration with MustValidate, and returns a copy of the query builder with the null semantics patterns compiled.
+func (g DefaultGormQueryBuilder) MustCompile() DefaultGormQueryBuilder {
+	g.MustValidate()
+
+	g.NullSemantics = g.NullSemantics.MustCompile()
+
+	return g
+}
+
 func (g DefaultGormQueryBuilder) PostVisitAnd(sqs []*SubQuery) (*SubQuery, error) {
 	clauses := make([]string, 0, len(sqs))
 	args := make([]interface{}, 0)
@@ -138,9 +163,24 @@ func (g DefaultGormQueryBuilder) VisitText(first, second string) (*SubQuery, err
 }
 
 func (g DefaultGormQueryBuilder) VisitIsNull(first string) (*SubQuery, error) {
-	return &SubQuery{
-		Clause: fmt.Sprintf("%s IS NULL", first),
-	}, nil
+	switch s := g.NullSemantics.ForField(first); s {
+	case epsearchast.DefaultNullSemantics, epsearchast.NullOrMissing:
+		return &SubQuery{
+			Clause: fmt.Sprintf("%s IS NULL", first),
+		}, nil
+	case epsearchast.NullOrMissingOrEmptyArray:
+		if !g.ArrayColumns[first] {
+			return &SubQuery{
+				Clause: fmt.Sprintf("%s IS NULL", first),
+			}, nil
+		}
+
+		return &SubQuery{
+			Clause: fmt.Sprintf("( %s IS NULL OR cardinality(%s) = 0 )", first, first),
+		}, nil
+	default:
+		return nil, fmt.Errorf("null semantics %s are not supported in SQL for field [%s]", s, first)
+	}
 }
 
 func (g DefaultGormQueryBuilder) ProcessLikeWildcards(valString string) string {
@@ -767,6 +767,92 @@ func TestSmokeTestPostgresWithFilters(t *testing.T) {
 
 }
 
+func TestSmokeTestPostgresIsNullWithNullSemantics(t *testing.T) {
+	// This is the same logical data set used in the Mongo and Elasticsearch tests, SQL has no missing columns
+	// so the document without the field is stored as NULL.
+	documents := []TestTable{
+		{
+			StringField: "explicit_null",
+			ArrayField:  nil,
+		}, {
+			StringField: "has_value",
+			ArrayField:  []string{"a"},
+		}, {
+			StringField: "missing",
+		}, {
+			StringField: "empty_array",
+			ArrayField:  []string{},
+		},
+	}
+
+	var testCases = []struct {
+		semantics epsearchast.NullSemantics
+		field     string
+		count     int64
+		supported bool
+	}{
+		{epsearchast.ExplicitNullOnly, "array_field", 1, false},
+		{epsearchast.MissingOnly, "array_field", 1, false},
+		{epsearchast.NullOrMissing, "array_field", 2, true},
+		{epsearchast.NullOrMissingOrEmptyArray, "array_field", 3, true},
+		// cardinality() is an error on a column that isn't an array, so this is the same as NullOrMissing.
+		{epsearchast.NullOrMissingOrEmptyArray, "nullable_string_field", 4, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String()+" "+tc.field, func(t *testing.T) {
+			testName = t.Name()
+			/*
+				Fixture Setup
+			*/
+			ctx := context.Background()
+			SetupDB(t, ctx, postgresDB)
+			InsertDocumentsOrFail(t, postgresDB, documents)
+
+			/*
+			  Execute SUT
+			*/
+			var qb epsearchast.SemanticReducer[SubQuery] = DefaultGormQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+				ArrayColumns: map[string]bool{"array_field": true},
+			}
+
+			ast, err := epsearchast.GetAst(fmt.Sprintf(`{"type": "IS_NULL", "args": ["%s"]}`, tc.field))
+			if err != nil {
+				t.Fatalf("Failed to get filter: %v", err)
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+
+			/*
+				Verification
+			*/
+			if !tc.supported {
+				if err == nil {
+					t.Fatalf("Expected an error for unsupported null semantics %s", tc.semantics)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to convert filter: %v", err)
+			}
+
+			var count int64
+			err = postgresDB.Model(&TestTable{}).Where(query.Clause, query.Args...).Count(&count).Error
+			if err != nil {
+				t.Fatalf("Failed to count documents: %v", err)
+			}
+
+			if count != tc.count {
+				t.Errorf("Expected count %d, but got %d", tc.count, count)
+			}
+		})
+	}
+}
+
 func InsertDocumentsOrFail(t *testing.T, db *gorm.DB, documents []TestTable) {
 
 	for _, doc := range documents {
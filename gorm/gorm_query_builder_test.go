@@ -89,6 +89,135 @@ func TestSimpleUnaryOperatorFiltersGeneratesCorrectWhereClause(t *testing.T) {
 
 }
 
+func TestIsNullOperatorFiltersGeneratesCorrectWhereClauseWithNullSemantics(t *testing.T) {
+	var testCases = []struct {
+		semantics      epsearchast.NullSemantics
+		expectedClause string
+	}{
+		{epsearchast.DefaultNullSemantics, "amount IS NULL"},
+		{epsearchast.NullOrMissing, "amount IS NULL"},
+		{epsearchast.NullOrMissingOrEmptyArray, "( amount IS NULL OR cardinality(amount) = 0 )"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			jsonTxt := `
+				{
+				"type": "IS_NULL",
+				"args": [ "amount"]
+			}`
+
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			var sr epsearchast.SemanticReducer[SubQuery] = DefaultGormQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+				ArrayColumns: map[string]bool{"amount": true},
+			}
+
+			// Execute SUT
+			query, err := epsearchast.SemanticReduceAst(astNode, sr)
+
+			// Verification
+
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedClause, query.Clause)
+		})
+	}
+}
+
+func TestIsNullOperatorFiltersGeneratesErrorWithUnsupportedNullSemantics(t *testing.T) {
+	for _, semantics := range []epsearchast.NullSemantics{epsearchast.ExplicitNullOnly, epsearchast.MissingOnly} {
+		t.Run(semantics.String(), func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			jsonTxt := `
+				{
+				"type": "IS_NULL",
+				"args": [ "amount"]
+			}`
+
+			astNode, err := epsearchast.GetAst(jsonTxt)
+			require.NoError(t, err)
+
+			var sr epsearchast.SemanticReducer[SubQuery] = DefaultGormQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: semantics,
+				},
+			}
+
+			// Execute SUT
+			_, err = epsearchast.SemanticReduceAst(astNode, sr)
+
+			// Verification
+			require.ErrorContains(t, err, "are not supported in SQL")
+		})
+	}
+}
+
+func TestMustValidatePanicsWithUnsupportedNullSemantics(t *testing.T) {
+	// Fixture Setup
+	qb := DefaultGormQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			FieldOverrides: map[string]epsearchast.NullSemantics{
+				"amount": epsearchast.MissingOnly,
+			},
+		},
+	}
+
+	// Execute SUT & Verification
+	require.PanicsWithValue(t, "Invalid null semantics: null semantics missing_only are not supported in SQL for field [amount]", func() {
+		qb.MustValidate()
+	})
+}
+
+func TestMustCompileGeneratesCorrectWhereClauseWithNullSemanticsOverride(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["tags"]}`)
+	require.NoError(t, err)
+
+	qb := DefaultGormQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			FieldOverrides: map[string]epsearchast.NullSemantics{
+				"^ta.*$": epsearchast.NullOrMissingOrEmptyArray,
+			},
+		},
+		ArrayColumns: map[string]bool{"tags": true},
+	}.MustCompile()
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[SubQuery](qb))
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, "( tags IS NULL OR cardinality(tags) = 0 )", query.Clause)
+}
+
+func TestIsNullWithEmptyArraySemanticsOnlyChecksCardinalityOfArrayColumns(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.ParseFilter(`is_null(tags):is_null(name)`)
+	require.NoError(t, err)
+
+	qb := DefaultGormQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			Default: epsearchast.NullOrMissingOrEmptyArray,
+		},
+		ArrayColumns: map[string]bool{"tags": true},
+	}.MustCompile()
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[SubQuery](qb))
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, "( ( tags IS NULL OR cardinality(tags) = 0 ) AND name IS NULL )", query.Clause)
+}
+
 func TestSimpleVariableOperatorFiltersGeneratesCorrectWhereClause(t *testing.T) {
 	for _, varOp := range varOps {
 		t.Run(fmt.Sprintf("%s", varOp.AstOp), func(t *testing.T) {
@@ -0,0 +1,22 @@
+package astgorm
+
+import (
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+)
+
+// GormQueryBuilderFromSchema returns a query builder with the array columns from the schema, for an AST that has had the Sql mapping applied.
+//
+// The GORM builder doesn't use field types, and the schema doesn't describe null semantics, so NullSemantics can be set on the result.
+func GormQueryBuilderFromSchema(s *astschema.Schema) DefaultGormQueryBuilder {
+	arrayColumns := map[string]bool{}
+
+	for name, field := range s.Fields {
+		if field.Sql != nil && field.Sql.Array {
+			arrayColumns[s.MappedName(name, astschema.Sql)] = true
+		}
+	}
+
+	return DefaultGormQueryBuilder{
+		ArrayColumns: arrayColumns,
+	}
+}
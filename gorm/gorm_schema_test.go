@@ -0,0 +1,43 @@
+package astgorm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormQueryBuilderFromSchemaUsesArrayColumns(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  tags:
+    operators: [is_null]
+    sql:
+      column: order_tags
+      array: true
+  status:
+    operators: [is_null]
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`is_null(tags):is_null(status)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyMapping(ast, astschema.Sql)
+	require.NoError(t, err)
+
+	qb := GormQueryBuilderFromSchema(s)
+	qb.NullSemantics = epsearchast.NullSemanticsConfig{Default: epsearchast.NullOrMissingOrEmptyArray}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(ast, qb.MustCompile())
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"order_tags": true}, qb.ArrayColumns)
+	require.Equal(t, "( ( order_tags IS NULL OR cardinality(order_tags) = 0 ) AND status IS NULL )", query.Clause)
+}
@@ -0,0 +1,159 @@
+package asthttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+)
+
+// DefaultVersion is the version of the EP-Internal-Search-Ast header that is read by default.
+const DefaultVersion = 3
+
+// Validation is the configuration used to validate every filter, see epsearchast.ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIndexIntersections.
+type Validation struct {
+	// AllowedOps is the operators that are allowed for each field, fields that aren't in the map are rejected.
+	AllowedOps map[string][]string
+
+	// Aliases are alternate names for fields, they are applied to the AST after it is validated so handlers only see the real field names.
+	Aliases map[string]string
+
+	// ValueValidators are go-playground/validator rules for the values of a field (e.g., oneof=paid pending).
+	ValueValidators map[string]string
+
+	// FieldTypes are the types of fields, values that can't be converted to the type are rejected.
+	FieldTypes map[string]epsearchast.FieldType
+
+	// AllowedIndexIntersections limits how complex OR filters can be (see epsearchast.GetEffectiveIndexIntersectionCount), if zero there is no limit.
+	AllowedIndexIntersections uint64
+}
+
+// Option configures the Middleware.
+type Option func(*middleware)
+
+// WithHeaderName sets the name of the header the filter is read from, instead of one derived from the version.
+func WithHeaderName(headerName string) Option {
+	return func(m *middleware) {
+		m.headerName = headerName
+	}
+}
+
+// WithVersion sets the version of the header the filter is read from (e.g., 3 reads EP-Internal-Search-Ast-v3).
+func WithVersion(version int) Option {
+	return func(m *middleware) {
+		m.headerName = headerNameForVersion(version)
+	}
+}
+
+// WithProblemDetails responds with RFC 7807 problem details (application/problem+json) instead of JSON:API errors when the filter is invalid.
+func WithProblemDetails() Option {
+	return func(m *middleware) {
+		m.writeError = WriteProblemDetails
+	}
+}
+
+type middleware struct {
+	validation Validation
+	headerName string
+	writeError func(w http.ResponseWriter, err error)
+	next       http.Handler
+}
+
+type contextKey struct{}
+
+// Middleware returns a function that wraps a handler so that the filter in the EP-Internal-Search-Ast header is parsed, validated, and has aliases applied
+// before the handler is called. The handler can retrieve the AST with AstFromContext, and if the request has no header, the handler is called without one.
+//
+// If the filter is invalid the handler isn't called, instead the middleware responds with a JSON:API error (or problem details with WithProblemDetails),
+// a 400 if the filter isn't valid (a ValidationErr), and a 500 otherwise, as a header that can't be parsed is a problem with whatever generated it and not the caller.
+//
+// An error is returned if the Validation itself isn't valid (e.g., an alias points to a field that isn't allowed).
+func Middleware(validation Validation, opts ...Option) (func(http.Handler) http.Handler, error) {
+	if _, err := epsearchast.NewValidatingVisitor(validation.AllowedOps, validation.Aliases, validation.ValueValidators, validation.FieldTypes); err != nil {
+		return nil, fmt.Errorf("invalid validation: %w", err)
+	}
+
+	m := middleware{
+		validation: validation,
+		headerName: headerNameForVersion(DefaultVersion),
+		writeError: WriteError,
+	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		m := m
+		m.next = next
+
+		return &m
+	}, nil
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := r.Header.Get(m.headerName)
+
+	if header == "" {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	ast, err := m.getAst(header)
+	if err != nil {
+		m.writeError(w, err)
+		return
+	}
+
+	m.next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), ast)))
+}
+
+func (m *middleware) getAst(header string) (*epsearchast.AstNode, error) {
+	ast, err := epsearchast.GetAst(header)
+	if err != nil {
+		return nil, err
+	}
+
+	v := m.validation
+
+	err = epsearchast.ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIndexIntersections(ast, v.AllowedOps, v.Aliases, v.ValueValidators, v.FieldTypes, v.AllowedIndexIntersections)
+	if err != nil {
+		return nil, err
+	}
+
+	return epsearchast.ApplyAliases(ast, v.Aliases)
+}
+
+// NewContext returns a copy of the context that carries the AST, which is useful for testing handlers without the Middleware.
+func NewContext(ctx context.Context, ast *epsearchast.AstNode) context.Context {
+	return context.WithValue(ctx, contextKey{}, ast)
+}
+
+// AstFromContext returns the validated AST (with aliases applied) stored by the Middleware, and false if the request didn't have a filter.
+func AstFromContext(ctx context.Context) (*epsearchast.AstNode, bool) {
+	ast, ok := ctx.Value(contextKey{}).(*epsearchast.AstNode)
+	return ast, ok && ast != nil
+}
+
+// WriteError writes err as a JSON:API error document (see epsearchast.NewJsonApiErrors), with a 400 status for a ValidationErr and a 500 status otherwise.
+func WriteError(w http.ResponseWriter, err error) {
+	writeJson(w, epsearchast.JsonApiContentType, epsearchast.HttpStatus(err), epsearchast.NewJsonApiErrors(err))
+}
+
+// WriteProblemDetails writes err as an RFC 7807 problem details document (see epsearchast.NewProblemDetails), with a 400 status for a ValidationErr and a 500 status otherwise.
+func WriteProblemDetails(w http.ResponseWriter, err error) {
+	writeJson(w, epsearchast.ProblemDetailsContentType, epsearchast.HttpStatus(err), epsearchast.NewProblemDetails(err))
+}
+
+func writeJson(w http.ResponseWriter, contentType string, status int, body any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func headerNameForVersion(version int) string {
+	return fmt.Sprintf("EP-Internal-Search-Ast-v%d", version)
+}
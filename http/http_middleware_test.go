@@ -0,0 +1,248 @@
+package asthttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+var validation = Validation{
+	AllowedOps: map[string][]string{
+		"status": {"eq", "in"},
+		"price":  {"gt", "lt"},
+	},
+	Aliases: map[string]string{
+		"state": "status",
+	},
+	ValueValidators: map[string]string{
+		"status": "oneof=paid pending",
+	},
+	FieldTypes: map[string]epsearchast.FieldType{
+		"price": epsearchast.Int64,
+	},
+	AllowedIndexIntersections: 4,
+}
+
+// serve sends a request with the headers through the middleware, and returns the response and the AST the handler received (if it was called).
+func serve(t *testing.T, headers map[string]string, opts ...Option) (*httptest.ResponseRecorder, *epsearchast.AstNode, bool) {
+	mw, err := Middleware(validation, opts...)
+	require.NoError(t, err)
+
+	var ast *epsearchast.AstNode
+	var called, found bool
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		ast, found = AstFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		return rec, nil, false
+	}
+
+	require.Equal(t, found, ast != nil)
+
+	return rec, ast, true
+}
+
+func TestMiddlewareStoresValidatedAstWithAliasesApplied(t *testing.T) {
+	//language=JSON
+	header := `{"type": "AND", "children": [{"type": "EQ", "args": ["state", "paid"]}, {"type": "GT", "args": ["price", "5"]}]}`
+
+	testCases := map[string]string{
+		"json":        header,
+		"url encoded": url.QueryEscape(header),
+	}
+
+	for name, headerValue := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			rec, ast, called := serve(t, map[string]string{"EP-Internal-Search-Ast-v3": headerValue})
+
+			// Verification
+			require.True(t, called)
+			require.Equal(t, http.StatusNoContent, rec.Code)
+			require.Equal(t, `eq("status","paid"):gt("price","5")`, ast.AsFilter())
+		})
+	}
+}
+
+func TestMiddlewareCallsHandlerWithoutAstWhenHeaderIsMissing(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	rec, ast, called := serve(t, map[string]string{})
+
+	// Verification
+	require.True(t, called)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Nil(t, ast)
+}
+
+func TestMiddlewareReadsConfiguredHeader(t *testing.T) {
+	//language=JSON
+	header := `{"type": "EQ", "args": ["status", "paid"]}`
+
+	testCases := []struct {
+		name       string
+		opts       []Option
+		headerName string
+		found      bool
+	}{
+		{"default version", nil, "EP-Internal-Search-Ast-v3", true},
+		{"header is case insensitive", nil, "ep-internal-search-ast-v3", true},
+		{"other version is ignored by default", nil, "EP-Internal-Search-Ast-v4", false},
+		{"version", []Option{WithVersion(4)}, "EP-Internal-Search-Ast-v4", true},
+		{"version ignores default", []Option{WithVersion(4)}, "EP-Internal-Search-Ast-v3", false},
+		{"header name", []Option{WithHeaderName("X-Filter")}, "X-Filter", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			rec, ast, called := serve(t, map[string]string{tc.headerName: header}, tc.opts...)
+
+			// Verification
+			require.True(t, called)
+			require.Equal(t, http.StatusNoContent, rec.Code)
+			require.Equal(t, tc.found, ast != nil)
+		})
+	}
+}
+
+func TestMiddlewareRespondsWithJsonApiErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		status   int
+		expected string
+	}{
+		{
+			name:   "unparseable header",
+			header: `{"type": `,
+			status: http.StatusInternalServerError,
+			//language=JSON
+			expected: `{"errors": [{"status": "500", "title": "Internal Server Error", "detail": "could not parse filter: error parsing decoded filter: unexpected end of JSON input unexpected end of JSON input"}]}`,
+		},
+		{
+			name:   "invalid ast",
+			header: `{"type": "EQ", "args": ["status"]}`,
+			status: http.StatusBadRequest,
+			//language=JSON
+			expected: `{"errors": [{"status": "400", "title": "Bad Request", "detail": "error validating filter: (eq(\"status\")): operator eq should have exactly 2 arguments", "source": {"parameter": "filter"}, "meta": {"filter": "eq(\"status\")"}}]}`,
+		},
+		{
+			name:   "unknown field",
+			header: `{"type": "EQ", "args": ["name", "shirt"]}`,
+			status: http.StatusBadRequest,
+			//language=JSON
+			expected: `{"errors": [{"status": "400", "title": "Bad Request", "detail": "error validating filter: unknown field [name] specified in search filter, allowed fields are [price status]", "source": {"parameter": "filter"}, "meta": {"filter": "eq(\"name\",\"shirt\")"}}]}`,
+		},
+		{
+			name:   "invalid value",
+			header: `{"type": "EQ", "args": ["status", "draft"]}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid type",
+			header: `{"type": "GT", "args": ["price", "cheap"]}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "too complex",
+			header: `{"type": "OR", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "EQ", "args": ["status", "pending"]}, {"type": "GT", "args": ["price", "1"]}, {"type": "LT", "args": ["price", "2"]}, {"type": "GT", "args": ["price", "3"]}]}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			rec, _, called := serve(t, map[string]string{"EP-Internal-Search-Ast-v3": tc.header})
+
+			// Verification
+			require.False(t, called)
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, "application/vnd.api+json", rec.Header().Get("Content-Type"))
+
+			if tc.expected != "" {
+				require.JSONEq(t, tc.expected, rec.Body.String())
+			} else {
+				require.Contains(t, rec.Body.String(), "error validating filter")
+			}
+		})
+	}
+}
+
+func TestMiddlewareRespondsWithProblemDetails(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	header := `{"type": "EQ", "args": ["name", "shirt"]}`
+
+	//language=JSON
+	expected := `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "error validating filter: unknown field [name] specified in search filter, allowed fields are [price status]",
+		"filter": "eq(\"name\",\"shirt\")"
+	}`
+
+	// Execute SUT
+	rec, _, called := serve(t, map[string]string{"EP-Internal-Search-Ast-v3": header}, WithProblemDetails())
+
+	// Verification
+	require.False(t, called)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, expected, rec.Body.String())
+}
+
+func TestMiddlewareReturnsErrorForInvalidValidation(t *testing.T) {
+	// Fixture Setup
+	invalid := Validation{
+		AllowedOps: map[string][]string{"status": {"eq"}},
+		Aliases:    map[string]string{"state": "unknown"},
+	}
+
+	// Execute SUT
+	mw, err := Middleware(invalid)
+
+	// Verification
+	require.ErrorContains(t, err, "invalid validation: alias from `state` to `unknown` points to a field not in the allowed ops")
+	require.Nil(t, mw)
+}
+
+func TestAstFromContext(t *testing.T) {
+	// Fixture Setup
+	ast := &epsearchast.AstNode{NodeType: "EQ", Args: []string{"status", "paid"}}
+
+	// Execute SUT
+	found, ok := AstFromContext(NewContext(context.Background(), ast))
+	_, missingOk := AstFromContext(context.Background())
+
+	// Verification
+	require.True(t, ok)
+	require.Same(t, ast, found)
+	require.False(t, missingOk)
+}
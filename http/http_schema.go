@@ -0,0 +1,16 @@
+package asthttp
+
+import (
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+)
+
+// ValidationFromSchema returns the validation for the Middleware from the schema, which applies aliases but not backend mappings.
+func ValidationFromSchema(s *astschema.Schema) Validation {
+	return Validation{
+		AllowedOps:                s.AllowedOps(),
+		Aliases:                   s.Aliases(),
+		ValueValidators:           s.ValueValidators(),
+		FieldTypes:                s.FieldTypes(),
+		AllowedIndexIntersections: s.AllowedIndexIntersections,
+	}
+}
@@ -0,0 +1,38 @@
+package asthttp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationFromSchema(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+allowed_index_intersections: 4
+fields:
+  status:
+    operators: [eq, in]
+    aliases: [state]
+    validator: oneof=paid pending
+  total:
+    operators: [gt]
+    type: int64
+`))
+	require.NoError(t, err)
+
+	// Execute SUT
+	validation := ValidationFromSchema(s)
+
+	// Verification
+	require.Equal(t, Validation{
+		AllowedOps:                map[string][]string{"status": {"eq", "in"}, "total": {"gt"}},
+		Aliases:                   map[string]string{"state": "status"},
+		ValueValidators:           map[string]string{"status": "oneof=paid pending"},
+		FieldTypes:                map[string]epsearchast.FieldType{"total": epsearchast.Int64},
+		AllowedIndexIntersections: 4,
+	}, validation)
+}
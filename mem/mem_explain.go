@@ -0,0 +1,233 @@
+package astmem
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+)
+
+// Reason explains why a node in the AST did not match (or for is_null, why it did).
+type Reason string
+
+const (
+	// ReasonMissingField means the field isn't in the document.
+	ReasonMissingField Reason = "missing_field"
+	// ReasonTypeMismatch means the field has values, but none of them can be compared with the filter (e.g., a string that isn't a number for an Int64 field).
+	ReasonTypeMismatch Reason = "type_mismatch"
+	// ReasonValueMismatch means the values of the field were compared with the filter, but didn't match.
+	ReasonValueMismatch Reason = "value_mismatch"
+	// ReasonWildcardFailed means no value of the field matched the like() or ilike() pattern.
+	ReasonWildcardFailed Reason = "wildcard_failed"
+	// ReasonTextNotMatched means not every term in a text() search was found.
+	ReasonTextNotMatched Reason = "text_not_matched"
+	// ReasonNotNull means the field has a value, so is_null() didn't match.
+	ReasonNotNull Reason = "not_null"
+	// ReasonExcludedByNullSemantics means the field is null, missing or an empty array, but the NullSemantics of the field don't match it.
+	ReasonExcludedByNullSemantics Reason = "excluded_by_null_semantics"
+	// ReasonNullOrMissing means the field is null or missing (or an empty array, depending on the NullSemantics), so is_null() matched.
+	ReasonNullOrMissing Reason = "null_or_missing"
+	// ReasonChildNotMatched means at least one child of an AND didn't match.
+	ReasonChildNotMatched Reason = "child_not_matched"
+	// ReasonNoChildMatched means none of the children of an OR matched.
+	ReasonNoChildMatched Reason = "no_child_matched"
+)
+
+// Explanation is a tree that mirrors the AST, and explains whether a document matched each node.
+type Explanation struct {
+	// Filter is the node rendered with epsearchast.AstNode.AsFilter.
+	Filter string `json:"filter"`
+
+	Matched bool `json:"matched"`
+
+	// Field is the field the node filters on, it is empty for AND and OR.
+	Field string `json:"field,omitempty"`
+
+	// Values are the values of the field in the document, with arrays replaced by their elements (except for is_null), and nil for null.
+	// They are not collected for text(*,...).
+	Values []any `json:"values,omitempty"`
+
+	// Reason is empty if the node matched, except for is_null.
+	Reason Reason `json:"reason,omitempty"`
+
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// Explain evaluates the AST against the document (which can be anything a Predicate supports), and returns an Explanation of why it did or did not match.
+// Unlike a Predicate, every node is evaluated.
+func Explain(a *epsearchast.AstNode, qb DefaultMemQueryBuilder, doc any) (*Explanation, error) {
+	root, err := getDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return epsearchast.ReduceAst(a, func(node *epsearchast.AstNode, children []*Explanation) (*Explanation, error) {
+		e := &Explanation{
+			Filter:   node.AsFilter(),
+			Children: children,
+		}
+
+		switch node.NodeType {
+		case "AND":
+			e.Matched = true
+
+			for _, c := range children {
+				e.Matched = e.Matched && c.Matched
+			}
+
+			if !e.Matched {
+				e.Reason = ReasonChildNotMatched
+			}
+		case "OR":
+			for _, c := range children {
+				e.Matched = e.Matched || c.Matched
+			}
+
+			if !e.Matched {
+				e.Reason = ReasonNoChildMatched
+			}
+		default:
+			if err := qb.explainLeaf(node, doc, root, e); err != nil {
+				return nil, err
+			}
+		}
+
+		return e, nil
+	})
+}
+
+func (d DefaultMemQueryBuilder) explainLeaf(node *epsearchast.AstNode, doc any, root document, e *Explanation) error {
+	predicate, err := Compile(&epsearchast.AstNode{NodeType: node.NodeType, Args: node.Args}, d)
+	if err != nil {
+		return err
+	}
+
+	if e.Matched, err = predicate(doc); err != nil {
+		return err
+	}
+
+	e.Field = node.Args[0]
+	p := newFieldPath(e.Field)
+
+	var values []reflect.Value
+	collect := func(v reflect.Value) bool {
+		values = append(values, v)
+		return false
+	}
+
+	switch {
+	case node.NodeType == "IS_NULL":
+		_, err = d.visitField(root, p, collect)
+	case e.Field != "*":
+		_, err = d.visitFieldElements(root, p, collect)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		e.Values = append(e.Values, toInterface(v))
+	}
+
+	switch {
+	case node.NodeType == "IS_NULL" && e.Matched:
+		e.Reason = ReasonNullOrMissing
+	case e.Matched:
+	case node.NodeType == "TEXT" && e.Field == "*":
+		e.Reason = ReasonTextNotMatched
+	case node.NodeType == "IS_NULL":
+		e.Reason = ReasonExcludedByNullSemantics
+
+		for _, v := range values {
+			if v = indirect(v); !isNull(v) && !(isArray(v) && v.Len() == 0) {
+				e.Reason = ReasonNotNull
+			}
+		}
+	case len(values) == 0:
+		e.Reason = ReasonMissingField
+	case node.NodeType == "LIKE" || node.NodeType == "ILIKE" || node.NodeType == "TEXT":
+		e.Reason = ReasonTypeMismatch
+
+		for _, v := range values {
+			if _, ok := appendString(nil, v); ok {
+				e.Reason = ReasonWildcardFailed
+				if node.NodeType == "TEXT" {
+					e.Reason = ReasonTextNotMatched
+				}
+
+				break
+			}
+		}
+	default:
+		filterValues, err := d.ConvertValues(e.Field, node.Args[1:]...)
+		if err != nil {
+			return err
+		}
+
+		e.Reason = ReasonTypeMismatch
+
+		for _, v := range values {
+			for _, fv := range filterValues {
+				if _, ok := compareValue(v, fv); ok {
+					e.Reason = ReasonValueMismatch
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func toInterface(v reflect.Value) any {
+	if v = indirect(v); !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// String renders the explanation as an indented tree, with one node per line.
+func (e *Explanation) String() string {
+	sb := strings.Builder{}
+	e.writeString(&sb, 0)
+	return sb.String()
+}
+
+func (e *Explanation) writeString(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+
+	if e.Matched {
+		sb.WriteString("MATCHED ")
+	} else {
+		sb.WriteString("NOT MATCHED ")
+	}
+
+	sb.WriteString(e.Filter)
+
+	if e.Values != nil {
+		values, err := json.Marshal(e.Values)
+		if err != nil {
+			values = []byte(fmt.Sprintf("%v", e.Values))
+		}
+
+		sb.WriteString(" ")
+		sb.WriteString(e.Field)
+		sb.WriteString("=")
+		sb.Write(values)
+	}
+
+	if e.Reason != "" {
+		sb.WriteString(" (")
+		sb.WriteString(strings.ReplaceAll(string(e.Reason), "_", " "))
+		sb.WriteString(")")
+	}
+
+	sb.WriteString("\n")
+
+	for _, c := range e.Children {
+		c.writeString(sb, depth+1)
+	}
+}
@@ -0,0 +1,215 @@
+package astmem
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+const explainFilter = `{
+	"type": "AND",
+	"children": [
+		{"type": "EQ", "args": ["status", "paid"]},
+		{
+			"type": "OR",
+			"children": [
+				{"type": "GT", "args": ["total", "200"]},
+				{"type": "LIKE", "args": ["items.sku", "COAT*"]}
+			]
+		},
+		{"type": "EQ", "args": ["coupon", "SUMMER"]}
+	]
+}`
+
+func TestExplainAnnotatesEveryNode(t *testing.T) {
+	for name, doc := range getTestDocuments() {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(explainFilter)
+			require.NoError(t, err)
+
+			// Execute SUT
+			explanation, err := Explain(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes}, doc)
+
+			// Verification
+			require.NoError(t, err)
+
+			require.False(t, explanation.Matched)
+			require.Equal(t, ast.AsFilter(), explanation.Filter)
+			require.Equal(t, ReasonChildNotMatched, explanation.Reason)
+			require.Len(t, explanation.Children, 3)
+
+			status := explanation.Children[0]
+			require.True(t, status.Matched)
+			require.Equal(t, `eq("status","paid")`, status.Filter)
+			require.Equal(t, "status", status.Field)
+			require.Equal(t, []any{"paid"}, status.Values)
+			require.Empty(t, status.Reason)
+
+			or := explanation.Children[1]
+			require.False(t, or.Matched)
+			require.Equal(t, ReasonNoChildMatched, or.Reason)
+			require.Empty(t, or.Field)
+
+			total := or.Children[0]
+			require.False(t, total.Matched)
+			require.Len(t, total.Values, 1)
+			require.Equal(t, "150", fmt.Sprint(total.Values[0]))
+			require.Equal(t, ReasonValueMismatch, total.Reason)
+
+			sku := or.Children[1]
+			require.False(t, sku.Matched)
+			require.Equal(t, []any{"SHIRT-RED", "HAT-BLUE"}, sku.Values)
+			require.Equal(t, ReasonWildcardFailed, sku.Reason)
+
+			coupon := explanation.Children[2]
+			require.False(t, coupon.Matched)
+			require.Nil(t, coupon.Values)
+			require.Equal(t, ReasonMissingField, coupon.Reason)
+		})
+	}
+}
+
+func TestExplainReasons(t *testing.T) {
+	doc := map[string]any{
+		"total":       "abc",
+		"tags":        []any{"sale", "gift"},
+		"nested":      map[string]any{"a": 1},
+		"description": "A summer order",
+		"notes":       nil,
+		"empty":       []any{},
+	}
+
+	testCases := []struct {
+		filter   string
+		semantic epsearchast.NullSemantics
+		matched  bool
+		reason   Reason
+	}{
+		{`{"type": "EQ", "args": ["total", "5"]}`, epsearchast.DefaultNullSemantics, false, ReasonTypeMismatch},
+		{`{"type": "GT", "args": ["missing", "5"]}`, epsearchast.DefaultNullSemantics, false, ReasonMissingField},
+		{`{"type": "CONTAINS", "args": ["tags", "new"]}`, epsearchast.DefaultNullSemantics, false, ReasonValueMismatch},
+		{`{"type": "CONTAINS_ALL", "args": ["tags", "sale", "new"]}`, epsearchast.DefaultNullSemantics, false, ReasonValueMismatch},
+		{`{"type": "CONTAINS_ANY", "args": ["tags", "new", "gift"]}`, epsearchast.DefaultNullSemantics, true, ""},
+		{`{"type": "LIKE", "args": ["nested", "a*"]}`, epsearchast.DefaultNullSemantics, false, ReasonTypeMismatch},
+		{`{"type": "ILIKE", "args": ["description", "*winter*"]}`, epsearchast.DefaultNullSemantics, false, ReasonWildcardFailed},
+		{`{"type": "TEXT", "args": ["description", "winter order"]}`, epsearchast.DefaultNullSemantics, false, ReasonTextNotMatched},
+		{`{"type": "TEXT", "args": ["*", "winter"]}`, epsearchast.DefaultNullSemantics, false, ReasonTextNotMatched},
+		{`{"type": "TEXT", "args": ["*", "summer"]}`, epsearchast.DefaultNullSemantics, true, ""},
+		{`{"type": "IS_NULL", "args": ["notes"]}`, epsearchast.DefaultNullSemantics, true, ReasonNullOrMissing},
+		{`{"type": "IS_NULL", "args": ["missing"]}`, epsearchast.DefaultNullSemantics, true, ReasonNullOrMissing},
+		{`{"type": "IS_NULL", "args": ["description"]}`, epsearchast.DefaultNullSemantics, false, ReasonNotNull},
+		{`{"type": "IS_NULL", "args": ["notes"]}`, epsearchast.MissingOnly, false, ReasonExcludedByNullSemantics},
+		{`{"type": "IS_NULL", "args": ["empty"]}`, epsearchast.DefaultNullSemantics, false, ReasonExcludedByNullSemantics},
+		{`{"type": "IS_NULL", "args": ["empty"]}`, epsearchast.NullOrMissingOrEmptyArray, true, ReasonNullOrMissing},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter+" "+tc.semantic.String(), func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			qb := DefaultMemQueryBuilder{
+				FieldTypes:    map[string]epsearchast.FieldType{"total": epsearchast.Int64},
+				NullSemantics: epsearchast.NullSemanticsConfig{Default: tc.semantic},
+			}
+
+			// Execute SUT
+			explanation, err := Explain(ast, qb, doc)
+
+			// Verification
+			require.NoError(t, err)
+			require.Equal(t, tc.matched, explanation.Matched)
+			require.Equal(t, tc.reason, explanation.Reason)
+		})
+	}
+}
+
+func TestExplanationRendersAsText(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(explainFilter)
+	require.NoError(t, err)
+
+	explanation, err := Explain(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes}, getTestDocuments()["map"])
+	require.NoError(t, err)
+
+	expected := `NOT MATCHED eq("status","paid"):(gt("total","200")|like("items.sku","COAT*")):eq("coupon","SUMMER") (child not matched)
+  MATCHED eq("status","paid") status=["paid"]
+  NOT MATCHED gt("total","200")|like("items.sku","COAT*") (no child matched)
+    NOT MATCHED gt("total","200") total=[150] (value mismatch)
+    NOT MATCHED like("items.sku","COAT*") items.sku=["SHIRT-RED","HAT-BLUE"] (wildcard failed)
+  NOT MATCHED eq("coupon","SUMMER") (missing field)
+`
+
+	// Execute SUT
+	text := explanation.String()
+
+	// Verification
+	require.Equal(t, expected, text)
+}
+
+func TestExplanationRendersAsJson(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(explainFilter)
+	require.NoError(t, err)
+
+	explanation, err := Explain(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes}, getTestDocuments()["json"])
+	require.NoError(t, err)
+
+	//language=JSON
+	expected := `{
+	"filter": "eq(\"status\",\"paid\"):(gt(\"total\",\"200\")|like(\"items.sku\",\"COAT*\")):eq(\"coupon\",\"SUMMER\")",
+	"matched": false,
+	"reason": "child_not_matched",
+	"children": [
+		{"filter": "eq(\"status\",\"paid\")", "matched": true, "field": "status", "values": ["paid"]},
+		{
+			"filter": "gt(\"total\",\"200\")|like(\"items.sku\",\"COAT*\")",
+			"matched": false,
+			"reason": "no_child_matched",
+			"children": [
+				{"filter": "gt(\"total\",\"200\")", "matched": false, "field": "total", "values": [150], "reason": "value_mismatch"},
+				{"filter": "like(\"items.sku\",\"COAT*\")", "matched": false, "field": "items.sku", "values": ["SHIRT-RED", "HAT-BLUE"], "reason": "wildcard_failed"}
+			]
+		},
+		{"filter": "eq(\"coupon\",\"SUMMER\")", "matched": false, "field": "coupon", "reason": "missing_field"}
+	]
+}`
+
+	// Execute SUT
+	explanationJson, err := json.Marshal(explanation)
+
+	// Verification
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(explanationJson))
+}
+
+func TestExplainReturnsErrors(t *testing.T) {
+	t.Run("invalid value", func(t *testing.T) {
+		// Fixture Setup
+		ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["total", "abc"]}`)
+		require.NoError(t, err)
+
+		// Execute SUT
+		_, err = Explain(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes}, map[string]any{})
+
+		// Verification
+		require.ErrorContains(t, err, "invalid value for int64")
+	})
+
+	t.Run("unsupported document", func(t *testing.T) {
+		// Fixture Setup
+		ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+		require.NoError(t, err)
+
+		// Execute SUT
+		_, err = Explain(ast, DefaultMemQueryBuilder{}, 42)
+
+		// Verification
+		require.ErrorContains(t, err, "unsupported document type int")
+	})
+}
@@ -0,0 +1,122 @@
+package astmem
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+)
+
+// Option configures the DefaultMemQueryBuilder used by FilterSlice and FilterSeq.
+type Option func(*DefaultMemQueryBuilder)
+
+// WithFieldTypes sets the types of fields, see DefaultMemQueryBuilder.FieldTypes.
+func WithFieldTypes(fieldTypes map[string]epsearchast.FieldType) Option {
+	return func(d *DefaultMemQueryBuilder) {
+		d.FieldTypes = fieldTypes
+	}
+}
+
+// WithNullSemantics sets what is_null matches, see DefaultMemQueryBuilder.NullSemantics.
+func WithNullSemantics(nullSemantics epsearchast.NullSemanticsConfig) Option {
+	return func(d *DefaultMemQueryBuilder) {
+		d.NullSemantics = nullSemantics
+	}
+}
+
+// WithTagName sets the struct tag used to find the name of struct fields, see DefaultMemQueryBuilder.TagName.
+func WithTagName(tagName string) Option {
+	return func(d *DefaultMemQueryBuilder) {
+		d.TagName = tagName
+	}
+}
+
+// FilterSlice returns the items that match the AST, in their original order.
+//
+// The AST is compiled once, and struct fields are looked up with an index that is computed once per type, so filtering a slice of structs does not allocate
+// except to build the result (text() on a field that isn't a string is the exception).
+func FilterSlice[T any](items []T, ast *epsearchast.AstNode, opts ...Option) ([]T, error) {
+	predicate, err := compileFor[T](ast, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []T
+
+	for i := range items {
+		// A pointer to the item avoids copying it to the heap when it's converted to an interface.
+		ok, err := predicate(&items[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not evaluate item at index %d: %w", i, err)
+		}
+
+		if ok {
+			matches = append(matches, items[i])
+		}
+	}
+
+	return matches, nil
+}
+
+// FilterSeq returns a sequence of the items that match the AST, which is evaluated lazily as the sequence is iterated.
+//
+// Errors in the AST, or an unsupported item type, are returned immediately. If an item can't be evaluated (e.g., an invalid JSON document), the item is
+// yielded with the error and the sequence stops.
+func FilterSeq[T any](items iter.Seq[T], ast *epsearchast.AstNode, opts ...Option) (iter.Seq2[T, error], error) {
+	predicate, err := compileFor[T](ast, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(T, error) bool) {
+		// This is reused for every item, so that only one copy escapes to the heap.
+		var item T
+
+		i := 0
+		for item = range items {
+			ok, err := predicate(&item)
+			if err != nil {
+				yield(item, fmt.Errorf("could not evaluate item at index %d: %w", i, err))
+				return
+			}
+
+			if ok && !yield(item, nil) {
+				return
+			}
+
+			i++
+		}
+	}, nil
+}
+
+func compileFor[T any](ast *epsearchast.AstNode, opts ...Option) (Predicate, error) {
+	if t := reflect.TypeFor[T](); !isSupportedType(t) {
+		return nil, fmt.Errorf("unsupported item type %s", t)
+	}
+
+	qb := DefaultMemQueryBuilder{}
+	for _, opt := range opts {
+		opt(&qb)
+	}
+
+	return Compile(ast, qb)
+}
+
+func isSupportedType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct, t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		return true
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return true
+	case t.Kind() == reflect.Interface:
+		// The type of each item is checked when it's evaluated.
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,293 @@
+package astmem
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestOrders() []testOrder {
+	return []testOrder{
+		{Status: "paid", Total: 150, Tags: []string{"sale"}, Items: []testItem{{Sku: "SHIRT-RED", Quantity: 2}}},
+		{Status: "pending", Total: 20, Tags: []string{"gift"}},
+		{Status: "paid", Total: 75, Items: []testItem{{Sku: "HAT-BLUE", Quantity: 1}}},
+		{Status: "cancelled", Total: 300, Tags: []string{"sale", "gift"}},
+	}
+}
+
+func TestFilterSliceReturnsMatchingItemsInOrder(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "OR", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "GT", "args": ["total", "250"]}]}`)
+	require.NoError(t, err)
+
+	orders := getTestOrders()
+
+	// Execute SUT
+	matches, err := FilterSlice(orders, ast, WithFieldTypes(fieldTypes))
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, []testOrder{orders[0], orders[2], orders[3]}, matches)
+}
+
+func TestFilterSliceSupportsItemTypes(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "CONTAINS", "args": ["tags", "gift"]}`)
+	require.NoError(t, err)
+
+	orders := getTestOrders()
+
+	var pointers []*testOrder
+	var mapDocs []map[string]any
+	var jsonDocs []json.RawMessage
+
+	for i := range orders {
+		pointers = append(pointers, &orders[i])
+
+		jsonDoc, err := json.Marshal(orders[i])
+		require.NoError(t, err)
+		jsonDocs = append(jsonDocs, jsonDoc)
+
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(jsonDoc, &m))
+		mapDocs = append(mapDocs, m)
+	}
+
+	// Execute SUT
+	pointerMatches, pointerErr := FilterSlice(pointers, ast)
+	mapMatches, mapErr := FilterSlice(mapDocs, ast)
+	jsonMatches, jsonErr := FilterSlice(jsonDocs, ast)
+
+	// Verification
+	require.NoError(t, pointerErr)
+	require.Equal(t, []*testOrder{pointers[1], pointers[3]}, pointerMatches)
+
+	require.NoError(t, mapErr)
+	require.Equal(t, []map[string]any{mapDocs[1], mapDocs[3]}, mapMatches)
+
+	require.NoError(t, jsonErr)
+	require.Equal(t, []json.RawMessage{jsonDocs[1], jsonDocs[3]}, jsonMatches)
+}
+
+func TestFilterSliceUsesOptions(t *testing.T) {
+	// Fixture Setup
+	type record struct {
+		Status string   `db:"state"`
+		Tags   []string `db:"tags"`
+	}
+
+	ast, err := epsearchast.GetAst(`{"type": "AND", "children": [{"type": "EQ", "args": ["state", "live"]}, {"type": "IS_NULL", "args": ["tags"]}]}`)
+	require.NoError(t, err)
+
+	records := []record{
+		{Status: "live"},
+		{Status: "live", Tags: []string{}},
+		{Status: "draft"},
+	}
+
+	// Execute SUT
+	matches, err := FilterSlice(records, ast,
+		WithTagName("db"),
+		WithNullSemantics(epsearchast.NullSemanticsConfig{Default: epsearchast.NullOrMissingOrEmptyArray}),
+	)
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, []record{records[0], records[1]}, matches)
+}
+
+func TestFilterSliceReturnsErrors(t *testing.T) {
+	t.Run("invalid value", func(t *testing.T) {
+		// Fixture Setup
+		ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["total", "abc"]}`)
+		require.NoError(t, err)
+
+		// Execute SUT
+		_, err = FilterSlice(getTestOrders(), ast, WithFieldTypes(fieldTypes))
+
+		// Verification
+		require.ErrorContains(t, err, "invalid value for int64")
+	})
+
+	t.Run("unsupported item type", func(t *testing.T) {
+		// Fixture Setup
+		ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+		require.NoError(t, err)
+
+		// Execute SUT
+		_, err = FilterSlice([]int{1, 2, 3}, ast)
+
+		// Verification
+		require.ErrorContains(t, err, "unsupported item type int")
+	})
+
+	t.Run("invalid item", func(t *testing.T) {
+		// Fixture Setup
+		ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+		require.NoError(t, err)
+
+		// Execute SUT
+		_, err = FilterSlice([][]byte{[]byte(`{"status": "paid"}`), []byte(`{"status":`)}, ast)
+
+		// Verification
+		require.ErrorContains(t, err, "could not evaluate item at index 1")
+	})
+}
+
+func TestFilterSeqReturnsMatchingItemsLazily(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+	require.NoError(t, err)
+
+	orders := getTestOrders()
+
+	var visited int
+	items := func(yield func(testOrder) bool) {
+		for _, o := range orders {
+			visited++
+			if !yield(o) {
+				return
+			}
+		}
+	}
+
+	// Execute SUT
+	seq, err := FilterSeq(items, ast)
+	require.NoError(t, err)
+
+	var first testOrder
+	for o, err := range seq {
+		require.NoError(t, err)
+		first = o
+		break
+	}
+
+	// Verification
+	require.Equal(t, orders[0], first)
+	require.Equal(t, 1, visited)
+
+	var matches []testOrder
+	for o, err := range seq {
+		require.NoError(t, err)
+		matches = append(matches, o)
+	}
+
+	require.Equal(t, []testOrder{orders[0], orders[2]}, matches)
+}
+
+func TestFilterSeqStopsWithErrorForItemsThatCannotBeEvaluated(t *testing.T) {
+	testCases := []struct {
+		name          string
+		items         []any
+		expectedError string
+	}{
+		{
+			name:          "invalid document",
+			items:         []any{json.RawMessage(`{"total": 150}`), json.RawMessage(`{"total":`), json.RawMessage(`{"total": 200}`)},
+			expectedError: "could not evaluate item at index 1: could not decode JSON document",
+		},
+		{
+			name:          "item that can't be converted to a document",
+			items:         []any{map[string]any{"total": 150}, 42, map[string]any{"total": 200}},
+			expectedError: "could not evaluate item at index 1: unsupported document type int",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(`{"type": "GT", "args": ["total", "100"]}`)
+			require.NoError(t, err)
+
+			// Execute SUT
+			seq, err := FilterSeq(slices.Values(tc.items), ast, WithFieldTypes(fieldTypes))
+			require.NoError(t, err)
+
+			var matches []any
+			var errs []error
+			for item, err := range seq {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+
+				matches = append(matches, item)
+			}
+
+			// Verification
+			require.Equal(t, tc.items[:1], matches)
+			require.Len(t, errs, 1)
+			require.ErrorContains(t, errs[0], tc.expectedError)
+		})
+	}
+}
+
+func TestFilterSeqReturnsErrorForUnsupportedItemType(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+	require.NoError(t, err)
+
+	// Execute SUT
+	_, err = FilterSeq(slices.Values([]string{"paid"}), ast)
+
+	// Verification
+	require.ErrorContains(t, err, "unsupported item type string")
+}
+
+func TestPredicateDoesNotAllocateForStructs(t *testing.T) {
+	testCases := []string{
+		`{"type": "EQ", "args": ["status", "paid"]}`,
+		`{"type": "EQ", "args": ["total", "150"]}`,
+		`{"type": "IN", "args": ["status", "pending", "paid"]}`,
+		`{"type": "GE", "args": ["created_at", "2024-06-01"]}`,
+		`{"type": "LT", "args": ["items.price", "50"]}`,
+		`{"type": "EQ", "args": ["items.1.sku", "HAT-BLUE"]}`,
+		`{"type": "LIKE", "args": ["items.sku", "SHIRT*"]}`,
+		`{"type": "ILIKE", "args": ["address.city", "*couver"]}`,
+		`{"type": "CONTAINS", "args": ["tags", "gift"]}`,
+		`{"type": "CONTAINS_ANY", "args": ["tags", "new", "sale"]}`,
+		`{"type": "CONTAINS_ALL", "args": ["items.quantity", "1", "2"]}`,
+		`{"type": "TEXT", "args": ["address.city", "vanc"]}`,
+		`{"type": "IS_NULL", "args": ["notes"]}`,
+		`{"type": "AND", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "GT", "args": ["total", "100"]}]}`,
+		`{"type": "OR", "children": [{"type": "EQ", "args": ["status", "pending"]}, {"type": "GT", "args": ["total", "100"]}]}`,
+	}
+
+	order := testOrder{
+		testAudit: testAudit{CreatedAt: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)},
+		Status:    "paid",
+		Total:     150,
+		Tags:      []string{"sale", "gift"},
+		Address:   testAddress{City: "Vancouver"},
+		Items: []testItem{
+			{Sku: "SHIRT-RED", Quantity: 2, Price: 25.5},
+			{Sku: "HAT-BLUE", Quantity: 1, Price: 99},
+		},
+	}
+
+	for _, filter := range testCases {
+		t.Run(filter, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(filter)
+			require.NoError(t, err)
+
+			predicate, err := Compile(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes})
+			require.NoError(t, err)
+
+			// Execute SUT
+			var matches bool
+			allocs := testing.AllocsPerRun(100, func() {
+				matches, err = predicate(&order)
+			})
+
+			// Verification
+			require.NoError(t, err)
+			require.True(t, matches)
+			require.Zero(t, allocs)
+		})
+	}
+}
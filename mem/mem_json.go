@@ -0,0 +1,374 @@
+package astmem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// JSON documents are evaluated by scanning the bytes for the fields in the filter, rather than decoding the document into a map[string]any.
+// Only the values of those fields are decoded, and scanning stops as soon as the result is known, so parts of an invalid document may not be checked.
+// If an object has duplicate keys, the first one is used.
+
+var errUnexpectedEnd = errors.New("unexpected end of JSON input")
+
+// getJson returns the document if it is JSON (e.g., a []byte, json.RawMessage or a pointer to one).
+func getJson(doc any) ([]byte, bool) {
+	v := indirect(reflect.ValueOf(doc))
+
+	if !isBytes(v) {
+		return nil, false
+	}
+
+	return v.Bytes(), true
+}
+
+func getJsonRoot(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("could not decode JSON document: %w", errUnexpectedEnd)
+	}
+
+	if data[0] != '{' {
+		return nil, fmt.Errorf("could not decode JSON document: expected an object but found %q", data[0])
+	}
+
+	return data, nil
+}
+
+// visitJsonValues is visitValues for a JSON document, data must start with a value. If elements is set, f is called with the elements of arrays at the path
+// (like visitElements), which avoids decoding them.
+func visitJsonValues(data []byte, p fieldPath, depth int, elements bool, f func(v reflect.Value) bool) (bool, error) {
+	if depth == len(p.parts) {
+		if elements {
+			return visitJsonElements(data, f)
+		}
+
+		v, err := decodeJsonValue(data)
+		if err != nil {
+			return false, err
+		}
+
+		return f(v), nil
+	}
+
+	var found bool
+	var err error
+
+	switch data[0] {
+	case '{':
+		_, scanErr := forEachJsonMember(data, func(key []byte, value []byte) bool {
+			if !jsonKeyEquals(key, p.parts[depth]) {
+				return false
+			}
+
+			found, err = visitJsonValues(value, p, depth+1, elements, f)
+			return true
+		})
+
+		if scanErr != nil {
+			return false, scanErr
+		}
+	case '[':
+		idx, i := p.indexes[depth], 0
+
+		_, scanErr := forEachJsonElement(data, func(value []byte) bool {
+			switch {
+			case idx < 0:
+				found, err = visitJsonValues(value, p, depth, elements, f)
+				return found || err != nil
+			case i == idx:
+				found, err = visitJsonValues(value, p, depth+1, elements, f)
+				return true
+			}
+
+			i++
+			return false
+		})
+
+		if scanErr != nil {
+			return false, scanErr
+		}
+	default:
+		// The path goes through a scalar, so the field is missing, but the value still needs to be valid.
+		if _, err := skipJsonValue(data, 0); err != nil {
+			return false, err
+		}
+	}
+
+	return found, err
+}
+
+// visitJsonElements is visitElements for a JSON document, data must start with a value.
+func visitJsonElements(data []byte, f func(v reflect.Value) bool) (bool, error) {
+	if data[0] != '[' {
+		v, err := decodeJsonValue(data)
+		if err != nil {
+			return false, err
+		}
+
+		return f(v), nil
+	}
+
+	var found bool
+	var err error
+
+	_, scanErr := forEachJsonElement(data, func(value []byte) bool {
+		found, err = visitJsonElements(value, f)
+		return found || err != nil
+	})
+
+	if scanErr != nil {
+		return false, scanErr
+	}
+
+	return found, err
+}
+
+// visitJsonStrings is visitAllStrings for a JSON document, data must start with a value.
+func visitJsonStrings(data []byte, f func(v reflect.Value) bool) (bool, error) {
+	var found bool
+	var err error
+
+	switch data[0] {
+	case '"':
+		v, decodeErr := decodeJsonValue(data)
+		if decodeErr != nil {
+			return false, decodeErr
+		}
+
+		return f(v), nil
+	case '{':
+		_, scanErr := forEachJsonMember(data, func(_ []byte, value []byte) bool {
+			found, err = visitJsonStrings(value, f)
+			return found || err != nil
+		})
+
+		if scanErr != nil {
+			return false, scanErr
+		}
+	case '[':
+		_, scanErr := forEachJsonElement(data, func(value []byte) bool {
+			found, err = visitJsonStrings(value, f)
+			return found || err != nil
+		})
+
+		if scanErr != nil {
+			return false, scanErr
+		}
+	default:
+		if _, err := skipJsonValue(data, 0); err != nil {
+			return false, err
+		}
+	}
+
+	return found, err
+}
+
+// decodeJsonValue decodes the value at the start of data, scalars are decoded directly, and objects and arrays with encoding/json.
+// Numbers are decoded as json.Number like the rest of the package.
+func decodeJsonValue(data []byte) (reflect.Value, error) {
+	end, err := skipJsonValue(data, 0)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	data = data[:end]
+
+	switch data[0] {
+	case 'n':
+		return reflect.Value{}, nil
+	case 't':
+		return reflect.ValueOf(true), nil
+	case 'f':
+		return reflect.ValueOf(false), nil
+	case '"':
+		if bytes.IndexByte(data, '\\') < 0 {
+			return reflect.ValueOf(string(data[1 : len(data)-1])), nil
+		}
+	case '[', '{':
+	default:
+		return reflect.ValueOf(json.Number(data)), nil
+	}
+
+	var v any
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return reflect.Value{}, fmt.Errorf("could not decode JSON document: %w", err)
+	}
+
+	return reflect.ValueOf(v), nil
+}
+
+// forEachJsonMember calls f (if it's not nil) with each key (including the quotes) and value in the object at the start of data, until f returns true.
+// It returns the index after the object, or -1 if f returned true.
+func forEachJsonMember(data []byte, f func(key []byte, value []byte) bool) (int, error) {
+	i := skipJsonWhitespace(data, 1)
+
+	if i < len(data) && data[i] == '}' {
+		return i + 1, nil
+	}
+
+	for {
+		if i >= len(data) || data[i] != '"' {
+			return 0, jsonSyntaxError(data, i, "object key")
+		}
+
+		keyEnd, err := skipJsonString(data, i)
+		if err != nil {
+			return 0, err
+		}
+
+		key := data[i:keyEnd]
+
+		i = skipJsonWhitespace(data, keyEnd)
+		if i >= len(data) || data[i] != ':' {
+			return 0, jsonSyntaxError(data, i, "':' after object key")
+		}
+
+		i = skipJsonWhitespace(data, i+1)
+
+		valueEnd, err := skipJsonValue(data, i)
+		if err != nil {
+			return 0, err
+		}
+
+		if f != nil && f(key, data[i:valueEnd]) {
+			return -1, nil
+		}
+
+		i = skipJsonWhitespace(data, valueEnd)
+
+		switch {
+		case i < len(data) && data[i] == ',':
+			i = skipJsonWhitespace(data, i+1)
+		case i < len(data) && data[i] == '}':
+			return i + 1, nil
+		default:
+			return 0, jsonSyntaxError(data, i, "',' or '}' after object value")
+		}
+	}
+}
+
+// forEachJsonElement calls f (if it's not nil) with each value in the array at the start of data, until f returns true.
+// It returns the index after the array, or -1 if f returned true.
+func forEachJsonElement(data []byte, f func(value []byte) bool) (int, error) {
+	i := skipJsonWhitespace(data, 1)
+
+	if i < len(data) && data[i] == ']' {
+		return i + 1, nil
+	}
+
+	for {
+		valueEnd, err := skipJsonValue(data, i)
+		if err != nil {
+			return 0, err
+		}
+
+		if f != nil && f(data[i:valueEnd]) {
+			return -1, nil
+		}
+
+		i = skipJsonWhitespace(data, valueEnd)
+
+		switch {
+		case i < len(data) && data[i] == ',':
+			i = skipJsonWhitespace(data, i+1)
+		case i < len(data) && data[i] == ']':
+			return i + 1, nil
+		default:
+			return 0, jsonSyntaxError(data, i, "',' or ']' after array element")
+		}
+	}
+}
+
+// skipJsonValue returns the index after the value that starts at i.
+func skipJsonValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, fmt.Errorf("could not decode JSON document: %w", errUnexpectedEnd)
+	}
+
+	switch c := data[i]; {
+	case c == '"':
+		return skipJsonString(data, i)
+	case c == '{':
+		end, err := forEachJsonMember(data[i:], nil)
+		return i + end, err
+	case c == '[':
+		end, err := forEachJsonElement(data[i:], nil)
+		return i + end, err
+	case c == 't':
+		return skipJsonLiteral(data, i, "true")
+	case c == 'f':
+		return skipJsonLiteral(data, i, "false")
+	case c == 'n':
+		return skipJsonLiteral(data, i, "null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		j := i + 1
+		for j < len(data) && (data[j] >= '0' && data[j] <= '9' || data[j] == '.' || data[j] == 'e' || data[j] == 'E' || data[j] == '+' || data[j] == '-') {
+			j++
+		}
+
+		return j, nil
+	}
+
+	return 0, jsonSyntaxError(data, i, "value")
+}
+
+// skipJsonString returns the index after the string that starts at i.
+func skipJsonString(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not decode JSON document: %w", errUnexpectedEnd)
+}
+
+func skipJsonLiteral(data []byte, i int, literal string) (int, error) {
+	if !bytes.HasPrefix(data[i:], []byte(literal)) {
+		return 0, jsonSyntaxError(data, i, literal)
+	}
+
+	return i + len(literal), nil
+}
+
+func skipJsonWhitespace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+
+	return i
+}
+
+func jsonSyntaxError(data []byte, i int, expected string) error {
+	if i >= len(data) {
+		return fmt.Errorf("could not decode JSON document: %w", errUnexpectedEnd)
+	}
+
+	return fmt.Errorf("could not decode JSON document: expected %s at offset %d but found %q", expected, i, data[i])
+}
+
+// jsonKeyEquals returns whether the key (including the quotes) is equal to name.
+func jsonKeyEquals(key []byte, name string) bool {
+	if bytes.IndexByte(key, '\\') < 0 {
+		return string(key[1:len(key)-1]) == name
+	}
+
+	var s string
+	if err := json.Unmarshal(key, &s); err != nil {
+		return false
+	}
+
+	return s == name
+}
@@ -0,0 +1,202 @@
+package astmem
+
+import (
+	"encoding/json"
+	"testing"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventJson = `{
+  "type" : "order.updated",
+  "id": "9d3c4f6e",
+  "data": {
+    "status": "paid",
+    "total": 1.5e2,
+    "paid": true,
+    "notes": null,
+    "tags": ["sale", "gift"],
+    "empty": [],
+    "nothing": {},
+    "description": "A \"lovely\" summer order\nfor the cottage",
+    "café": "open",
+    "items": [
+      {"sku": "SHIRT-RED", "quantity": 2, "price": 25.5, "options": [["red", "large"]]},
+      {"sku": "HAT-BLUE", "quantity": 1, "price": -99, "options": []}
+    ]
+  },
+  "meta": {"version": 3, "nested": {"deep": [1, [2, {"x": "y"}]]}}
+}`
+
+func TestPredicateOnJsonMatchesUnmarshalledJson(t *testing.T) {
+	testCases := []string{
+		`{"type": "EQ", "args": ["type", "order.updated"]}`,
+		`{"type": "EQ", "args": ["data.status", "paid"]}`,
+		`{"type": "EQ", "args": ["data.total", "150"]}`,
+		`{"type": "GT", "args": ["data.total", "149.5"]}`,
+		`{"type": "EQ", "args": ["data.paid", "true"]}`,
+		`{"type": "EQ", "args": ["data.description", "A \"lovely\" summer order\nfor the cottage"]}`,
+		`{"type": "EQ", "args": ["data.café", "open"]}`,
+		`{"type": "EQ", "args": ["data.missing", "foo"]}`,
+		`{"type": "EQ", "args": ["data.status.deeper", "foo"]}`,
+		`{"type": "EQ", "args": ["data.items.sku", "HAT-BLUE"]}`,
+		`{"type": "EQ", "args": ["data.items.1.sku", "HAT-BLUE"]}`,
+		`{"type": "EQ", "args": ["data.items.0.sku", "HAT-BLUE"]}`,
+		`{"type": "EQ", "args": ["data.items.5.sku", "HAT-BLUE"]}`,
+		`{"type": "LT", "args": ["data.items.price", "0"]}`,
+		`{"type": "IN", "args": ["data.status", "pending", "paid"]}`,
+		`{"type": "LIKE", "args": ["data.items.sku", "SHIRT*"]}`,
+		`{"type": "ILIKE", "args": ["data.description", "*COTTAGE"]}`,
+		`{"type": "CONTAINS", "args": ["data.tags", "gift"]}`,
+		`{"type": "CONTAINS", "args": ["data.items.options", "large"]}`,
+		`{"type": "CONTAINS_ANY", "args": ["data.tags", "new", "sale"]}`,
+		`{"type": "CONTAINS_ALL", "args": ["data.tags", "sale", "gift"]}`,
+		`{"type": "CONTAINS_ALL", "args": ["data.items.quantity", "1", "2"]}`,
+		`{"type": "CONTAINS_ALL", "args": ["data.items.quantity", "1", "3"]}`,
+		`{"type": "CONTAINS", "args": ["meta.nested.deep", "2"]}`,
+		`{"type": "EQ", "args": ["meta.nested.deep.x", "y"]}`,
+		`{"type": "TEXT", "args": ["data.description", "lovely cott"]}`,
+		`{"type": "TEXT", "args": ["*", "summer shirt"]}`,
+		`{"type": "TEXT", "args": ["*", "winter"]}`,
+		`{"type": "IS_NULL", "args": ["data.notes"]}`,
+		`{"type": "IS_NULL", "args": ["data.empty"]}`,
+		`{"type": "IS_NULL", "args": ["data.nothing"]}`,
+		`{"type": "IS_NULL", "args": ["data.missing"]}`,
+		`{"type": "IS_NULL", "args": ["data.items.options"]}`,
+		`{"type": "AND", "children": [{"type": "EQ", "args": ["data.status", "paid"]}, {"type": "EQ", "args": ["meta.version", "3"]}]}`,
+		`{"type": "OR", "children": [{"type": "EQ", "args": ["data.status", "pending"]}, {"type": "EQ", "args": ["meta.version", "4"]}]}`,
+	}
+
+	var unmarshalled map[string]any
+	require.NoError(t, json.Unmarshal([]byte(testEventJson), &unmarshalled))
+
+	qb := DefaultMemQueryBuilder{
+		FieldTypes: map[string]epsearchast.FieldType{
+			"data.total":          epsearchast.Float64,
+			"data.paid":           epsearchast.Boolean,
+			"data.items.price":    epsearchast.Float64,
+			"data.items.quantity": epsearchast.Int64,
+			"meta.version":        epsearchast.Int64,
+			"meta.nested.deep":    epsearchast.Int64,
+		},
+		NullSemantics: epsearchast.NullSemanticsConfig{Default: epsearchast.NullOrMissingOrEmptyArray},
+	}
+
+	for _, filter := range testCases {
+		t.Run(filter, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(filter)
+			require.NoError(t, err)
+
+			predicate, err := Compile(ast, qb)
+			require.NoError(t, err)
+
+			expected, err := predicate(unmarshalled)
+			require.NoError(t, err)
+
+			// Execute SUT
+			matches, err := predicate([]byte(testEventJson))
+
+			// Verification
+			require.NoError(t, err)
+			require.Equal(t, expected, matches)
+		})
+	}
+}
+
+func TestPredicateOnJsonReturnsErrorForInvalidJson(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                ``,
+		"array":                `["status"]`,
+		"string":               `"status"`,
+		"truncated":            `{"status": "pa`,
+		"truncated object":     `{"other": "paid"`,
+		"missing colon":        `{"status" "paid"}`,
+		"missing comma":        `{"other": 1 "status": "paid"}`,
+		"invalid literal":      `{"other": nul, "status": "paid"}`,
+		"invalid value":        `{"other": ?, "status": "paid"}`,
+		"unterminated array":   `{"other": [1, 2, "status": "paid"}`,
+		"invalid nested value": `{"other": {"a": [tru]}, "status": "paid"}`,
+	}
+
+	for name, doc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+			require.NoError(t, err)
+
+			predicate, err := Compile(ast, DefaultMemQueryBuilder{})
+			require.NoError(t, err)
+
+			// Execute SUT
+			_, err = predicate([]byte(doc))
+
+			// Verification
+			require.ErrorContains(t, err, "could not decode JSON document")
+		})
+	}
+}
+
+func TestPredicateOnJsonStopsScanningOnceFieldIsFound(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+	require.NoError(t, err)
+
+	predicate, err := Compile(ast, DefaultMemQueryBuilder{})
+	require.NoError(t, err)
+
+	// Execute SUT
+	matches, err := predicate([]byte(`{"status": "paid", "rest": [this is not scanned`))
+
+	// Verification
+	require.NoError(t, err)
+	require.True(t, matches)
+}
+
+func BenchmarkPredicateOnJson(b *testing.B) {
+	predicate := getBenchmarkPredicate(b)
+	data := []byte(testEventJson)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if ok, err := predicate(data); err != nil || !ok {
+			b.Fatal(ok, err)
+		}
+	}
+}
+
+func BenchmarkPredicateOnUnmarshalledJson(b *testing.B) {
+	predicate := getBenchmarkPredicate(b)
+	data := []byte(testEventJson)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		var m map[string]any
+		if err := json.Unmarshal(data, &m); err != nil {
+			b.Fatal(err)
+		}
+
+		if ok, err := predicate(m); err != nil || !ok {
+			b.Fatal(ok, err)
+		}
+	}
+}
+
+func getBenchmarkPredicate(b *testing.B) Predicate {
+	ast, err := epsearchast.GetAst(`{"type": "AND", "children": [{"type": "EQ", "args": ["type", "order.updated"]}, {"type": "CONTAINS", "args": ["data.tags", "gift"]}, {"type": "GE", "args": ["data.items.quantity", "2"]}]}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	predicate, err := Compile(ast, DefaultMemQueryBuilder{
+		FieldTypes: map[string]epsearchast.FieldType{"data.items.quantity": epsearchast.Int64},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return predicate
+}
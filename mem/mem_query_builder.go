@@ -0,0 +1,832 @@
+package astmem
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+)
+
+// Predicate returns whether a document matches the filter. Documents can be a map[string]any (or any map with string keys), a struct (or pointer to one),
+// or a JSON document as a []byte or json.RawMessage.
+type Predicate func(doc any) (bool, error)
+
+// DefaultMemQueryBuilder generates a Predicate that evaluates the filter against Go values in memory, which lets you check whether a record matches a filter without a database.
+//
+// Fields are dot separated paths into the document (e.g., contact.email), if the path contains an array, the rest of the path is applied to each element.
+// Like Elasticsearch and Mongo, operators other than is_null match an array if any element matches.
+type DefaultMemQueryBuilder struct {
+	// FieldTypes controls how values are validated and converted (with epsearchast.Convert), and how they are compared to the document.
+	// Fields that are not in this map are compared as strings (e.g., a range on a number without a type is a lexicographic comparison).
+	FieldTypes map[string]epsearchast.FieldType
+
+	// NullSemantics controls what is_null matches, by default both explicit nulls and missing fields are matched.
+	NullSemantics epsearchast.NullSemanticsConfig
+
+	// TagName is the struct tag used to find the name of a struct field, if empty json is used. Fields without the tag use the name of the field.
+	TagName string
+}
+
+var _ epsearchast.SemanticReducer[Predicate] = (*DefaultMemQueryBuilder)(nil)
+
+// Compile converts the AST into a Predicate.
+func Compile(a *epsearchast.AstNode, qb epsearchast.SemanticReducer[Predicate]) (Predicate, error) {
+	p, err := epsearchast.SemanticReduceAst(a, qb)
+	if err != nil {
+		return nil, err
+	}
+
+	return *p, nil
+}
+
+func (d DefaultMemQueryBuilder) PostVisitAnd(rs []*Predicate) (*Predicate, error) {
+	return newPredicate(func(doc any) (bool, error) {
+		for _, r := range rs {
+			if ok, err := (*r)(doc); err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) PostVisitOr(rs []*Predicate) (*Predicate, error) {
+	return newPredicate(func(doc any) (bool, error) {
+		for _, r := range rs {
+			if ok, err := (*r)(doc); err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) VisitIn(args ...string) (*Predicate, error) {
+	values, err := d.ConvertValues(args[0], args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.anyElementMatches(args[0], func(v reflect.Value) bool {
+		return equalsAny(v, values)
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) VisitEq(first, second string) (*Predicate, error) {
+	return d.buildComparisonPredicate(first, second, func(c int) bool { return c == 0 })
+}
+
+func (d DefaultMemQueryBuilder) VisitLe(first, second string) (*Predicate, error) {
+	return d.buildComparisonPredicate(first, second, func(c int) bool { return c <= 0 })
+}
+
+func (d DefaultMemQueryBuilder) VisitLt(first, second string) (*Predicate, error) {
+	return d.buildComparisonPredicate(first, second, func(c int) bool { return c < 0 })
+}
+
+func (d DefaultMemQueryBuilder) VisitGe(first, second string) (*Predicate, error) {
+	return d.buildComparisonPredicate(first, second, func(c int) bool { return c >= 0 })
+}
+
+func (d DefaultMemQueryBuilder) VisitGt(first, second string) (*Predicate, error) {
+	return d.buildComparisonPredicate(first, second, func(c int) bool { return c > 0 })
+}
+
+func (d DefaultMemQueryBuilder) buildComparisonPredicate(first, second string, f func(c int) bool) (*Predicate, error) {
+	value, err := d.ConvertValue(first, second)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.anyElementMatches(first, func(v reflect.Value) bool {
+		c, ok := compareValue(v, value)
+		return ok && f(c)
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) VisitLike(first, second string) (*Predicate, error) {
+	if v, ok := d.FieldTypes[first]; ok && v != epsearchast.String {
+		return nil, fmt.Errorf("like() operator is only supported for string fields, and [%s] is not a string", first)
+	}
+
+	return d.buildWildcardPredicate(first, ProcessLikeWildcards(second))
+}
+
+func (d DefaultMemQueryBuilder) VisitILike(first, second string) (*Predicate, error) {
+	if v, ok := d.FieldTypes[first]; ok && v != epsearchast.String {
+		return nil, fmt.Errorf("ilike() operator is only supported for string fields, and [%s] is not a string", first)
+	}
+
+	return d.buildWildcardPredicate(first, "(?i)"+ProcessLikeWildcards(second))
+}
+
+func (d DefaultMemQueryBuilder) buildWildcardPredicate(first string, pattern string) (*Predicate, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.anyElementMatches(first, func(v reflect.Value) bool {
+		if v.Kind() == reflect.String {
+			return re.MatchString(v.String())
+		}
+
+		var buf [64]byte
+		b, ok := appendString(buf[:0], v)
+		return ok && re.Match(b)
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) VisitContains(first, second string) (*Predicate, error) {
+	// Like Elasticsearch, a scalar is treated as an array with one element.
+	return d.VisitEq(first, second)
+}
+
+func (d DefaultMemQueryBuilder) VisitContainsAny(args ...string) (*Predicate, error) {
+	return d.VisitIn(args...)
+}
+
+func (d DefaultMemQueryBuilder) VisitContainsAll(args ...string) (*Predicate, error) {
+	values, err := d.ConvertValues(args[0], args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newFieldPath(args[0])
+
+	return newPredicate(func(doc any) (bool, error) {
+		root, err := getDocument(doc)
+		if err != nil {
+			return false, err
+		}
+
+		for _, value := range values {
+			found, err := d.visitFieldElements(root, p, func(e reflect.Value) bool {
+				c, ok := compareValue(e, value)
+				return ok && c == 0
+			})
+
+			if err != nil || !found {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}), nil
+}
+
+// VisitText matches if every term in the search appears as a word in the field (ignoring case), the last term only needs to be the prefix of a word,
+// which is similar to a match_bool_prefix query in Elasticsearch. The field * searches every string in the document.
+func (d DefaultMemQueryBuilder) VisitText(first, second string) (*Predicate, error) {
+	if v, ok := d.FieldTypes[first]; ok && v != epsearchast.String {
+		return nil, fmt.Errorf("text() operator is only supported for string fields, and [%s] is not a string", first)
+	}
+
+	terms := strings.FieldsFunc(strings.ToLower(second), isNotWordRune)
+	p := newFieldPath(first)
+
+	return newPredicate(func(doc any) (bool, error) {
+		root, err := getDocument(doc)
+		if err != nil {
+			return false, err
+		}
+
+		if len(terms) == 0 {
+			return false, nil
+		}
+
+		for i, term := range terms {
+			prefix := i == len(terms)-1
+
+			matchesTerm := func(e reflect.Value) bool {
+				if e.Kind() == reflect.String {
+					return containsWord(e.String(), term, prefix)
+				}
+
+				var buf [64]byte
+				b, ok := appendString(buf[:0], e)
+				return ok && containsWord(string(b), term, prefix)
+			}
+
+			var found bool
+			if first == "*" {
+				found, err = visitDocumentStrings(root, matchesTerm)
+			} else {
+				found, err = d.visitFieldElements(root, p, matchesTerm)
+			}
+
+			if err != nil || !found {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}), nil
+}
+
+func (d DefaultMemQueryBuilder) VisitIsNull(first string) (*Predicate, error) {
+	s := d.NullSemantics.ForField(first)
+
+	switch s {
+	case epsearchast.DefaultNullSemantics, epsearchast.NullOrMissing, epsearchast.ExplicitNullOnly, epsearchast.MissingOnly, epsearchast.NullOrMissingOrEmptyArray:
+	default:
+		return nil, fmt.Errorf("unsupported null semantics %s for field [%s]", s, first)
+	}
+
+	p := newFieldPath(first)
+
+	return newPredicate(func(doc any) (bool, error) {
+		root, err := getDocument(doc)
+		if err != nil {
+			return false, err
+		}
+
+		missing, null, emptyArray := true, false, false
+
+		_, err = d.visitField(root, p, func(v reflect.Value) bool {
+			missing = false
+
+			switch v = indirect(v); {
+			case isNull(v):
+				null = true
+			case isArray(v) && v.Len() == 0:
+				emptyArray = true
+			}
+
+			return false
+		})
+
+		if err != nil {
+			return false, err
+		}
+
+		switch s {
+		case epsearchast.ExplicitNullOnly:
+			return null, nil
+		case epsearchast.MissingOnly:
+			return missing, nil
+		case epsearchast.NullOrMissingOrEmptyArray:
+			return missing || null || emptyArray, nil
+		default:
+			return missing || null, nil
+		}
+	}), nil
+}
+
+// ConvertValue validates and converts the value from the filter to the type of the field.
+func (d DefaultMemQueryBuilder) ConvertValue(fieldName string, v string) (any, error) {
+	if fieldType, ok := d.FieldTypes[fieldName]; ok {
+		return epsearchast.Convert(fieldType, v)
+	}
+
+	return v, nil
+}
+
+// ConvertValues validates and converts the values from the filter to the type of the field.
+func (d DefaultMemQueryBuilder) ConvertValues(fieldName string, v ...string) ([]any, error) {
+	if fieldType, ok := d.FieldTypes[fieldName]; ok {
+		return epsearchast.ConvertAll(fieldType, v...)
+	}
+
+	return epsearchast.ConvertAll(epsearchast.String, v...)
+}
+
+// ProcessLikeWildcards converts a like() value into a regular expression, a * at the start or end of the value matches any characters.
+func ProcessLikeWildcards(valString string) string {
+	if valString == "*" {
+		return "^.*$"
+	}
+
+	var startsWithStar = strings.HasPrefix(valString, "*")
+	var endsWithStar = strings.HasSuffix(valString, "*")
+	if startsWithStar {
+		valString = valString[1:]
+	}
+	if endsWithStar {
+		valString = valString[:len(valString)-1]
+	}
+
+	valString = regexp.QuoteMeta(valString)
+
+	if startsWithStar {
+		valString = ".*" + valString
+	}
+	if endsWithStar {
+		valString += ".*"
+	}
+	return "(?s)^" + valString + "$"
+}
+
+func newPredicate(f func(doc any) (bool, error)) *Predicate {
+	p := Predicate(f)
+	return &p
+}
+
+// anyElementMatches returns a predicate that matches if any element of the field matches.
+func (d DefaultMemQueryBuilder) anyElementMatches(fieldName string, f func(v reflect.Value) bool) *Predicate {
+	p := newFieldPath(fieldName)
+
+	return newPredicate(func(doc any) (bool, error) {
+		root, err := getDocument(doc)
+		if err != nil {
+			return false, err
+		}
+
+		return d.visitFieldElements(root, p, f)
+	})
+}
+
+// fieldPath is a field name that has been split into its parts, so this doesn't need to be done for every document.
+type fieldPath struct {
+	parts []string
+
+	// The parts as values that can be used to look up maps
+	keys []reflect.Value
+
+	// The parts as array indexes, or -1 if the part isn't a number
+	indexes []int
+}
+
+func newFieldPath(fieldName string) fieldPath {
+	p := fieldPath{
+		parts: strings.Split(fieldName, "."),
+	}
+
+	for _, part := range p.parts {
+		p.keys = append(p.keys, reflect.ValueOf(part))
+
+		idx, err := strconv.Atoi(part)
+		if err != nil || idx < 0 {
+			idx = -1
+		}
+
+		p.indexes = append(p.indexes, idx)
+	}
+
+	return p
+}
+
+// visitValues calls f with every value at the path in v (there may be more than one if the path goes through an array), until f returns true,
+// and returns whether it did. If the field is missing f is never called.
+func (d DefaultMemQueryBuilder) visitValues(v reflect.Value, p fieldPath, depth int, f func(v reflect.Value) bool) bool {
+	if depth == len(p.parts) {
+		return f(v)
+	}
+
+	v = indirect(v)
+
+	switch {
+	case !v.IsValid():
+	case isArray(v):
+		if idx := p.indexes[depth]; idx >= 0 {
+			return idx < v.Len() && d.visitValues(v.Index(idx), p, depth+1, f)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if d.visitValues(v.Index(i), p, depth, f) {
+				return true
+			}
+		}
+	case isStringMap(v):
+		key := p.keys[depth]
+		if key.Type() != v.Type().Key() {
+			key = key.Convert(v.Type().Key())
+		}
+
+		if child := v.MapIndex(key); child.IsValid() {
+			return d.visitValues(child, p, depth+1, f)
+		}
+	case v.Kind() == reflect.Struct && v.Type() != timeType:
+		if child, ok := d.getStructField(v, p.parts[depth]); ok {
+			return d.visitValues(child, p, depth+1, f)
+		}
+	}
+
+	return false
+}
+
+// visitElements calls f with v, or the elements of v if it's an array, until f returns true, and returns whether it did.
+func visitElements(v reflect.Value, f func(v reflect.Value) bool) bool {
+	v = indirect(v)
+
+	if isArray(v) {
+		for i := 0; i < v.Len(); i++ {
+			if visitElements(v.Index(i), f) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return f(v)
+}
+
+// visitAllStrings calls f with every string in v, until f returns true, and returns whether it did.
+func visitAllStrings(v reflect.Value, f func(v reflect.Value) bool) bool {
+	v = indirect(v)
+
+	switch {
+	case !v.IsValid():
+	case v.Kind() == reflect.String:
+		return f(v)
+	case isArray(v):
+		for i := 0; i < v.Len(); i++ {
+			if visitAllStrings(v.Index(i), f) {
+				return true
+			}
+		}
+	case v.Kind() == reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			if visitAllStrings(it.Value(), f) {
+				return true
+			}
+		}
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && visitAllStrings(v.Field(i), f) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// document is the root of a document, either a Go value, or a JSON document which is scanned rather than decoded.
+type document struct {
+	value reflect.Value
+	json  []byte
+}
+
+func getDocument(doc any) (document, error) {
+	if data, ok := getJson(doc); ok {
+		root, err := getJsonRoot(data)
+		return document{json: root}, err
+	}
+
+	root := indirect(reflect.ValueOf(doc))
+
+	if !isStringMap(root) && root.Kind() != reflect.Struct {
+		if root.IsValid() {
+			// This reports the type of the item rather than a pointer to it (e.g., from FilterSeq).
+			return document{}, fmt.Errorf("unsupported document type %s", root.Type())
+		}
+
+		return document{}, fmt.Errorf("unsupported document type %T", doc)
+	}
+
+	return document{value: root}, nil
+}
+
+// visitField calls f with every value of the field in the document, see visitValues.
+func (d DefaultMemQueryBuilder) visitField(doc document, p fieldPath, f func(v reflect.Value) bool) (bool, error) {
+	if doc.json != nil {
+		return visitJsonValues(doc.json, p, 0, false, f)
+	}
+
+	return d.visitValues(doc.value, p, 0, f), nil
+}
+
+// visitFieldElements calls f with every element of the field in the document, see visitValues and visitElements.
+func (d DefaultMemQueryBuilder) visitFieldElements(doc document, p fieldPath, f func(v reflect.Value) bool) (bool, error) {
+	if doc.json != nil {
+		return visitJsonValues(doc.json, p, 0, true, f)
+	}
+
+	return d.visitValues(doc.value, p, 0, func(v reflect.Value) bool {
+		return visitElements(v, f)
+	}), nil
+}
+
+// visitDocumentStrings calls f with every string in the document, see visitAllStrings.
+func visitDocumentStrings(doc document, f func(v reflect.Value) bool) (bool, error) {
+	if doc.json != nil {
+		return visitJsonStrings(doc.json, f)
+	}
+
+	return visitAllStrings(doc.value, f), nil
+}
+
+// The fields of each struct type by tag name, the key is a reflect.Type and the value is a map[string]map[string][]int of tag name to field name to index.
+var structFieldsCache sync.Map
+
+func (d DefaultMemQueryBuilder) getStructField(v reflect.Value, name string) (reflect.Value, bool) {
+	tagName := d.TagName
+	if tagName == "" {
+		tagName = "json"
+	}
+
+	idx, ok := getStructFields(v.Type(), tagName)[name]
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	// This only fails if the field is promoted through a nil embedded pointer, which means it's missing.
+	f, err := v.FieldByIndexErr(idx)
+
+	return f, err == nil
+}
+
+// getStructFields returns the index of every field in the struct by name, this is computed once per type and tag name.
+func getStructFields(t reflect.Type, tagName string) map[string][]int {
+	cached, _ := structFieldsCache.Load(t)
+	byTag, _ := cached.(map[string]map[string][]int)
+
+	if fields, ok := byTag[tagName]; ok {
+		return fields
+	}
+
+	fields := map[string][]int{}
+	addStructFields(t, tagName, nil, fields, map[string]int{}, map[reflect.Type]bool{})
+
+	// The cached maps are never modified, so they can be read without locks.
+	newByTag := map[string]map[string][]int{tagName: fields}
+	for k, v := range byTag {
+		newByTag[k] = v
+	}
+
+	structFieldsCache.Store(t, newByTag)
+
+	return fields
+}
+
+// addStructFields adds the fields of the struct to fields, like encoding/json, fields of embedded structs are promoted, unless there is a field with the same name at a shallower depth.
+func addStructFields(t reflect.Type, tagName string, index []int, fields map[string][]int, depths map[string]int, seen map[reflect.Type]bool) {
+	// A struct can embed a pointer to itself (e.g., a linked list node), so a type isn't expanded again inside itself.
+	if seen[t] {
+		return
+	}
+
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, _, _ := strings.Cut(f.Tag.Get(tagName), ",")
+		if tag == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, tagName, fieldIndex, fields, depths, seen)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if tag == "" {
+			tag = f.Name
+		}
+
+		if depth, ok := depths[tag]; ok && depth <= len(index) {
+			continue
+		}
+
+		fields[tag] = fieldIndex
+		depths[tag] = len(index)
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// indirect follows pointers and interfaces, returning the zero Value if it finds a nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func isNull(v reflect.Value) bool {
+	v = indirect(v)
+
+	if !v.IsValid() {
+		return true
+	}
+
+	// A nil slice or map is encoded as null in JSON.
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil()
+}
+
+func isArray(v reflect.Value) bool {
+	// []byte is a string of bytes rather than an array of values.
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+func isBytes(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
+}
+
+func isStringMap(v reflect.Value) bool {
+	return v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String
+}
+
+func equalsAny(v reflect.Value, values []any) bool {
+	for _, value := range values {
+		if c, ok := compareValue(v, value); ok && c == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compareValue compares a value from the document with a converted value from the filter, it returns false if the document value can't be compared.
+// Strings in the document are parsed if the filter value isn't a string (e.g., json.Number).
+func compareValue(v reflect.Value, filterValue any) (int, bool) {
+	v = indirect(v)
+
+	if !v.IsValid() {
+		return 0, false
+	}
+
+	switch fv := filterValue.(type) {
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareOrdered(v.Int(), fv), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if fv < 0 {
+				return 1, true
+			}
+
+			return compareOrdered(v.Uint(), uint64(fv)), true
+		case reflect.Float32, reflect.Float64:
+			return compareOrdered(v.Float(), float64(fv)), true
+		case reflect.String:
+			if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+				return compareOrdered(i, fv), true
+			}
+
+			if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+				return compareOrdered(f, float64(fv)), true
+			}
+		}
+	case float64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareOrdered(float64(v.Int()), fv), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return compareOrdered(float64(v.Uint()), fv), true
+		case reflect.Float32, reflect.Float64:
+			return compareOrdered(v.Float(), fv), true
+		case reflect.String:
+			if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+				return compareOrdered(f, fv), true
+			}
+		}
+	case bool:
+		switch v.Kind() {
+		case reflect.Bool:
+			return compareOrdered(boolToInt(v.Bool()), boolToInt(fv)), true
+		case reflect.String:
+			if b, err := strconv.ParseBool(v.String()); err == nil {
+				return compareOrdered(boolToInt(b), boolToInt(fv)), true
+			}
+		}
+	case time.Time:
+		if t, ok := toTime(v); ok {
+			return t.Compare(fv), true
+		}
+	case string:
+		if v.Kind() == reflect.String {
+			return strings.Compare(v.String(), fv), true
+		}
+
+		var buf [64]byte
+		if b, ok := appendString(buf[:0], v); ok {
+			return compareBytes(b, fv), true
+		}
+	}
+
+	return 0, false
+}
+
+func toTime(v reflect.Value) (time.Time, bool) {
+	switch {
+	case v.Type() == timeType && v.CanAddr():
+		// This avoids copying the value to the heap.
+		return *(v.Addr().Interface().(*time.Time)), true
+	case v.Type() == timeType:
+		return v.Interface().(time.Time), true
+	case v.Kind() == reflect.String:
+		if t, err := time.Parse(time.RFC3339Nano, v.String()); err == nil {
+			return t, true
+		}
+
+		if t, err := time.Parse(time.DateOnly, v.String()); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// appendString appends the value as a string to buf, and returns false if it isn't a scalar.
+func appendString(buf []byte, v reflect.Value) ([]byte, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return append(buf, v.String()...), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(buf, v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(buf, v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(buf, v.Float(), 'f', -1, 64), true
+	case reflect.Bool:
+		return strconv.AppendBool(buf, v.Bool()), true
+	}
+
+	if isBytes(v) {
+		return append(buf, v.Bytes()...), true
+	}
+
+	if t, ok := toTime(v); ok && v.Kind() != reflect.String {
+		return t.UTC().AppendFormat(buf, time.RFC3339Nano), true
+	}
+
+	return buf, false
+}
+
+// compareBytes is strings.Compare(string(b), s) without converting b to a string.
+func compareBytes(b []byte, s string) int {
+	for i := 0; i < len(b) && i < len(s); i++ {
+		if b[i] != s[i] {
+			return compareOrdered(b[i], s[i])
+		}
+	}
+
+	return compareOrdered(len(b), len(s))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func compareOrdered[T int | int64 | uint64 | float64 | byte](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// containsWord returns whether s contains term as a word (ignoring case), or the prefix of a word if prefix is set.
+func containsWord(s string, term string, prefix bool) bool {
+	for {
+		start := strings.IndexFunc(s, func(r rune) bool { return !isNotWordRune(r) })
+		if start < 0 {
+			return false
+		}
+
+		s = s[start:]
+
+		word := s
+		if end := strings.IndexFunc(s, isNotWordRune); end >= 0 {
+			word, s = s[:end], s[end:]
+		} else {
+			s = ""
+		}
+
+		if strings.EqualFold(word, term) || (prefix && len(word) > len(term) && strings.EqualFold(word[:len(term)], term)) {
+			return true
+		}
+	}
+}
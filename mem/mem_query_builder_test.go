@@ -0,0 +1,320 @@
+package astmem
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City     string `json:"city"`
+	Postcode *string
+}
+
+type testAudit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testOrder struct {
+	testAudit
+	Status   string         `json:"status"`
+	Total    int            `json:"total"`
+	Paid     bool           `json:"paid"`
+	Tags     []string       `json:"tags"`
+	Notes    *string        `json:"notes"`
+	Address  testAddress    `json:"address"`
+	Items    []testItem     `json:"items"`
+	Internal string         `json:"-"`
+	Extra    map[string]any `json:"extra"`
+}
+
+type testItem struct {
+	Sku      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+var fieldTypes = map[string]epsearchast.FieldType{
+	"total":          epsearchast.Int64,
+	"paid":           epsearchast.Boolean,
+	"created_at":     epsearchast.Date,
+	"items.quantity": epsearchast.Int64,
+	"items.price":    epsearchast.Float64,
+}
+
+func getTestDocuments() map[string]any {
+	order := testOrder{
+		testAudit: testAudit{CreatedAt: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)},
+		Status:    "paid",
+		Total:     150,
+		Paid:      true,
+		Tags:      []string{"sale", "gift"},
+		Address:   testAddress{City: "Vancouver"},
+		Items: []testItem{
+			{Sku: "SHIRT-RED", Quantity: 2, Price: 25.5},
+			{Sku: "HAT-BLUE", Quantity: 1, Price: 99},
+		},
+		Internal: "secret",
+		Extra: map[string]any{
+			"description": "A lovely summer order for the cottage",
+			"empty":       []any{},
+			"null":        nil,
+		},
+	}
+
+	jsonDoc, err := json.Marshal(order)
+	if err != nil {
+		panic(err)
+	}
+
+	var mapDoc map[string]any
+	if err := json.Unmarshal(jsonDoc, &mapDoc); err != nil {
+		panic(err)
+	}
+
+	return map[string]any{
+		"struct":         order,
+		"struct pointer": &order,
+		"map":            mapDoc,
+		"json":           jsonDoc,
+		"raw json":       json.RawMessage(jsonDoc),
+	}
+}
+
+func TestPredicateMatchesDocuments(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected bool
+	}{
+		{`{"type": "EQ", "args": ["status", "paid"]}`, true},
+		{`{"type": "EQ", "args": ["status", "Paid"]}`, false},
+		{`{"type": "EQ", "args": ["total", "150"]}`, true},
+		{`{"type": "EQ", "args": ["paid", "true"]}`, true},
+		{`{"type": "EQ", "args": ["paid", "false"]}`, false},
+		{`{"type": "EQ", "args": ["address.city", "Vancouver"]}`, true},
+		{`{"type": "EQ", "args": ["Internal", "secret"]}`, false},
+		{`{"type": "EQ", "args": ["missing", "foo"]}`, false},
+		{`{"type": "IN", "args": ["status", "pending", "paid"]}`, true},
+		{`{"type": "IN", "args": ["status", "pending", "cancelled"]}`, false},
+		{`{"type": "GT", "args": ["total", "100"]}`, true},
+		{`{"type": "GT", "args": ["total", "150"]}`, false},
+		{`{"type": "GE", "args": ["total", "150"]}`, true},
+		{`{"type": "LT", "args": ["total", "1000"]}`, true},
+		{`{"type": "LE", "args": ["total", "149"]}`, false},
+		{`{"type": "GT", "args": ["created_at", "2024-06-01"]}`, true},
+		{`{"type": "LT", "args": ["created_at", "2024-06-15T12:00:00Z"]}`, false},
+		{`{"type": "LE", "args": ["created_at", "2024-06-15T12:00:00Z"]}`, true},
+		{`{"type": "GT", "args": ["items.price", "99"]}`, false},
+		{`{"type": "GE", "args": ["items.price", "99"]}`, true},
+		{`{"type": "EQ", "args": ["items.sku", "HAT-BLUE"]}`, true},
+		{`{"type": "EQ", "args": ["items.1.sku", "HAT-BLUE"]}`, true},
+		{`{"type": "EQ", "args": ["items.0.sku", "HAT-BLUE"]}`, false},
+		{`{"type": "LIKE", "args": ["items.sku", "SHIRT*"]}`, true},
+		{`{"type": "LIKE", "args": ["items.sku", "shirt*"]}`, false},
+		{`{"type": "ILIKE", "args": ["items.sku", "shirt*"]}`, true},
+		{`{"type": "ILIKE", "args": ["items.sku", "*blue"]}`, true},
+		{`{"type": "ILIKE", "args": ["items.sku", "*T*R*"]}`, false},
+		{`{"type": "LIKE", "args": ["status", "*"]}`, true},
+		{`{"type": "CONTAINS", "args": ["tags", "gift"]}`, true},
+		{`{"type": "CONTAINS", "args": ["tags", "new"]}`, false},
+		{`{"type": "CONTAINS_ANY", "args": ["tags", "new", "sale"]}`, true},
+		{`{"type": "CONTAINS_ANY", "args": ["tags", "new", "hot"]}`, false},
+		{`{"type": "CONTAINS_ALL", "args": ["tags", "gift", "sale"]}`, true},
+		{`{"type": "CONTAINS_ALL", "args": ["tags", "gift", "new"]}`, false},
+		{`{"type": "CONTAINS_ALL", "args": ["items.quantity", "1", "2"]}`, true},
+		{`{"type": "TEXT", "args": ["extra.description", "summer cottage"]}`, true},
+		{`{"type": "TEXT", "args": ["extra.description", "Summer Cott"]}`, true},
+		{`{"type": "TEXT", "args": ["extra.description", "winter cottage"]}`, false},
+		{`{"type": "TEXT", "args": ["*", "vancouver"]}`, true},
+		{`{"type": "TEXT", "args": ["*", "toronto"]}`, false},
+		{`{"type": "IS_NULL", "args": ["notes"]}`, true},
+		{`{"type": "IS_NULL", "args": ["status"]}`, false},
+		{`{"type": "IS_NULL", "args": ["missing"]}`, true},
+		{`{"type": "IS_NULL", "args": ["address.Postcode"]}`, true},
+		{`{"type": "IS_NULL", "args": ["extra.empty"]}`, false},
+		{`{"type": "AND", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "GT", "args": ["total", "100"]}]}`, true},
+		{`{"type": "AND", "children": [{"type": "EQ", "args": ["status", "paid"]}, {"type": "GT", "args": ["total", "200"]}]}`, false},
+		{`{"type": "OR", "children": [{"type": "EQ", "args": ["status", "pending"]}, {"type": "GT", "args": ["total", "100"]}]}`, true},
+		{`{"type": "OR", "children": [{"type": "EQ", "args": ["status", "pending"]}, {"type": "GT", "args": ["total", "200"]}]}`, false},
+	}
+
+	qb := DefaultMemQueryBuilder{
+		FieldTypes: fieldTypes,
+	}
+
+	for _, tc := range testCases {
+		for name, doc := range getTestDocuments() {
+			t.Run(fmt.Sprintf("%s %s", name, tc.filter), func(t *testing.T) {
+				// Fixture Setup
+				ast, err := epsearchast.GetAst(tc.filter)
+				require.NoError(t, err)
+
+				predicate, err := Compile(ast, qb)
+				require.NoError(t, err)
+
+				// Execute SUT
+				matches, err := predicate(doc)
+
+				// Verification
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, matches)
+			})
+		}
+	}
+}
+
+func TestPredicateWithoutFieldTypesComparesStrings(t *testing.T) {
+	// Fixture Setup
+	ast, err := epsearchast.GetAst(`{"type": "GT", "args": ["total", "20"]}`)
+	require.NoError(t, err)
+
+	predicate, err := Compile(ast, DefaultMemQueryBuilder{})
+	require.NoError(t, err)
+
+	// Execute SUT
+	matches, err := predicate(map[string]any{"total": 150})
+
+	// Verification
+	require.NoError(t, err)
+	require.False(t, matches)
+}
+
+func TestPredicateIsNullWithNullSemantics(t *testing.T) {
+	doc := map[string]any{
+		"null":        nil,
+		"empty_array": []any{},
+		"value":       "foo",
+	}
+
+	testCases := []struct {
+		semantics epsearchast.NullSemantics
+		expected  map[string]bool
+	}{
+		{epsearchast.DefaultNullSemantics, map[string]bool{"null": true, "empty_array": false, "value": false, "missing": true}},
+		{epsearchast.ExplicitNullOnly, map[string]bool{"null": true, "empty_array": false, "value": false, "missing": false}},
+		{epsearchast.MissingOnly, map[string]bool{"null": false, "empty_array": false, "value": false, "missing": true}},
+		{epsearchast.NullOrMissing, map[string]bool{"null": true, "empty_array": false, "value": false, "missing": true}},
+		{epsearchast.NullOrMissingOrEmptyArray, map[string]bool{"null": true, "empty_array": true, "value": false, "missing": true}},
+	}
+
+	for _, tc := range testCases {
+		for field, expected := range tc.expected {
+			t.Run(fmt.Sprintf("%s %s", tc.semantics, field), func(t *testing.T) {
+				// Fixture Setup
+				ast, err := epsearchast.GetAst(fmt.Sprintf(`{"type": "IS_NULL", "args": ["%s"]}`, field))
+				require.NoError(t, err)
+
+				predicate, err := Compile(ast, DefaultMemQueryBuilder{
+					NullSemantics: epsearchast.NullSemanticsConfig{Default: tc.semantics},
+				})
+				require.NoError(t, err)
+
+				// Execute SUT
+				matches, err := predicate(doc)
+
+				// Verification
+				require.NoError(t, err)
+				require.Equal(t, expected, matches)
+			})
+		}
+	}
+}
+
+func TestPredicateUsesTagName(t *testing.T) {
+	// Fixture Setup
+	type record struct {
+		Status string `bson:"state" json:"status"`
+	}
+
+	ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["state", "live"]}`)
+	require.NoError(t, err)
+
+	predicate, err := Compile(ast, DefaultMemQueryBuilder{TagName: "bson"})
+	require.NoError(t, err)
+
+	// Execute SUT
+	matches, err := predicate(record{Status: "live"})
+
+	// Verification
+	require.NoError(t, err)
+	require.True(t, matches)
+}
+
+func TestPredicateSupportsSelfReferentialEmbeddedPointers(t *testing.T) {
+	// Fixture Setup
+	type node struct {
+		*node
+		Status string `json:"status"`
+	}
+
+	ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "live"]}`)
+	require.NoError(t, err)
+
+	predicate, err := Compile(ast, DefaultMemQueryBuilder{})
+	require.NoError(t, err)
+
+	// Execute SUT
+	matches, err := predicate(node{node: &node{Status: "draft"}, Status: "live"})
+
+	// Verification
+	require.NoError(t, err)
+	require.True(t, matches)
+}
+
+func TestCompileReturnsErrorForInvalidValues(t *testing.T) {
+	testCases := []struct {
+		filter string
+		error  string
+	}{
+		{`{"type": "EQ", "args": ["total", "abc"]}`, "invalid value for int64"},
+		{`{"type": "IN", "args": ["total", "1", "two"]}`, "invalid value for int64"},
+		{`{"type": "CONTAINS_ALL", "args": ["items.quantity", "1", "two"]}`, "invalid value for int64"},
+		{`{"type": "GT", "args": ["created_at", "yesterday"]}`, "invalid value for date"},
+		{`{"type": "LIKE", "args": ["total", "1*"]}`, "like() operator is only supported for string fields, and [total] is not a string"},
+		{`{"type": "ILIKE", "args": ["total", "1*"]}`, "ilike() operator is only supported for string fields, and [total] is not a string"},
+		{`{"type": "TEXT", "args": ["total", "1"]}`, "text() operator is only supported for string fields, and [total] is not a string"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			// Execute SUT
+			_, err = Compile(ast, DefaultMemQueryBuilder{FieldTypes: fieldTypes})
+
+			// Verification
+			require.ErrorContains(t, err, tc.error)
+		})
+	}
+}
+
+func TestPredicateReturnsErrorForUnsupportedDocuments(t *testing.T) {
+	testCases := map[string]any{
+		"invalid json": []byte(`{"status":`),
+		"scalar":       42,
+		"nil":          nil,
+	}
+
+	for name, doc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "paid"]}`)
+			require.NoError(t, err)
+
+			predicate, err := Compile(ast, DefaultMemQueryBuilder{})
+			require.NoError(t, err)
+
+			// Execute SUT
+			_, err = predicate(doc)
+
+			// Verification
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package astmem
+
+import (
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+)
+
+// MemQueryBuilderFromSchema returns a query builder with the field types from the schema, for an AST that has had aliases applied,
+// as documents in memory use the names of fields in the filter.
+//
+// The schema doesn't describe null semantics or struct tags, so NullSemantics and TagName can be set on the result.
+func MemQueryBuilderFromSchema(s *astschema.Schema) DefaultMemQueryBuilder {
+	return DefaultMemQueryBuilder{
+		FieldTypes: s.FieldTypes(),
+	}
+}
@@ -0,0 +1,46 @@
+package astmem
+
+import (
+	"strings"
+	"testing"
+
+	epsearchast "github.com/elasticpath/epcc-search-ast-helper"
+	astschema "github.com/elasticpath/epcc-search-ast-helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemQueryBuilderFromSchemaUsesFieldTypes(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  status:
+    operators: [eq]
+    aliases: [state]
+    mongo:
+      path: attributes.status
+  total:
+    operators: [gt]
+    type: int64
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(state,paid):gt(total,5)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyAliases(ast)
+	require.NoError(t, err)
+
+	// A string comparison would be false, as "10" < "5".
+	doc := map[string]any{"status": "paid", "total": 10}
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(ast, MemQueryBuilderFromSchema(s))
+
+	// Verification
+	require.NoError(t, err)
+
+	matched, err := (*query)(doc)
+	require.NoError(t, err)
+	require.True(t, matched)
+}
@@ -2,6 +2,8 @@ package astmongo
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/elasticpath/epcc-search-ast-helper"
@@ -13,6 +15,111 @@ type DefaultAtlasSearchQueryBuilder struct {
 	// If a field is not in this map, or if the analyzer name is "",
 	// the base path will be used without specifying a multi-analyzer
 	FieldToMultiAnalyzers map[string]*StringMultiAnalyzers
+
+	// NullSemantics controls what is_null matches. Like Elasticsearch, Atlas Search does not index null values or empty arrays,
+	// so an exists query cannot tell explicit nulls, missing fields and empty arrays apart, and only epsearchast.NullOrMissingOrEmptyArray (the default) is supported.
+	NullSemantics epsearchast.NullSemanticsConfig
+
+	// FieldTypes controls how values are converted (with epsearchast.Convert) before being sent to Atlas Search, fields not in this map are sent as strings.
+	// The field must be indexed with the matching type (e.g., number, date, boolean), which makes range operators compare numerically or chronologically
+	// instead of lexicographically.
+	FieldTypes map[string]epsearchast.FieldType
+
+	// ObjectIdFields are fields that store ObjectIds, values are converted to bson.ObjectID and the field must be indexed with the objectId type.
+	ObjectIdFields map[string]bool
+
+	// UseCompoundFilter places predicates that don't need to contribute to relevance (everything except text and fields with a score in FieldScores)
+	// in compound.filter instead of compound.must, so that exact filters (e.g., on status or price) don't distort scoring.
+	// https://www.mongodb.com/docs/atlas/atlas-search/compound/#filter-examples
+	UseCompoundFilter bool
+
+	// FieldScores lets you modify the score of predicates on a field (https://www.mongodb.com/docs/atlas/atlas-search/score/modify-score/).
+	FieldScores map[string]*FieldScore
+
+	// https://www.mongodb.com/docs/atlas/atlas-search/embedded-document/
+	// EmbeddedDocumentFieldToQuery is a keyed map that takes as a key a regular expression for an attribute that we should match (e.g., requested by the user, after aliases have been processed).
+	// The value is information about how to replace it, and allows us to create an embeddedDocument query that requires all the subqueries to match the same element of the array at Path.
+	// The regular expression can have capture groups that will be used as replacements in the subquery keys and values.
+	// Predicates that are AND-ed together on the same path (and with the same ForceEQ subqueries) are grouped into one query, so they must all match the same element.
+	EmbeddedDocumentFieldToQuery map[string]EmbeddedDocumentReplacement
+
+	// The compiled patterns from EmbeddedDocumentFieldToQuery sorted by pattern, set by MustCompile, otherwise the patterns are compiled for each predicate.
+	compiledEmbeddedDocumentFields []compiledEmbeddedDocumentField
+
+	// FieldToTextStrategy controls the operator (and its options) used for text() on a field.
+	// If a field is not in this map, DefaultTextStrategy is used.
+	FieldToTextStrategy map[string]*TextStrategy
+
+	// The strategy to use for text() on fields not in FieldToTextStrategy, the zero value generates a plain text operator.
+	DefaultTextStrategy TextStrategy
+
+	// Flatten merges nested compound operators (e.g., AND(AND(a,b),c) generates a single compound with three must clauses), and removes compound operators
+	// that only wrap a single clause.
+	Flatten bool
+}
+
+type TextOperator string
+
+const (
+	// https://www.mongodb.com/docs/atlas/atlas-search/text/
+	TextOperatorText TextOperator = "text"
+	// https://www.mongodb.com/docs/atlas/atlas-search/phrase/
+	TextOperatorPhrase TextOperator = "phrase"
+	// https://www.mongodb.com/docs/atlas/atlas-search/autocomplete/ (the field must be indexed with the autocomplete type)
+	TextOperatorAutocomplete TextOperator = "autocomplete"
+)
+
+type TextStrategy struct {
+	// The operator to use, if empty the text operator is used.
+	Operator TextOperator
+
+	// The maximum number of single-character edits for fuzzy matching (1 or 2), only supported by text and autocomplete.
+	// If zero, fuzzy matching is disabled.
+	// If set, will use: {"fuzzy": {"maxEdits": this_value, "prefixLength": FuzzyPrefixLength}}
+	FuzzyMaxEdits int
+
+	// The number of characters at the beginning of each term that must exactly match when fuzzy matching.
+	FuzzyPrefixLength int
+
+	// The allowable distance between words in the query phrase, only supported by phrase.
+	// If set, will use: {"slop": this_value}
+	Slop int
+
+	// The name of the synonym mapping in the index definition, only supported by text and phrase, and can't be combined with fuzzy matching.
+	// If set, will use: {"synonyms": this_value}
+	Synonyms string
+}
+
+type EmbeddedDocumentReplacement struct {
+	// The path of the array of documents, which must be indexed with the embeddedDocuments type (See: https://www.mongodb.com/docs/atlas/atlas-search/field-types/embedded-documents-type/)
+	Path string
+
+	// A map which generates the set of subqueries queries that should be generated, keys must be the full path of the field (e.g., variants.sku not sku).
+	// Named capture groups in the parent map will be replaced (e.g., a field ^variants\[(?P<sku>\w+)\]\.(?P<attr>\w+)$) can use $sku as a replacement in this string.
+	Subqueries map[string]Replacement
+}
+
+type compiledEmbeddedDocumentField struct {
+	pattern     *regexp.Regexp
+	replacement EmbeddedDocumentReplacement
+}
+
+type Replacement struct {
+	// The value we should search for, we can use the named capture groups from the parent regex as replacements, also the special value $value is available
+	Value string
+
+	// By default, we will use the existing search term as a replacement, if set to true, we will generate an equality match.
+	ForceEQ bool
+}
+
+type FieldScore struct {
+	// Multiplies the score of matching documents by this value.
+	// If set, will use: {"score": {"boost": {"value": this_value}}}
+	Boost float64
+
+	// Replaces the score of matching documents with this value, only one of Boost or Constant may be set.
+	// If set, will use: {"score": {"constant": {"value": this_value}}}
+	Constant float64
 }
 
 type StringMultiAnalyzers struct {
@@ -29,16 +136,229 @@ type StringMultiAnalyzers struct {
 
 var _ epsearchast.SemanticReducer[bson.D] = (*DefaultAtlasSearchQueryBuilder)(nil)
 
+// MustValidate will ensure that the configuration of the query builder is correct and if not, panics. It simplifies safe initialization of the variable.
+func (d DefaultAtlasSearchQueryBuilder) MustValidate() {
+	if err := d.NullSemantics.ValidateSupported("Atlas Search", epsearchast.NullOrMissingOrEmptyArray); err != nil {
+		panic(fmt.Sprintf("Invalid null semantics: %v", err))
+	}
+
+	if err := d.DefaultTextStrategy.validate(); err != nil {
+		panic(fmt.Sprintf("Invalid default text strategy: %v", err))
+	}
+
+	for k, v := range d.FieldToTextStrategy {
+		if v == nil {
+			continue
+		}
+
+		if err := v.validate(); err != nil {
+			panic(fmt.Sprintf("Invalid text strategy for field [%s]: %v", k, err))
+		}
+	}
+
+	for k, v := range d.EmbeddedDocumentFieldToQuery {
+		re := regexp.MustCompile(k)
+
+		if !strings.HasPrefix(k, "^") {
+			panic(fmt.Sprintf("All embedded document fields must be anchored to the start of the string (e.g., start with a ^), [%s] does not", k))
+		}
+
+		if !strings.HasSuffix(k, "$") {
+			panic(fmt.Sprintf("All embedded document fields must be anchored at the end of the string (e.g., end in an $), [%s] does not", k))
+		}
+
+		var groupKeys []string
+		for _, name := range re.SubexpNames() {
+			if name == "value" {
+				panic(fmt.Sprintf("Named capture group 'value' is reserved for the replacement value, [%s] cannot use this", k))
+			}
+			groupKeys = append(groupKeys, name)
+		}
+
+		// Resolve keys in decreasing order of length, so that $user doesn't clobber $username.
+		sortByDecreasingLength(groupKeys)
+
+		if v.Path == "" {
+			panic(fmt.Sprintf("Path must be set for embedded document field [%s]", k))
+		}
+
+		if len(v.Subqueries) < 1 {
+			panic(fmt.Sprintf("Subqueries must be set for embedded document field [%s]", k))
+		}
+
+		for sK, sV := range v.Subqueries {
+			if strings.Contains(sK, "$value") {
+				// See DefaultEsQueryBuilder.MustValidate, allowing this would let users pick the field that is searched.
+				panic(fmt.Sprintf("You cannot use $value as replacement in a key in [%s]", sK))
+			}
+
+			if !strings.HasPrefix(sK, v.Path+".") {
+				panic(fmt.Sprintf("Subquery key [%s] for embedded document field [%s] must be under the path [%s]", sK, k, v.Path))
+			}
+
+			sqField := sK
+			sqValue := sV.Value
+
+			for _, group := range groupKeys {
+				if group == "" {
+					continue
+				}
+				sqField = strings.ReplaceAll(sqField, "$"+group, "")
+				sqValue = strings.ReplaceAll(sqValue, "$"+group, "")
+			}
+
+			sqValue = strings.ReplaceAll(sqValue, "$value", "")
+
+			if strings.Contains(sqField, "$") {
+				panic(fmt.Sprintf("Not all templates replaced in embedded document field [%s] key [%s], after replacement left over with: %s ", k, sK, sqField))
+			}
+
+			if strings.Contains(sqValue, "$") {
+				panic(fmt.Sprintf("Not all templates replaced in embedded document field [%s] key [%s] with value [%s], after replacement left over with: %s", k, sK, sV.Value, sqValue))
+			}
+		}
+	}
+}
+
+// MustCompile validates the configuration with MustValidate, and returns a copy of the query builder with the embedded document and null semantics patterns compiled.
+// The embedded document and null semantics configuration must not be changed after this is called.
+func (d DefaultAtlasSearchQueryBuilder) MustCompile() DefaultAtlasSearchQueryBuilder {
+	d.MustValidate()
+
+	d.compiledEmbeddedDocumentFields = d.compileEmbeddedDocumentFields()
+	d.NullSemantics = d.NullSemantics.MustCompile()
+
+	return d
+}
+
+// getEmbeddedDocumentFields returns the embedded document patterns sorted by pattern.
+func (d DefaultAtlasSearchQueryBuilder) getEmbeddedDocumentFields() []compiledEmbeddedDocumentField {
+	if d.compiledEmbeddedDocumentFields != nil {
+		return d.compiledEmbeddedDocumentFields
+	}
+
+	return d.compileEmbeddedDocumentFields()
+}
+
+func (d DefaultAtlasSearchQueryBuilder) compileEmbeddedDocumentFields() []compiledEmbeddedDocumentField {
+	patterns := make([]string, 0, len(d.EmbeddedDocumentFieldToQuery))
+	for k := range d.EmbeddedDocumentFieldToQuery {
+		patterns = append(patterns, k)
+	}
+
+	sort.Strings(patterns)
+
+	compiled := make([]compiledEmbeddedDocumentField, 0, len(patterns))
+	for _, k := range patterns {
+		compiled = append(compiled, compiledEmbeddedDocumentField{
+			pattern:     regexp.MustCompile(k),
+			replacement: d.EmbeddedDocumentFieldToQuery[k],
+		})
+	}
+
+	return compiled
+}
+
 func (d DefaultAtlasSearchQueryBuilder) PostVisitAnd(rs []*bson.D) (*bson.D, error) {
 	// https://www.mongodb.com/docs/atlas/atlas-search/compound/
+	if !d.UseCompoundFilter && !d.Flatten {
+		if len(d.EmbeddedDocumentFieldToQuery) > 0 && len(rs) > 1 {
+			grouped, err := groupEmbeddedDocumentQueries(rs)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(grouped) == 1 {
+				return grouped[0], nil
+			}
+
+			rs = grouped
+		}
+
+		return &bson.D{
+			{"compound", bson.D{
+				{"must", rs},
+			}},
+		}, nil
+	}
+
+	var musts, filters []*bson.D
+
+	for _, r := range rs {
+		if d.Flatten {
+			if childMusts, childFilters, ok := getConjunctionClauses(*r); ok {
+				musts = append(musts, childMusts...)
+				filters = append(filters, childFilters...)
+				continue
+			}
+		}
+
+		musts = append(musts, r)
+	}
+
+	if len(d.EmbeddedDocumentFieldToQuery) > 0 {
+		// This happens after flattening, so that embedded document queries in nested conjunctions are grouped too.
+		grouped, err := groupEmbeddedDocumentQueries(append(musts, filters...))
+		if err != nil {
+			return nil, err
+		}
+
+		musts, filters = grouped, nil
+	}
+
+	if d.UseCompoundFilter {
+		var scoring []*bson.D
+
+		for _, r := range musts {
+			if isScoringClause(*r) {
+				scoring = append(scoring, r)
+			} else {
+				filters = append(filters, r)
+			}
+		}
+
+		musts = scoring
+	}
+
+	if d.Flatten && len(musts) == 1 && len(filters) == 0 {
+		// A compound with a single must clause matches and scores the same as the clause.
+		return musts[0], nil
+	}
+
+	compound := bson.D{}
+
+	if len(musts) > 0 {
+		compound = append(compound, bson.E{Key: "must", Value: musts})
+	}
+
+	if len(filters) > 0 {
+		compound = append(compound, bson.E{Key: "filter", Value: filters})
+	}
+
 	return &bson.D{
-		{"compound", bson.D{
-			{"must", rs},
-		}},
+		{"compound", compound},
 	}, nil
 }
 
 func (d DefaultAtlasSearchQueryBuilder) PostVisitOr(rs []*bson.D) (*bson.D, error) {
+	if d.Flatten {
+		var shoulds []*bson.D
+
+		for _, r := range rs {
+			if childShoulds, ok := getDisjunctionClauses(*r); ok {
+				shoulds = append(shoulds, childShoulds...)
+			} else {
+				shoulds = append(shoulds, r)
+			}
+		}
+
+		if len(shoulds) == 1 {
+			return shoulds[0], nil
+		}
+
+		rs = shoulds
+	}
+
 	// https://www.mongodb.com/docs/atlas/atlas-search/compound/
 	return &bson.D{
 		{"compound", bson.D{
@@ -50,14 +370,247 @@ func (d DefaultAtlasSearchQueryBuilder) PostVisitOr(rs []*bson.D) (*bson.D, erro
 	}, nil
 }
 
-func (d DefaultAtlasSearchQueryBuilder) VisitText(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/text/
+// groupEmbeddedDocumentQueries combines embeddedDocument queries on the same path, and with the same ForceEQ subqueries (e.g., the colour of variants[red]),
+// into one embeddedDocument query, so that all the predicates must match the same element of the array.
+func groupEmbeddedDocumentQueries(rs []*bson.D) ([]*bson.D, error) {
+	type group struct {
+		path    string
+		musts   []*bson.D
+		filters []*bson.D
+		size    int
+	}
+
+	var grouped []*bson.D
+	var groups []*group
+	groupIdx := map[string]int{}
+
+	for _, r := range rs {
+		path, musts, filters, ok := getEmbeddedDocumentQuery(*r)
+		if !ok {
+			grouped = append(grouped, r)
+			groups = append(groups, nil)
+			continue
+		}
+
+		filtersJson, err := bson.MarshalExtJSON(bson.D{{"filter", filters}}, true, false)
+		if err != nil {
+			return nil, fmt.Errorf("could not group embedded document query for path [%s]: %w", path, err)
+		}
+
+		key := path + "/" + string(filtersJson)
+		if idx, ok := groupIdx[key]; ok {
+			groups[idx].musts = append(groups[idx].musts, musts...)
+			groups[idx].size++
+			continue
+		}
+
+		groupIdx[key] = len(grouped)
+		grouped = append(grouped, r)
+		groups = append(groups, &group{path: path, musts: musts, filters: filters, size: 1})
+	}
+
+	for i, g := range groups {
+		if g == nil || g.size < 2 {
+			continue
+		}
+
+		grouped[i] = buildEmbeddedDocumentQuery(g.path, g.musts, g.filters)
+	}
+
+	return grouped, nil
+}
+
+// getEmbeddedDocumentQuery returns the path, and the must and filter clauses of q, if q is an embeddedDocument query from processEmbeddedDocumentFieldToQuery.
+func getEmbeddedDocumentQuery(q bson.D) (string, []*bson.D, []*bson.D, bool) {
+	if len(q) != 1 || q[0].Key != "embeddedDocument" {
+		return "", nil, nil, false
+	}
+
+	body, ok := q[0].Value.(bson.D)
+	if !ok || len(body) != 2 || body[0].Key != "path" || body[1].Key != "operator" {
+		return "", nil, nil, false
+	}
+
+	path, ok := body[0].Value.(string)
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	operator, ok := body[1].Value.(bson.D)
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	musts, filters, ok := getConjunctionClauses(operator)
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	return path, musts, filters, true
+}
+
+func buildEmbeddedDocumentQuery(path string, musts []*bson.D, filters []*bson.D) *bson.D {
+	compound := bson.D{{"must", musts}}
+
+	if len(filters) > 0 {
+		compound = append(compound, bson.E{Key: "filter", Value: filters})
+	}
+
 	return &bson.D{
-		{"text", bson.D{
-			{"query", second},
-			{"path", first},
+		{"embeddedDocument", bson.D{
+			{"path", path},
+			{"operator", bson.D{
+				{"compound", compound},
+			}},
 		}},
-	}, nil
+	}
+}
+
+// getConjunctionClauses returns the must and filter clauses of q, if q is a compound that only contains must and filter clauses (i.e., it came from PostVisitAnd, and has no score).
+func getConjunctionClauses(q bson.D) ([]*bson.D, []*bson.D, bool) {
+	compound, ok := getCompound(q)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var musts, filters []*bson.D
+
+	for _, e := range compound {
+		clauses, ok := e.Value.([]*bson.D)
+		if !ok {
+			return nil, nil, false
+		}
+
+		switch e.Key {
+		case "must":
+			musts = clauses
+		case "filter":
+			filters = clauses
+		default:
+			return nil, nil, false
+		}
+	}
+
+	return musts, filters, true
+}
+
+// getDisjunctionClauses returns the should clauses of q, if q is a compound that only contains should clauses where one must match (i.e., it came from PostVisitOr, and has no score).
+func getDisjunctionClauses(q bson.D) ([]*bson.D, bool) {
+	compound, ok := getCompound(q)
+	if !ok || len(compound) != 2 || compound[0].Key != "should" || compound[1].Key != "minimumShouldMatch" || compound[1].Value != 1 {
+		return nil, false
+	}
+
+	shoulds, ok := compound[0].Value.([]*bson.D)
+
+	return shoulds, ok
+}
+
+func getCompound(q bson.D) (bson.D, bool) {
+	if len(q) != 1 || q[0].Key != "compound" {
+		return nil, false
+	}
+
+	compound, ok := q[0].Value.(bson.D)
+
+	return compound, ok
+}
+
+func (d DefaultAtlasSearchQueryBuilder) VisitText(first, second string) (*bson.D, error) {
+	return d.buildQueryWithBuilder(d.GetTextQueryBuilder(), first, second)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetTextQueryBuilder() func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		if err := d.ValidateStringField("text", args[0]); err != nil {
+			return nil, err
+		}
+
+		strategy := d.textStrategyForField(args[0])
+
+		if err := strategy.validate(); err != nil {
+			return nil, fmt.Errorf("invalid text strategy for field [%s]: %w", args[0], err)
+		}
+
+		operator := strategy.Operator
+		if operator == "" {
+			operator = TextOperatorText
+		}
+
+		options := bson.D{
+			{"query", args[1]},
+			{"path", args[0]},
+		}
+
+		if strategy.FuzzyMaxEdits != 0 {
+			options = append(options, bson.E{Key: "fuzzy", Value: bson.D{
+				{"maxEdits", strategy.FuzzyMaxEdits},
+				{"prefixLength", strategy.FuzzyPrefixLength},
+			}})
+		}
+
+		if strategy.Slop != 0 {
+			options = append(options, bson.E{Key: "slop", Value: strategy.Slop})
+		}
+
+		if strategy.Synonyms != "" {
+			options = append(options, bson.E{Key: "synonyms", Value: strategy.Synonyms})
+		}
+
+		return d.ApplyFieldScore(args[0], &bson.D{
+			{string(operator), options},
+		})
+	}
+}
+
+func (d DefaultAtlasSearchQueryBuilder) textStrategyForField(fieldName string) TextStrategy {
+	if s, ok := d.FieldToTextStrategy[fieldName]; ok && s != nil {
+		return *s
+	}
+
+	return d.DefaultTextStrategy
+}
+
+func (t TextStrategy) validate() error {
+	switch t.Operator {
+	case "", TextOperatorText, TextOperatorPhrase, TextOperatorAutocomplete:
+	default:
+		return fmt.Errorf("unknown operator %s", t.Operator)
+	}
+
+	if t.FuzzyMaxEdits < 0 || t.FuzzyMaxEdits > 2 {
+		return fmt.Errorf("fuzzy max edits must be 1 or 2, got %d", t.FuzzyMaxEdits)
+	}
+
+	if t.FuzzyPrefixLength < 0 {
+		return fmt.Errorf("fuzzy prefix length must not be negative, got %d", t.FuzzyPrefixLength)
+	}
+
+	if t.FuzzyPrefixLength != 0 && t.FuzzyMaxEdits == 0 {
+		return fmt.Errorf("fuzzy prefix length requires fuzzy max edits to be set")
+	}
+
+	if t.FuzzyMaxEdits != 0 && t.Operator == TextOperatorPhrase {
+		return fmt.Errorf("fuzzy matching is not supported by the phrase operator")
+	}
+
+	if t.Slop < 0 {
+		return fmt.Errorf("slop must not be negative, got %d", t.Slop)
+	}
+
+	if t.Slop != 0 && t.Operator != TextOperatorPhrase {
+		return fmt.Errorf("slop is only supported by the phrase operator")
+	}
+
+	if t.Synonyms != "" && t.Operator == TextOperatorAutocomplete {
+		return fmt.Errorf("synonyms are not supported by the autocomplete operator")
+	}
+
+	if t.Synonyms != "" && t.FuzzyMaxEdits != 0 {
+		return fmt.Errorf("synonyms can't be combined with fuzzy matching")
+	}
+
+	return nil
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitIn(args ...string) (*bson.D, error) {
@@ -66,109 +619,349 @@ func (d DefaultAtlasSearchQueryBuilder) VisitIn(args ...string) (*bson.D, error)
 		return nil, fmt.Errorf("IN operator requires at least 2 arguments (field and at least one value)")
 	}
 
-	fieldName := args[0]
-	values := args[1:]
+	return d.buildQueryWithBuilder(d.GetInQueryBuilder(), args...)
+}
 
-	return &bson.D{
-		{"in", bson.D{
-			{"path", fieldName},
-			{"value", values},
-		}},
-	}, nil
+func (d DefaultAtlasSearchQueryBuilder) GetInQueryBuilder() func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		fieldName := args[0]
+		values, err := d.ConvertValues(fieldName, args[1:]...)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ApplyFieldScore(fieldName, &bson.D{
+			{"in", bson.D{
+				{"path", fieldName},
+				{"value", values},
+			}},
+		})
+	}
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitEq(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/equals/
-	return &bson.D{
-		{"equals", bson.D{
-			{"path", first},
-			{"value", second},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetEqualsQueryBuilder(), first, second)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetEqualsQueryBuilder() func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		// https://www.mongodb.com/docs/atlas/atlas-search/equals/
+		q, err := d.buildEqualsQuery(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ApplyFieldScore(args[0], q)
+	}
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitLe(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/range/
-	return &bson.D{
-		{"range", bson.D{
-			{"path", first},
-			{"lte", second},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetRangeQueryBuilder("lte"), first, second)
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitLt(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/range/
-	return &bson.D{
-		{"range", bson.D{
-			{"path", first},
-			{"lt", second},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetRangeQueryBuilder("lt"), first, second)
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitGe(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/range/
-	return &bson.D{
-		{"range", bson.D{
-			{"path", first},
-			{"gte", second},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetRangeQueryBuilder("gte"), first, second)
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitGt(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/range/
-	return &bson.D{
-		{"range", bson.D{
-			{"path", first},
-			{"gt", second},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetRangeQueryBuilder("gt"), first, second)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetRangeQueryBuilder(op string) func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		// https://www.mongodb.com/docs/atlas/atlas-search/range/
+		value, err := d.ConvertValue(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ApplyFieldScore(args[0], &bson.D{
+			{"range", bson.D{
+				{"path", args[0]},
+				{op, value},
+			}},
+		})
+	}
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitLike(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/wildcard/
 	// Case-sensitive wildcard matching (unlike ILIKE which is case-insensitive)
-	path := d.getWildcardPath(first, true)
-
-	return &bson.D{
-		{"wildcard", bson.D{
-			{"path", path},
-			{"query", d.ProcessWildcardString(second)},
-			{"allowAnalyzedField", true},
-		}},
-	}, nil
+	return d.buildQueryWithBuilder(d.GetWildcardQueryBuilder(true), first, second)
 }
 
 func (d DefaultAtlasSearchQueryBuilder) VisitILike(first, second string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/atlas/atlas-search/wildcard/
 	// Case-insensitive wildcard matching (uses allowAnalyzedField: true)
-	path := d.getWildcardPath(first, false)
+	return d.buildQueryWithBuilder(d.GetWildcardQueryBuilder(false), first, second)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetWildcardQueryBuilder(caseSensitive bool) func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		operator := "ilike"
+		if caseSensitive {
+			operator = "like"
+		}
+
+		if err := d.ValidateStringField(operator, args[0]); err != nil {
+			return nil, err
+		}
+
+		// https://www.mongodb.com/docs/atlas/atlas-search/wildcard/
+		path := d.getWildcardPath(args[0], caseSensitive)
+
+		return d.ApplyFieldScore(args[0], &bson.D{
+			{"wildcard", bson.D{
+				{"path", path},
+				{"query", d.ProcessWildcardString(args[1])},
+				{"allowAnalyzedField", true},
+			}},
+		})
+	}
+}
+
+func (d DefaultAtlasSearchQueryBuilder) VisitContains(first, second string) (*bson.D, error) {
+	// https://www.mongodb.com/docs/atlas/atlas-search/equals/
+	// When the path is an array, equals matches if any element of the array is equal to the value.
+	return d.buildQueryWithBuilder(d.GetEqualsQueryBuilder(), first, second)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) VisitContainsAny(args ...string) (*bson.D, error) {
+	// https://www.mongodb.com/docs/atlas/atlas-search/in/
+	// When the path is an array, in matches if any element of the array is equal to any of the values.
+	if len(args) < 2 {
+		return nil, fmt.Errorf("CONTAINS_ANY operator requires at least 2 arguments (field and at least one value)")
+	}
+
+	return d.buildQueryWithBuilder(d.GetInQueryBuilder(), args...)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) VisitContainsAll(args ...string) (*bson.D, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("CONTAINS_ALL operator requires at least 2 arguments (field and at least one value)")
+	}
+
+	return d.buildQueryWithBuilder(d.GetContainsAllQueryBuilder(), args...)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetContainsAllQueryBuilder() func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		// https://www.mongodb.com/docs/atlas/atlas-search/compound/
+		// Every value must be present in the array, so we need an equals for each value.
+		musts := make([]*bson.D, 0, len(args)-1)
+
+		for _, v := range args[1:] {
+			eq, err := d.buildEqualsQuery(args[0], v)
+			if err != nil {
+				return nil, err
+			}
+
+			musts = append(musts, eq)
+		}
+
+		if d.Flatten && len(musts) == 1 {
+			return d.ApplyFieldScore(args[0], musts[0])
+		}
+
+		return d.ApplyFieldScore(args[0], &bson.D{
+			{"compound", bson.D{
+				{"must", musts},
+			}},
+		})
+	}
+}
+
+func (d DefaultAtlasSearchQueryBuilder) buildEqualsQuery(first, second string) (*bson.D, error) {
+	value, err := d.ConvertValue(first, second)
+	if err != nil {
+		return nil, err
+	}
 
 	return &bson.D{
-		{"wildcard", bson.D{
-			{"path", path},
-			{"query", d.ProcessWildcardString(second)},
-			{"allowAnalyzedField", true},
+		{"equals", bson.D{
+			{"path", first},
+			{"value", value},
 		}},
 	}, nil
 }
 
-func (d DefaultAtlasSearchQueryBuilder) VisitContains(_, _ string) (*bson.D, error) {
-	return nil, fmt.Errorf("CONTAINS operator not yet implemented for Atlas Search")
+func (d DefaultAtlasSearchQueryBuilder) VisitIsNull(first string) (*bson.D, error) {
+	switch s := d.NullSemantics.ForField(first); s {
+	case epsearchast.DefaultNullSemantics, epsearchast.NullOrMissingOrEmptyArray:
+	default:
+		return nil, fmt.Errorf("null semantics %s are not supported in Atlas Search for field [%s]", s, first)
+	}
+
+	return d.buildQueryWithBuilder(d.GetMustNotExistQueryBuilder(), first)
+}
+
+func (d DefaultAtlasSearchQueryBuilder) GetMustNotExistQueryBuilder() func(args ...string) (*bson.D, error) {
+	return func(args ...string) (*bson.D, error) {
+		// https://www.mongodb.com/docs/atlas/atlas-search/exists/
+		return &bson.D{
+			{"compound", bson.D{
+				{"mustNot", []*bson.D{
+					{
+						{"exists", bson.D{
+							{"path", args[0]},
+						}},
+					},
+				}},
+			}},
+		}, nil
+	}
+}
+
+func (d DefaultAtlasSearchQueryBuilder) buildQueryWithBuilder(b func(args ...string) (*bson.D, error), args ...string) (*bson.D, error) {
+	embeddedQuery, ok, err := d.processEmbeddedDocumentFieldToQuery(b, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return embeddedQuery, nil
+	}
+
+	return b(args...)
+}
+
+// processEmbeddedDocumentFieldToQuery converts a request for a field that is in an array of documents into a compound query, wrapped in an embeddedDocument operator,
+// so that all the predicates must match the same element of the array, e.g., eq(variants[red].sku,foo) becomes eq(variants.sku,foo):eq(variants.colour,red) on a single element.
+// Subqueries with ForceEQ identify the element and don't contribute to the score, so they are placed in compound.filter, which also lets PostVisitAnd group predicates on the same element.
+// builder takes the arguments and returns the subquery, it changes whether we need to build an equals, range, wildcard or other Atlas Search operator.
+func (d DefaultAtlasSearchQueryBuilder) processEmbeddedDocumentFieldToQuery(builder func(args ...string) (*bson.D, error), args ...string) (*bson.D, bool, error) {
+	if len(args) < 1 {
+		return nil, false, fmt.Errorf("no arguments provided")
+	}
+
+	var embeddedQuery *bson.D = nil
+
+	numMatches := 0
+	for _, f := range d.getEmbeddedDocumentFields() {
+		searchField := args[0]
+		if !f.pattern.MatchString(searchField) {
+			continue
+		}
+
+		numMatches++
+
+		v := f.replacement
+
+		groupMap := extractNamedGroupsFromSearchField(f.pattern, searchField)
+
+		subQueryNames := make([]string, 0, len(v.Subqueries))
+		for sqFieldName := range v.Subqueries {
+			subQueryNames = append(subQueryNames, sqFieldName)
+		}
+
+		sort.Strings(subQueryNames)
+
+		var musts, filters []*bson.D
+
+		for _, sqFieldName := range subQueryNames {
+			sqFieldValue := v.Subqueries[sqFieldName]
+
+			sqField, sqValue := applyPatternGroupsToFieldNameAndValue(sqFieldName, sqFieldValue.Value, groupMap)
+
+			replacedArgs := buildReplacementArgs(args, sqField, sqValue)
+
+			if sqFieldValue.ForceEQ {
+				if len(replacedArgs) < 2 {
+					// is_null(variants[red].sku) doesn't have a second argument to replace with $value, so we use the value directly.
+					replacedArgs = append(replacedArgs, sqValue)
+				}
+
+				q, err := d.buildEqualsQuery(replacedArgs[0], replacedArgs[1])
+				if err != nil {
+					return nil, false, err
+				}
+
+				filters = append(filters, q)
+				continue
+			}
+
+			q, err := builder(replacedArgs...)
+			if err != nil {
+				return nil, false, err
+			}
+
+			musts = append(musts, q)
+		}
+
+		if len(musts) == 0 {
+			musts, filters = filters, nil
+		}
+
+		embeddedQuery = buildEmbeddedDocumentQuery(v.Path, musts, filters)
+	}
+
+	if numMatches > 1 {
+		return nil, false, fmt.Errorf("found more than one embedded document field for %s", args[0])
+	}
+
+	if numMatches == 0 {
+		return nil, false, nil
+	}
+
+	return embeddedQuery, true, nil
+}
+
+func buildReplacementArgs(args []string, sqField string, sqValue string) []string {
+	replacedArgs := make([]string, len(args))
+
+	// Don't allow the field name to be replaced with $value as it can open up injection attacks.
+	replacedArgs[0] = sqField
+
+	for i := 1; i < len(args); i++ {
+		replacedArgs[i] = strings.ReplaceAll(sqValue, `$value`, args[i])
+	}
+	return replacedArgs
 }
 
-func (d DefaultAtlasSearchQueryBuilder) VisitContainsAny(_ ...string) (*bson.D, error) {
-	return nil, fmt.Errorf("CONTAINS_ANY operator not yet implemented for Atlas Search")
+func applyPatternGroupsToFieldNameAndValue(sqFieldName string, sqFieldValue string, groupMap map[string]string) (string, string) {
+	sqValue := sqFieldValue
+	sqField := sqFieldName
+
+	groupKeys := make([]string, 0, len(groupMap))
+	for k := range groupMap {
+		groupKeys = append(groupKeys, k)
+	}
+
+	sortByDecreasingLength(groupKeys)
+
+	for _, k := range groupKeys {
+		if k == "" {
+			continue
+		}
+
+		sqField = strings.ReplaceAll(sqField, "$"+k, groupMap[k])
+		sqValue = strings.ReplaceAll(sqValue, "$"+k, groupMap[k])
+	}
+	return sqField, sqValue
 }
 
-func (d DefaultAtlasSearchQueryBuilder) VisitContainsAll(_ ...string) (*bson.D, error) {
-	return nil, fmt.Errorf("CONTAINS_ALL operator not yet implemented for Atlas Search")
+func extractNamedGroupsFromSearchField(p *regexp.Regexp, first string) map[string]string {
+	res := p.FindStringSubmatch(first)
+
+	groupMap := make(map[string]string)
+	for i, name := range p.SubexpNames() {
+		if i != 0 && name != "" {
+			groupMap[name] = res[i]
+		}
+	}
+	return groupMap
 }
 
-func (d DefaultAtlasSearchQueryBuilder) VisitIsNull(_ string) (*bson.D, error) {
-	return nil, fmt.Errorf("IS_NULL operator not yet implemented for Atlas Search")
+func sortByDecreasingLength(groupKeys []string) {
+	sort.Slice(groupKeys, func(i, j int) bool {
+		if len(groupKeys[i]) != len(groupKeys[j]) {
+			return len(groupKeys[i]) > len(groupKeys[j])
+		}
+		return groupKeys[i] < groupKeys[j]
+	})
 }
 
 // ProcessWildcardString processes wildcard strings for Atlas Search wildcard queries
@@ -218,3 +1011,123 @@ func (d DefaultAtlasSearchQueryBuilder) getWildcardPath(fieldName string, caseSe
 	// Otherwise, return simple field name
 	return fieldName
 }
+
+// ValidateStringField returns an error if the field has been configured with a type other than a string, for operators that only make sense on strings.
+func (d DefaultAtlasSearchQueryBuilder) ValidateStringField(operator string, fieldName string) error {
+	if d.ObjectIdFields[fieldName] {
+		return fmt.Errorf("%s() operator is only supported for string fields, and [%s] is an objectId", operator, fieldName)
+	}
+
+	if v, ok := d.FieldTypes[fieldName]; ok {
+		if v != epsearchast.String {
+			return fmt.Errorf("%s() operator is only supported for string fields, and [%s] is not a string", operator, fieldName)
+		}
+	}
+
+	return nil
+}
+
+// ConvertValue converts the value to the type configured for the field in FieldTypes or ObjectIdFields, returning an error if the value can't be converted.
+func (d DefaultAtlasSearchQueryBuilder) ConvertValue(fieldName string, v string) (interface{}, error) {
+	if d.ObjectIdFields[fieldName] {
+		oid, err := bson.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for objectId: `%v`", v)
+		}
+		return oid, nil
+	}
+
+	if fieldType, ok := d.FieldTypes[fieldName]; ok {
+		return epsearchast.Convert(fieldType, v)
+	}
+
+	return v, nil
+}
+
+// ConvertValues converts all the values with ConvertValue.
+func (d DefaultAtlasSearchQueryBuilder) ConvertValues(fieldName string, v ...string) ([]interface{}, error) {
+	newV := make([]interface{}, 0, len(v))
+
+	for idx, value := range v {
+		c, err := d.ConvertValue(fieldName, value)
+		if err != nil {
+			return nil, fmt.Errorf("error converting value at index %v: %w", idx, err)
+		}
+
+		newV = append(newV, c)
+	}
+
+	return newV, nil
+}
+
+// ApplyFieldScore adds the score options for the field from FieldScores to the operator in q.
+func (d DefaultAtlasSearchQueryBuilder) ApplyFieldScore(fieldName string, q *bson.D) (*bson.D, error) {
+	config, ok := d.FieldScores[fieldName]
+	if !ok || config == nil {
+		return q, nil
+	}
+
+	var score bson.D
+
+	switch {
+	case config.Boost != 0 && config.Constant != 0:
+		return nil, fmt.Errorf("only one of boost or constant can be set for the score of field [%s]", fieldName)
+	case config.Boost != 0:
+		score = bson.D{{"boost", bson.D{{"value", config.Boost}}}}
+	case config.Constant != 0:
+		score = bson.D{{"constant", bson.D{{"value", config.Constant}}}}
+	default:
+		return q, nil
+	}
+
+	if len(*q) != 1 {
+		return nil, fmt.Errorf("expected a single operator when applying score for field [%s], got %d", fieldName, len(*q))
+	}
+
+	operator, ok := (*q)[0].Value.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("unexpected operator type %T when applying score for field [%s]", (*q)[0].Value, fieldName)
+	}
+
+	(*q)[0].Value = append(operator, bson.E{Key: "score", Value: score})
+
+	return q, nil
+}
+
+// scoringOperators are the operators that contribute to relevance and belong in compound.must even with UseCompoundFilter.
+var scoringOperators = map[string]bool{
+	"text":         true,
+	"phrase":       true,
+	"autocomplete": true,
+}
+
+// isScoringClause returns true if the clause contains an operator that should contribute to scoring, either because it's a text operator,
+// or a score has been explicitly set.
+func isScoringClause(q bson.D) bool {
+	for _, e := range q {
+		if scoringOperators[e.Key] || e.Key == "score" {
+			return true
+		}
+
+		switch e.Key {
+		case "filter", "mustNot":
+			// Clauses in these never contribute to the score.
+			continue
+		}
+
+		switch v := e.Value.(type) {
+		case bson.D:
+			if isScoringClause(v) {
+				return true
+			}
+		case []*bson.D:
+			for _, c := range v {
+				if c != nil && isScoringClause(*c) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -37,6 +37,14 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 			"text_field":            "Developers like IDEs",
 			"uuid_field":            "550e8400-e29b-41d4-a716-446655440001",
 			"date_field":            time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			"number_field":          int64(5),
+			"boolean_field":         true,
+			"object_id_field":       mustObjectID("650000000000000000000001"),
+			"name_field":            "Running Shoes",
+			"variants": []bson.M{
+				{"colour": "red", "sku": "shoe-1"},
+				{"colour": "blue", "sku": "shoe-2"},
+			},
 		},
 		bson.M{
 			"string_field":          "test2 test2",
@@ -45,13 +53,24 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 			"text_field":            "I like Development Environments",
 			"uuid_field":            "550e8400-e29b-41d4-a716-446655440002",
 			"date_field":            time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC),
+			"number_field":          10.5,
+			"boolean_field":         false,
+			"object_id_field":       mustObjectID("650000000000000000000002"),
+			"name_field":            "Trail Running Jacket",
+			"variants": []bson.M{
+				{"colour": "blue", "sku": "shoe-1"},
+			},
 		},
 		bson.M{
-			"string_field": "test3 test3",
-			"array_field":  []string{"c c"},
-			"text_field":   "Vim is the best",
-			"uuid_field":   "550e8400-e29b-41d4-a716-446655440003",
-			"date_field":   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			"string_field":    "test3 test3",
+			"array_field":     []string{"c c"},
+			"text_field":      "Vim is the best",
+			"uuid_field":      "550e8400-e29b-41d4-a716-446655440003",
+			"date_field":      time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			"number_field":    int64(100),
+			"boolean_field":   true,
+			"object_id_field": mustObjectID("650000000000000000000003"),
+			"name_field":      "Walking Boots",
 		},
 	}
 
@@ -396,6 +415,219 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 					}`,
 			count: 0,
 		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS",
+						"args": ["array_field", "a a"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS",
+						"args": ["array_field", "c c"]
+					}`,
+			count: 2,
+		},
+		{
+			// Test CONTAINS is exact, and doesn't match individual tokens
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS",
+						"args": ["array_field", "c"]
+					}`,
+			count: 0,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS_ANY",
+						"args": ["array_field", "a a", "d d"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS_ANY",
+						"args": ["array_field", "z z"]
+					}`,
+			count: 0,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS_ALL",
+						"args": ["array_field", "c c", "d d"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS_ALL",
+						"args": ["array_field", "c c"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "CONTAINS_ALL",
+						"args": ["array_field", "a a", "c c"]
+					}`,
+			count: 0,
+		},
+		{
+			// Test IS_NULL matches both the explicit null and the missing field
+			//language=JSON
+			filter: `{
+						"type": "IS_NULL",
+						"args": ["nullable_string_field"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IS_NULL",
+						"args": ["string_field"]
+					}`,
+			count: 0,
+		},
+		{
+			// Test GT on a number field compares numerically, lexicographically "10.5" < "5"
+			//language=JSON
+			filter: `{
+						"type": "GT",
+						"args": ["number_field", "6"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "LE",
+						"args": ["number_field", "10.5"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["number_field", "100"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IN",
+						"args": ["number_field", "5", "100"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["boolean_field", "true"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["boolean_field", "false"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "GE",
+						"args": ["date_field", "2024-06-15T00:00:00Z"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "LT",
+						"args": ["date_field", "2024-06-15"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["object_id_field", "650000000000000000000002"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IN",
+						"args": ["object_id_field", "650000000000000000000001", "650000000000000000000003"]
+					}`,
+			count: 2,
+		},
+		{
+			// Test text() uses the autocomplete operator configured for the field
+			//language=JSON
+			filter: `{
+						"type": "TEXT",
+						"args": ["name_field", "runn"]
+					}`,
+			count: 2,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "TEXT",
+						"args": ["name_field", "boo"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["variants[red].sku", "shoe-1"]
+					}`,
+			count: 1,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["variants[blue].sku", "shoe-1"]
+					}`,
+			count: 1,
+		},
+		{
+			// Test both predicates must match the same element, the first document has a red variant and a shoe-2 variant, but not both together
+			//language=JSON
+			filter: `{
+						"type": "EQ",
+						"args": ["variants[red].sku", "shoe-2"]
+					}`,
+			count: 0,
+		},
+		{
+			//language=JSON
+			filter: `{
+						"type": "IN",
+						"args": ["variants[blue].sku", "shoe-1", "shoe-2"]
+					}`,
+			count: 2,
+		},
 	}
 
 	collection := SetupAtlasDB(t, ctx, atlasClient)
@@ -437,9 +669,15 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 					// Token type for exact EQ/IN matching
 					bson.D{{"type", "token"}},
 				}},
-				{"array_field", bson.D{
-					// String supports (moreLikeThis, phrase, queryString, regex, span, text, wildcard)
-					{"type", "string"},
+				{"array_field", bson.A{
+					bson.D{
+						// String supports (moreLikeThis, phrase, queryString, regex, span, text, wildcard)
+						{"type", "string"},
+					},
+					bson.D{
+						// Token supports (equals, facet, in, range) and is used for CONTAINS/CONTAINS_ANY/CONTAINS_ALL
+						{"type", "token"},
+					},
 				}},
 				{"nullable_string_field", bson.A{
 					// String type with standard analyzer (for TEXT queries) and keyword multi-analyzers (for LIKE/ILIKE)
@@ -481,6 +719,28 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 				{"date_field", bson.D{
 					{"type", "date"},
 				}},
+				{"number_field", bson.D{
+					{"type", "number"},
+				}},
+				{"boolean_field", bson.D{
+					{"type", "boolean"},
+				}},
+				{"object_id_field", bson.D{
+					{"type", "objectId"},
+				}},
+				// name_field: indexed for type-ahead with the autocomplete operator
+				{"name_field", bson.D{
+					{"type", "autocomplete"},
+				}},
+				// variants: indexed as embedded documents so predicates can be matched against the same element
+				{"variants", bson.D{
+					{"type", "embeddedDocuments"},
+					{"dynamic", false},
+					{"fields", bson.D{
+						{"colour", bson.D{{"type", "token"}}},
+						{"sku", bson.D{{"type", "token"}}},
+					}},
+				}},
 			}},
 		}},
 	}
@@ -532,6 +792,26 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 						WildcardCaseSensitive:   "caseSensitiveKeywordAnalyzer",
 					},
 				},
+				FieldTypes: map[string]epsearchast.FieldType{
+					"date_field":    epsearchast.Date,
+					"number_field":  epsearchast.Float64,
+					"boolean_field": epsearchast.Boolean,
+				},
+				ObjectIdFields: map[string]bool{
+					"object_id_field": true,
+				},
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"name_field": {Operator: TextOperatorAutocomplete},
+				},
+				EmbeddedDocumentFieldToQuery: map[string]EmbeddedDocumentReplacement{
+					`^variants\[(?P<colour>[a-z]+)\]\.(?P<attr>[a-z]+)$`: {
+						Path: "variants",
+						Subqueries: map[string]Replacement{
+							"variants.colour": {Value: "$colour", ForceEQ: true},
+							"variants.$attr":  {Value: "$value"},
+						},
+					},
+				},
 			}
 
 			// Create Query Object
@@ -584,6 +864,14 @@ func TestSmokeTestAtlasSearchWithFilters(t *testing.T) {
 
 }
 
+func mustObjectID(hex string) bson.ObjectID {
+	oid, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		panic(err)
+	}
+	return oid
+}
+
 func SetupAtlasDB(t *testing.T, ctx context.Context, atlasClient *mongo.Client) *mongo.Collection {
 	db := atlasClient.Database("testdb")
 
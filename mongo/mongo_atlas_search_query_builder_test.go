@@ -0,0 +1,824 @@
+package astmongo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAtlasSearchArrayOperatorsGeneratesCorrectQuery(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		filter       string
+		expectedJson string
+	}{
+		{
+			name: "contains",
+			//language=JSON
+			filter:       `{"type": "CONTAINS", "args": ["tags", "red"]}`,
+			expectedJson: `{"equals":{"path":"tags","value":"red"}}`,
+		},
+		{
+			name: "contains_any",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ANY", "args": ["tags", "red", "blue"]}`,
+			expectedJson: `{"in":{"path":"tags","value":["red","blue"]}}`,
+		},
+		{
+			name: "contains_all",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]}`,
+			expectedJson: `{"compound":{"must":[{"equals":{"path":"tags","value":"red"}},{"equals":{"path":"tags","value":"blue"}}]}}`,
+		},
+		{
+			name: "is_null",
+			//language=JSON
+			filter:       `{"type": "IS_NULL", "args": ["tags"]}`,
+			expectedJson: `{"compound":{"mustNot":[{"exists":{"path":"tags"}}]}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{}
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchIsNullGeneratesErrorWithUnsupportedNullSemantics(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["tags"]}`)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			Default: epsearchast.MissingOnly,
+		},
+	}
+
+	// Execute SUT
+	_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.ErrorContains(t, err, "are not supported in Atlas Search")
+}
+
+func TestAtlasSearchMustValidatePanicsWithUnsupportedNullSemantics(t *testing.T) {
+	// Fixture Setup
+	qb := DefaultAtlasSearchQueryBuilder{
+		NullSemantics: epsearchast.NullSemanticsConfig{
+			Default: epsearchast.NullOrMissing,
+		},
+	}
+
+	// Execute SUT & Verification
+	require.PanicsWithValue(t, "Invalid null semantics: null semantics null_or_missing are not supported in Atlas Search", func() {
+		qb.MustValidate()
+	})
+}
+
+func TestAtlasSearchTypedValuesGeneratesCorrectQuery(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		filter       string
+		expectedJson string
+	}{
+		{
+			name: "int64 range",
+			//language=JSON
+			filter:       `{"type": "GT", "args": ["quantity", "10"]}`,
+			expectedJson: `{"range":{"path":"quantity","gt":{"$numberLong":"10"}}}`,
+		},
+		{
+			name: "float64 range",
+			//language=JSON
+			filter:       `{"type": "LE", "args": ["price", "10.5"]}`,
+			expectedJson: `{"range":{"path":"price","lte":{"$numberDouble":"10.5"}}}`,
+		},
+		{
+			name: "boolean equals",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["active", "true"]}`,
+			expectedJson: `{"equals":{"path":"active","value":true}}`,
+		},
+		{
+			name: "date range",
+			//language=JSON
+			filter:       `{"type": "GE", "args": ["created_at", "2024-01-01"]}`,
+			expectedJson: `{"range":{"path":"created_at","gte":{"$date":{"$numberLong":"1704067200000"}}}}`,
+		},
+		{
+			name: "objectId in",
+			//language=JSON
+			filter:       `{"type": "IN", "args": ["parent_id", "650000000000000000000001", "650000000000000000000002"]}`,
+			expectedJson: `{"in":{"path":"parent_id","value":[{"$oid":"650000000000000000000001"},{"$oid":"650000000000000000000002"}]}}`,
+		},
+		{
+			name: "int64 contains_any",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ANY", "args": ["quantity", "1", "2"]}`,
+			expectedJson: `{"in":{"path":"quantity","value":[{"$numberLong":"1"},{"$numberLong":"2"}]}}`,
+		},
+		{
+			name: "untyped string",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["status", "true"]}`,
+			expectedJson: `{"equals":{"path":"status","value":"true"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+				FieldTypes: map[string]epsearchast.FieldType{
+					"quantity":   epsearchast.Int64,
+					"price":      epsearchast.Float64,
+					"active":     epsearchast.Boolean,
+					"created_at": epsearchast.Date,
+				},
+				ObjectIdFields: map[string]bool{
+					"parent_id": true,
+				},
+			}
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchTypedValuesGeneratesErrorWhenValueCantBeConverted(t *testing.T) {
+	var testCases = []struct {
+		filter        string
+		expectedError string
+	}{
+		//language=JSON
+		{`{"type": "GT", "args": ["quantity", "ten"]}`, "invalid value for int64"},
+		//language=JSON
+		{`{"type": "EQ", "args": ["parent_id", "not-an-object-id"]}`, "invalid value for objectId"},
+		//language=JSON
+		{`{"type": "LIKE", "args": ["quantity", "1*"]}`, "like() operator is only supported for string fields"},
+		//language=JSON
+		{`{"type": "ILIKE", "args": ["parent_id", "65*"]}`, "ilike() operator is only supported for string fields"},
+		//language=JSON
+		{`{"type": "TEXT", "args": ["quantity", "one"]}`, "text() operator is only supported for string fields"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+				FieldTypes: map[string]epsearchast.FieldType{
+					"quantity": epsearchast.Int64,
+				},
+				ObjectIdFields: map[string]bool{
+					"parent_id": true,
+				},
+			}
+
+			// Execute SUT
+			_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestAtlasSearchAndWithCompoundFilterGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	astJson := `{
+		"type": "AND",
+		"children": [
+			{"type": "TEXT", "args": ["name", "shoes"]},
+			{"type": "EQ", "args": ["status", "live"]},
+			{
+				"type": "OR",
+				"children": [
+					{"type": "GT", "args": ["price", "10"]},
+					{"type": "IS_NULL", "args": ["price"]}
+				]
+			}
+		]
+	}`
+
+	astNode, err := epsearchast.GetAst(astJson)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		UseCompoundFilter: true,
+	}
+
+	expectedJson := `{"compound":{"must":[{"text":{"query":"shoes","path":"name"}}],"filter":[{"equals":{"path":"status","value":"live"}},{"compound":{"should":[{"range":{"path":"price","gt":"10"}},{"compound":{"mustNot":[{"exists":{"path":"price"}}]}}],"minimumShouldMatch":{"$numberInt":"1"}}}]}}`
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(doc))
+}
+
+func TestAtlasSearchAndWithCompoundFilterAndOnlyFiltersGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	astJson := `{
+		"type": "AND",
+		"children": [
+			{"type": "EQ", "args": ["status", "live"]},
+			{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]}
+		]
+	}`
+
+	astNode, err := epsearchast.GetAst(astJson)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		UseCompoundFilter: true,
+	}
+
+	expectedJson := `{"compound":{"filter":[{"equals":{"path":"status","value":"live"}},{"compound":{"must":[{"equals":{"path":"tags","value":"red"}},{"equals":{"path":"tags","value":"blue"}}]}}]}}`
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(doc))
+}
+
+func TestAtlasSearchFieldScoresGeneratesCorrectQuery(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	astJson := `{
+		"type": "AND",
+		"children": [
+			{"type": "TEXT", "args": ["name", "shoes"]},
+			{"type": "EQ", "args": ["featured", "true"]},
+			{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]},
+			{"type": "EQ", "args": ["status", "live"]}
+		]
+	}`
+
+	astNode, err := epsearchast.GetAst(astJson)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		UseCompoundFilter: true,
+		FieldScores: map[string]*FieldScore{
+			"name":     {Boost: 3},
+			"featured": {Constant: 5},
+			"tags":     {Boost: 0.5},
+		},
+	}
+
+	expectedJson := `{"compound":{"must":[` +
+		`{"text":{"query":"shoes","path":"name","score":{"boost":{"value":{"$numberDouble":"3.0"}}}}},` +
+		`{"equals":{"path":"featured","value":"true","score":{"constant":{"value":{"$numberDouble":"5.0"}}}}},` +
+		`{"compound":{"must":[{"equals":{"path":"tags","value":"red"}},{"equals":{"path":"tags","value":"blue"}}],"score":{"boost":{"value":{"$numberDouble":"0.5"}}}}}` +
+		`],"filter":[{"equals":{"path":"status","value":"live"}}]}}`
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(doc))
+}
+
+func TestAtlasSearchFieldScoresGeneratesErrorWhenBoostAndConstantSet(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "EQ", "args": ["status", "live"]}`)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		FieldScores: map[string]*FieldScore{
+			"status": {Boost: 2, Constant: 1},
+		},
+	}
+
+	// Execute SUT
+	_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.ErrorContains(t, err, "only one of boost or constant can be set")
+}
+
+func TestAtlasSearchEmbeddedDocumentFieldGeneratesCorrectQuery(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		filter       string
+		expectedJson string
+	}{
+		{
+			name: "eq",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["variants[red].sku", "shoe-1"]}`,
+			expectedJson: `{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"equals":{"path":"variants.sku","value":"shoe-1"}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}}`,
+		},
+		{
+			name: "in",
+			//language=JSON
+			filter:       `{"type": "IN", "args": ["variants[red].sku", "shoe-1", "shoe-2"]}`,
+			expectedJson: `{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"in":{"path":"variants.sku","value":["shoe-1","shoe-2"]}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}}`,
+		},
+		{
+			name: "typed range",
+			//language=JSON
+			filter:       `{"type": "GT", "args": ["variants[red].stock", "5"]}`,
+			expectedJson: `{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"range":{"path":"variants.stock","gt":{"$numberLong":"5"}}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}}`,
+		},
+		{
+			name: "is_null",
+			//language=JSON
+			filter:       `{"type": "IS_NULL", "args": ["variants[red].sku"]}`,
+			expectedJson: `{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"compound":{"mustNot":[{"exists":{"path":"variants.sku"}}]}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}}`,
+		},
+		{
+			name: "unmatched field",
+			//language=JSON
+			filter:       `{"type": "EQ", "args": ["sku", "shoe-1"]}`,
+			expectedJson: `{"equals":{"path":"sku","value":"shoe-1"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			qb := DefaultAtlasSearchQueryBuilder{
+				FieldTypes: map[string]epsearchast.FieldType{
+					"variants.stock": epsearchast.Int64,
+				},
+				EmbeddedDocumentFieldToQuery: map[string]EmbeddedDocumentReplacement{
+					`^variants\[(?P<colour>[a-z]+)\]\.(?P<attr>[a-z]+)$`: {
+						Path: "variants",
+						Subqueries: map[string]Replacement{
+							"variants.colour": {Value: "$colour", ForceEQ: true},
+							"variants.$attr":  {Value: "$value"},
+						},
+					},
+				},
+			}
+
+			qb.MustValidate()
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[bson.D](qb))
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchEmbeddedDocumentFieldGroupsConjunctions(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		filter       string
+		flatten      bool
+		expectedJson string
+	}{
+		{
+			name:         "same path",
+			filter:       `eq(items.sku,a):gt(items.qty,2)`,
+			expectedJson: `{"embeddedDocument":{"path":"items","operator":{"compound":{"must":[{"equals":{"path":"items.sku","value":"a"}},{"range":{"path":"items.qty","gt":"2"}}]}}}}`,
+		},
+		{
+			name:         "same element",
+			filter:       `eq(variants[red].sku,shoe-1):gt(variants[red].stock,0):eq(name,shoe)`,
+			expectedJson: `{"compound":{"must":[{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"equals":{"path":"variants.sku","value":"shoe-1"}},{"range":{"path":"variants.stock","gt":"0"}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}},{"equals":{"path":"name","value":"shoe"}}]}}`,
+		},
+		{
+			name:         "different elements",
+			filter:       `eq(variants[red].sku,shoe-1):eq(variants[blue].sku,shoe-2)`,
+			expectedJson: `{"compound":{"must":[{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"equals":{"path":"variants.sku","value":"shoe-1"}}],"filter":[{"equals":{"path":"variants.colour","value":"red"}}]}}}},{"embeddedDocument":{"path":"variants","operator":{"compound":{"must":[{"equals":{"path":"variants.sku","value":"shoe-2"}}],"filter":[{"equals":{"path":"variants.colour","value":"blue"}}]}}}}]}}`,
+		},
+		{
+			name:         "nested conjunctions with flatten",
+			filter:       `(eq(items.sku,a):eq(name,shoe)):gt(items.qty,2)`,
+			flatten:      true,
+			expectedJson: `{"compound":{"must":[{"embeddedDocument":{"path":"items","operator":{"compound":{"must":[{"equals":{"path":"items.sku","value":"a"}},{"range":{"path":"items.qty","gt":"2"}}]}}}},{"equals":{"path":"name","value":"shoe"}}]}}`,
+		},
+		{
+			name:         "disjunction",
+			filter:       `eq(items.sku,a)|gt(items.qty,2)`,
+			expectedJson: `{"compound":{"should":[{"embeddedDocument":{"path":"items","operator":{"compound":{"must":[{"equals":{"path":"items.sku","value":"a"}}]}}}},{"embeddedDocument":{"path":"items","operator":{"compound":{"must":[{"range":{"path":"items.qty","gt":"2"}}]}}}}],"minimumShouldMatch":1}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.ParseFilter(tc.filter)
+			require.NoError(t, err)
+
+			qb := DefaultAtlasSearchQueryBuilder{
+				Flatten: tc.flatten,
+				EmbeddedDocumentFieldToQuery: map[string]EmbeddedDocumentReplacement{
+					`^items\.(?P<attr>[a-z]+)$`: {
+						Path:       "items",
+						Subqueries: map[string]Replacement{"items.$attr": {Value: "$value"}},
+					},
+					`^variants\[(?P<colour>[a-z]+)\]\.(?P<attr>[a-z]+)$`: {
+						Path: "variants",
+						Subqueries: map[string]Replacement{
+							"variants.colour": {Value: "$colour", ForceEQ: true},
+							"variants.$attr":  {Value: "$value"},
+						},
+					},
+				},
+			}.MustCompile()
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[bson.D](qb))
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, false, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchEmbeddedDocumentFieldGeneratesErrorWhenMultiplePatternsMatch(t *testing.T) {
+	//Fixture Setup
+	astNode, err := epsearchast.GetAst(`{"type": "EQ", "args": ["variants[red].sku", "shoe-1"]}`)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		EmbeddedDocumentFieldToQuery: map[string]EmbeddedDocumentReplacement{
+			`^variants\[(?P<colour>[a-z]+)\]\.sku$`: {
+				Path:       "variants",
+				Subqueries: map[string]Replacement{"variants.sku": {Value: "$value"}},
+			},
+			`^variants\[red\]\.(?P<attr>[a-z]+)$`: {
+				Path:       "variants",
+				Subqueries: map[string]Replacement{"variants.$attr": {Value: "$value"}},
+			},
+		},
+	}
+
+	// Execute SUT
+	_, err = epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.ErrorContains(t, err, "found more than one embedded document field")
+}
+
+func TestAtlasSearchEmbeddedDocumentFieldMustValidatePanicsWithInvalidConfiguration(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		config map[string]EmbeddedDocumentReplacement
+	}{
+		{
+			name: "not anchored at start",
+			config: map[string]EmbeddedDocumentReplacement{
+				`variants\.sku$`: {Path: "variants", Subqueries: map[string]Replacement{"variants.sku": {Value: "$value"}}},
+			},
+		},
+		{
+			name: "empty pattern",
+			config: map[string]EmbeddedDocumentReplacement{
+				"": {Path: "variants", Subqueries: map[string]Replacement{"variants.sku": {Value: "$value"}}},
+			},
+		},
+		{
+			name: "not anchored at end",
+			config: map[string]EmbeddedDocumentReplacement{
+				`^variants\.sku`: {Path: "variants", Subqueries: map[string]Replacement{"variants.sku": {Value: "$value"}}},
+			},
+		},
+		{
+			name: "missing path",
+			config: map[string]EmbeddedDocumentReplacement{
+				`^variants\.sku$`: {Subqueries: map[string]Replacement{"variants.sku": {Value: "$value"}}},
+			},
+		},
+		{
+			name: "subquery outside path",
+			config: map[string]EmbeddedDocumentReplacement{
+				`^variants\.sku$`: {Path: "variants", Subqueries: map[string]Replacement{"sku": {Value: "$value"}}},
+			},
+		},
+		{
+			name: "unknown template",
+			config: map[string]EmbeddedDocumentReplacement{
+				`^variants\.sku$`: {Path: "variants", Subqueries: map[string]Replacement{"variants.sku": {Value: "$colour"}}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			qb := DefaultAtlasSearchQueryBuilder{
+				EmbeddedDocumentFieldToQuery: tc.config,
+			}
+
+			// Execute SUT & Verification
+			require.Panics(t, qb.MustValidate)
+		})
+	}
+}
+
+func TestAtlasSearchTextStrategiesGeneratesCorrectQuery(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		field        string
+		expectedJson string
+	}{
+		{
+			name:         "default strategy",
+			field:        "description",
+			expectedJson: `{"text":{"query":"red shoes","path":"description","fuzzy":{"maxEdits":{"$numberInt":"1"},"prefixLength":{"$numberInt":"0"}}}}`,
+		},
+		{
+			name:         "fuzzy text",
+			field:        "name",
+			expectedJson: `{"text":{"query":"red shoes","path":"name","fuzzy":{"maxEdits":{"$numberInt":"2"},"prefixLength":{"$numberInt":"3"}}}}`,
+		},
+		{
+			name:         "phrase with slop and synonyms",
+			field:        "title",
+			expectedJson: `{"phrase":{"query":"red shoes","path":"title","slop":{"$numberInt":"2"},"synonyms":"colours"}}`,
+		},
+		{
+			name:         "autocomplete",
+			field:        "search_as_you_type",
+			expectedJson: `{"autocomplete":{"query":"red shoes","path":"search_as_you_type"}}`,
+		},
+		{
+			name:         "plain text",
+			field:        "summary",
+			expectedJson: `{"text":{"query":"red shoes","path":"summary"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(fmt.Sprintf(`{"type": "TEXT", "args": ["%s", "red shoes"]}`, tc.field))
+			require.NoError(t, err)
+
+			qb := DefaultAtlasSearchQueryBuilder{
+				DefaultTextStrategy: TextStrategy{
+					FuzzyMaxEdits: 1,
+				},
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"name":               {FuzzyMaxEdits: 2, FuzzyPrefixLength: 3},
+					"title":              {Operator: TextOperatorPhrase, Slop: 2, Synonyms: "colours"},
+					"search_as_you_type": {Operator: TextOperatorAutocomplete},
+					"summary":            {Operator: TextOperatorText},
+				},
+			}
+
+			qb.MustValidate()
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[bson.D](qb))
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchTextStrategiesGeneratesErrorWithInvalidStrategy(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		strategy      TextStrategy
+		expectedError string
+	}{
+		{"unknown operator", TextStrategy{Operator: "regex"}, "unknown operator regex"},
+		{"too many edits", TextStrategy{FuzzyMaxEdits: 3}, "fuzzy max edits must be 1 or 2"},
+		{"prefix without fuzzy", TextStrategy{FuzzyPrefixLength: 2}, "fuzzy prefix length requires fuzzy max edits"},
+		{"fuzzy phrase", TextStrategy{Operator: TextOperatorPhrase, FuzzyMaxEdits: 1}, "fuzzy matching is not supported by the phrase operator"},
+		{"slop on text", TextStrategy{Slop: 1}, "slop is only supported by the phrase operator"},
+		{"autocomplete synonyms", TextStrategy{Operator: TextOperatorAutocomplete, Synonyms: "colours"}, "synonyms are not supported by the autocomplete operator"},
+		{"fuzzy synonyms", TextStrategy{FuzzyMaxEdits: 1, Synonyms: "colours"}, "synonyms can't be combined with fuzzy matching"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(`{"type": "TEXT", "args": ["name", "shoes"]}`)
+			require.NoError(t, err)
+
+			qb := DefaultAtlasSearchQueryBuilder{
+				FieldToTextStrategy: map[string]*TextStrategy{
+					"name": &tc.strategy,
+				},
+			}
+
+			// Execute SUT
+			_, err = epsearchast.SemanticReduceAst(astNode, epsearchast.SemanticReducer[bson.D](qb))
+
+			// Verification
+			require.ErrorContains(t, err, tc.expectedError)
+			require.Panics(t, qb.MustValidate)
+		})
+	}
+}
+
+func TestAtlasSearchAndWithCompoundFilterKeepsAutocompleteInMust(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	astJson := `{
+		"type": "AND",
+		"children": [
+			{"type": "TEXT", "args": ["name", "sho"]},
+			{"type": "EQ", "args": ["status", "live"]}
+		]
+	}`
+
+	astNode, err := epsearchast.GetAst(astJson)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		UseCompoundFilter: true,
+		FieldToTextStrategy: map[string]*TextStrategy{
+			"name": {Operator: TextOperatorAutocomplete},
+		},
+	}
+
+	expectedJson := `{"compound":{"must":[{"autocomplete":{"query":"sho","path":"name"}}],"filter":[{"equals":{"path":"status","value":"live"}}]}}`
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(doc))
+}
+
+func TestAtlasSearchFlattenMergesNestedCompounds(t *testing.T) {
+	var testCases = []struct {
+		name              string
+		useCompoundFilter bool
+		filter            string
+		expectedJson      string
+	}{
+		{
+			name: "nested and/or",
+			//language=JSON
+			filter: `{
+				"type": "AND",
+				"children": [
+					{"type": "AND", "children": [{"type": "EQ", "args": ["a", "1"]}, {"type": "EQ", "args": ["b", "2"]}]},
+					{"type": "OR", "children": [{"type": "EQ", "args": ["c", "3"]}, {"type": "OR", "children": [{"type": "EQ", "args": ["d", "4"]}, {"type": "EQ", "args": ["e", "5"]}]}]},
+					{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]}
+				]
+			}`,
+			expectedJson: `{"compound":{"must":[` +
+				`{"equals":{"path":"a","value":"1"}},{"equals":{"path":"b","value":"2"}},` +
+				`{"compound":{"should":[{"equals":{"path":"c","value":"3"}},{"equals":{"path":"d","value":"4"}},{"equals":{"path":"e","value":"5"}}],"minimumShouldMatch":{"$numberInt":"1"}}},` +
+				`{"equals":{"path":"tags","value":"red"}},{"equals":{"path":"tags","value":"blue"}}]}}`,
+		},
+		{
+			name:              "nested and with compound filter",
+			useCompoundFilter: true,
+			//language=JSON
+			filter: `{
+				"type": "AND",
+				"children": [
+					{"type": "AND", "children": [{"type": "TEXT", "args": ["name", "shoes"]}, {"type": "EQ", "args": ["b", "2"]}]},
+					{"type": "EQ", "args": ["c", "3"]}
+				]
+			}`,
+			expectedJson: `{"compound":{"must":[{"text":{"query":"shoes","path":"name"}}],"filter":[{"equals":{"path":"b","value":"2"}},{"equals":{"path":"c","value":"3"}}]}}`,
+		},
+		{
+			name: "single value contains_all",
+			//language=JSON
+			filter:       `{"type": "CONTAINS_ALL", "args": ["tags", "red"]}`,
+			expectedJson: `{"equals":{"path":"tags","value":"red"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//Fixture Setup
+			astNode, err := epsearchast.GetAst(tc.filter)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+				UseCompoundFilter: tc.useCompoundFilter,
+				Flatten:           true,
+			}
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
+func TestAtlasSearchFlattenDoesNotMergeScoredCompounds(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	astJson := `{
+		"type": "AND",
+		"children": [
+			{"type": "CONTAINS_ALL", "args": ["tags", "red", "blue"]},
+			{"type": "EQ", "args": ["a", "1"]}
+		]
+	}`
+
+	astNode, err := epsearchast.GetAst(astJson)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultAtlasSearchQueryBuilder{
+		Flatten: true,
+		FieldScores: map[string]*FieldScore{
+			"tags": {Boost: 2},
+		},
+	}
+
+	expectedJson := `{"compound":{"must":[` +
+		`{"compound":{"must":[{"equals":{"path":"tags","value":"red"}},{"equals":{"path":"tags","value":"blue"}}],"score":{"boost":{"value":{"$numberDouble":"2.0"}}}}},` +
+		`{"equals":{"path":"a","value":"1"}}]}}`
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedJson, string(doc))
+}
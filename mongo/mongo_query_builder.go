@@ -11,11 +11,26 @@ import "go.mongodb.org/mongo-driver/v2/bson"
 
 type DefaultMongoQueryBuilder struct {
 	FieldTypes map[string]epsearchast.FieldType
+
+	// NullSemantics controls what is_null matches, by default both explicit nulls and missing fields are matched.
+	NullSemantics epsearchast.NullSemanticsConfig
+
+	// Flatten merges nested $and and $or operators (e.g., AND(AND(a,b),c) generates a single $and with three clauses), and removes operators
+	// that only wrap a single clause.
+	Flatten bool
 }
 
 var _ epsearchast.SemanticReducer[bson.D] = (*DefaultMongoQueryBuilder)(nil)
 
 func (d DefaultMongoQueryBuilder) PostVisitAnd(rs []*bson.D) (*bson.D, error) {
+	if d.Flatten {
+		rs = flattenLogicalOperator("$and", rs)
+
+		if len(rs) == 1 {
+			return rs[0], nil
+		}
+	}
+
 	// https://www.mongodb.com/docs/manual/reference/operator/query/and/
 	return &bson.D{
 		{"$and",
@@ -25,6 +40,14 @@ func (d DefaultMongoQueryBuilder) PostVisitAnd(rs []*bson.D) (*bson.D, error) {
 }
 
 func (d DefaultMongoQueryBuilder) PostVisitOr(rs []*bson.D) (*bson.D, error) {
+	if d.Flatten {
+		rs = flattenLogicalOperator("$or", rs)
+
+		if len(rs) == 1 {
+			return rs[0], nil
+		}
+	}
+
 	// https://www.mongodb.com/docs/manual/reference/operator/query/or/
 	return &bson.D{
 		{"$or",
@@ -33,6 +56,24 @@ func (d DefaultMongoQueryBuilder) PostVisitOr(rs []*bson.D) (*bson.D, error) {
 	}, nil
 }
 
+// flattenLogicalOperator replaces any clause in rs that only contains the same logical operator (e.g., {"$and": [a, b]}) with its clauses.
+func flattenLogicalOperator(operator string, rs []*bson.D) []*bson.D {
+	flattened := make([]*bson.D, 0, len(rs))
+
+	for _, r := range rs {
+		if r != nil && len(*r) == 1 && (*r)[0].Key == operator {
+			if clauses, ok := (*r)[0].Value.([]*bson.D); ok {
+				flattened = append(flattened, clauses...)
+				continue
+			}
+		}
+
+		flattened = append(flattened, r)
+	}
+
+	return flattened
+}
+
 func (d DefaultMongoQueryBuilder) VisitIn(args ...string) (*bson.D, error) {
 
 	if err := d.ValidateValues(args[0], args[1:]...); err != nil {
@@ -165,10 +206,28 @@ func (d DefaultMongoQueryBuilder) VisitText(first, second string) (*bson.D, erro
 }
 
 func (d DefaultMongoQueryBuilder) VisitIsNull(first string) (*bson.D, error) {
-	// https://www.mongodb.com/docs/manual/tutorial/query-for-null-fields/#equality-filter
-	// This will match fields that either contain the item field whose value is nil or those that do not contain the field
-	// Customize this method if you need different nil handling (i.e., explicit nil)
-	return &bson.D{{first, bson.D{{"$eq", nil}}}}, nil
+	switch s := d.NullSemantics.ForField(first); s {
+	case epsearchast.DefaultNullSemantics, epsearchast.NullOrMissing:
+		// https://www.mongodb.com/docs/manual/tutorial/query-for-null-fields/#equality-filter
+		// This will match fields that either contain the item field whose value is nil or those that do not contain the field
+		return &bson.D{{first, bson.D{{"$eq", nil}}}}, nil
+	case epsearchast.ExplicitNullOnly:
+		// https://www.mongodb.com/docs/manual/tutorial/query-for-null-fields/#type-check
+		return &bson.D{{first, bson.D{{"$type", "null"}}}}, nil
+	case epsearchast.MissingOnly:
+		// https://www.mongodb.com/docs/manual/tutorial/query-for-null-fields/#existence-check
+		return &bson.D{{first, bson.D{{"$exists", false}}}}, nil
+	case epsearchast.NullOrMissingOrEmptyArray:
+		// https://www.mongodb.com/docs/manual/reference/operator/query/size/
+		return &bson.D{
+			{"$or", []*bson.D{
+				{{first, bson.D{{"$eq", nil}}}},
+				{{first, bson.D{{"$size", 0}}}},
+			}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported null semantics %s for field [%s]", s, first)
+	}
 }
 
 func (d DefaultMongoQueryBuilder) ProcessLikeWildcards(valString string) string {
@@ -697,6 +697,79 @@ func TestSmokeTestMongoWithFilters(t *testing.T) {
 
 }
 
+func TestSmokeTestMongoIsNullWithNullSemantics(t *testing.T) {
+	// This is the same logical data set used in the Postgres and Elasticsearch tests.
+	documents := []interface{}{
+		bson.M{
+			"string_field": "explicit_null",
+			"array_field":  nil,
+		},
+		bson.M{
+			"string_field": "has_value",
+			"array_field":  []string{"a"},
+		},
+		bson.M{
+			"string_field": "missing",
+		},
+		bson.M{
+			"string_field": "empty_array",
+			"array_field":  []string{},
+		},
+	}
+
+	var testCases = []struct {
+		semantics epsearchast.NullSemantics
+		count     int64
+	}{
+		{epsearchast.ExplicitNullOnly, 1},
+		{epsearchast.MissingOnly, 1},
+		{epsearchast.NullOrMissing, 2},
+		{epsearchast.NullOrMissingOrEmptyArray, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			/*
+				Fixture Setup
+			*/
+			ctx := context.Background()
+			collection := SetupDB(t, ctx)
+			InsertDocumentsOrFail(t, collection, ctx, documents)
+
+			/*
+			  Execute SUT
+			*/
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultMongoQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					Default: tc.semantics,
+				},
+			}
+
+			ast, err := epsearchast.GetAst(`{"type": "IS_NULL", "args": ["array_field"]}`)
+			if err != nil {
+				t.Fatalf("Failed to get filter: %v", err)
+			}
+
+			query, err := epsearchast.SemanticReduceAst(ast, qb)
+			if err != nil {
+				t.Fatalf("Failed to get filter: %v", err)
+			}
+
+			/*
+				Verification
+			*/
+			count, err := collection.CountDocuments(ctx, query)
+			if err != nil {
+				t.Fatalf("Failed to count documents: %v", err)
+			}
+
+			if count != tc.count {
+				t.Errorf("Expected count %d, but got %d", tc.count, count)
+			}
+		})
+	}
+}
+
 func InsertDocumentsOrFail(t *testing.T, collection *mongo.Collection, ctx context.Context, documents []interface{}) {
 	_, err := collection.InsertMany(ctx, documents)
 	if err != nil {
@@ -386,6 +386,54 @@ func TestSimpleUnaryOperatorFiltersGeneratesCorrectFilter(t *testing.T) {
 	}
 }
 
+func TestIsNullOperatorFiltersGeneratesCorrectFilterWithNullSemantics(t *testing.T) {
+	var testCases = []struct {
+		semantics    epsearchast.NullSemantics
+		expectedJson string
+	}{
+		{epsearchast.DefaultNullSemantics, `{"amount":{"$eq":null}}`},
+		{epsearchast.NullOrMissing, `{"amount":{"$eq":null}}`},
+		{epsearchast.ExplicitNullOnly, `{"amount":{"$type":"null"}}`},
+		{epsearchast.MissingOnly, `{"amount":{"$exists":false}}`},
+		{epsearchast.NullOrMissingOrEmptyArray, `{"$or":[{"amount":{"$eq":null}},{"amount":{"$size":{"$numberInt":"0"}}}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.semantics.String(), func(t *testing.T) {
+			//Fixture Setup
+			//language=JSON
+			astJson := `
+				{
+				"type": "IS_NULL",
+				"args": [ "amount"]
+			}`
+
+			astNode, err := epsearchast.GetAst(astJson)
+			require.NoError(t, err)
+
+			var qb epsearchast.SemanticReducer[bson.D] = DefaultMongoQueryBuilder{
+				NullSemantics: epsearchast.NullSemanticsConfig{
+					FieldOverrides: map[string]epsearchast.NullSemantics{
+						"amount": tc.semantics,
+					},
+				},
+			}
+
+			// Execute SUT
+			queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+			// Verification
+
+			require.NoError(t, err)
+
+			doc, err := bson.MarshalExtJSON(queryObj, true, false)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedJson, string(doc))
+		})
+	}
+}
+
 func TestSimpleVariableOperatorFiltersGeneratesCorrectFilter(t *testing.T) {
 	for _, varOp := range varOps {
 		t.Run(fmt.Sprintf("%s", varOp.AstOp), func(t *testing.T) {
@@ -790,3 +838,54 @@ func (l *LowerCaseEmail) VisitEq(first, second string) (*bson.D, error) {
 		return DefaultMongoQueryBuilder.VisitEq(l.DefaultMongoQueryBuilder, first, second)
 	}
 }
+
+func TestFlattenMergesNestedLogicalOperators(t *testing.T) {
+	//Fixture Setup
+	//language=JSON
+	jsonTxt := `{
+		"type": "AND",
+		"children": [
+			{
+				"type": "AND",
+				"children": [
+					{"type": "EQ", "args": ["a", "1"]},
+					{"type": "EQ", "args": ["b", "2"]}
+				]
+			},
+			{
+				"type": "OR",
+				"children": [
+					{"type": "EQ", "args": ["c", "3"]},
+					{
+						"type": "OR",
+						"children": [
+							{"type": "EQ", "args": ["d", "4"]},
+							{"type": "EQ", "args": ["e", "5"]}
+						]
+					}
+				]
+			},
+			{"type": "EQ", "args": ["f", "6"]}
+		]
+	}`
+
+	expectedMongoJSON := `{"$and":[{"a":{"$eq":"1"}},{"b":{"$eq":"2"}},{"$or":[{"c":{"$eq":"3"}},{"d":{"$eq":"4"}},{"e":{"$eq":"5"}}]},{"f":{"$eq":"6"}}]}`
+
+	astNode, err := epsearchast.GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	var qb epsearchast.SemanticReducer[bson.D] = DefaultMongoQueryBuilder{
+		Flatten: true,
+	}
+
+	// Execute SUT
+	queryObj, err := epsearchast.SemanticReduceAst(astNode, qb)
+
+	// Verification
+	require.NoError(t, err)
+
+	doc, err := bson.MarshalExtJSON(queryObj, true, false)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedMongoJSON, string(doc))
+}
@@ -0,0 +1,47 @@
+package astmongo
+
+import (
+	"regexp"
+
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+)
+
+// MongoQueryBuilderFromSchema returns a query builder with the field types from the schema, for an AST that has had the Mongo mapping applied.
+//
+// The schema doesn't describe null semantics, so NullSemantics can be set on the result.
+func MongoQueryBuilderFromSchema(s *astschema.Schema) DefaultMongoQueryBuilder {
+	return DefaultMongoQueryBuilder{
+		FieldTypes: s.MappedFieldTypes(astschema.Mongo),
+	}
+}
+
+// AtlasSearchQueryBuilderFromSchema returns a query builder with the field types and embedded documents from the schema, for an AST that has had the Mongo
+// mapping applied.
+//
+// The schema doesn't describe null semantics or how text is searched, so NullSemantics, FieldToTextStrategy and the other options can be set on the result.
+func AtlasSearchQueryBuilderFromSchema(s *astschema.Schema) DefaultAtlasSearchQueryBuilder {
+	embeddedDocumentFieldToQuery := map[string]EmbeddedDocumentReplacement{}
+
+	for name, field := range s.Fields {
+		if field.Mongo == nil || field.Mongo.EmbeddedDocument == nil {
+			continue
+		}
+
+		mapped := s.MappedName(name, astschema.Mongo)
+		ed := field.Mongo.EmbeddedDocument
+
+		subqueries := map[string]Replacement{mapped: {Value: "$value"}}
+
+		for f, v := range ed.Filter {
+			subqueries[f] = Replacement{Value: v, ForceEQ: true}
+		}
+
+		// The schema has field names rather than patterns, so the pattern only matches the field.
+		embeddedDocumentFieldToQuery["^"+regexp.QuoteMeta(mapped)+"$"] = EmbeddedDocumentReplacement{Path: ed.Path, Subqueries: subqueries}
+	}
+
+	return DefaultAtlasSearchQueryBuilder{
+		FieldTypes:                   s.MappedFieldTypes(astschema.Mongo),
+		EmbeddedDocumentFieldToQuery: embeddedDocumentFieldToQuery,
+	}
+}
@@ -0,0 +1,109 @@
+package astmongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/elasticpath/epcc-search-ast-helper/schema"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func getMappedOrdersAst(t *testing.T) (*astschema.Schema, *epsearchast.AstNode) {
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  status:
+    operators: [eq]
+    aliases: [state]
+    mongo:
+      path: attributes.status
+  total:
+    operators: [gt]
+    type: int64
+    mongo:
+      path: meta.total
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(state,paid):gt(total,5)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyAliases(ast)
+	require.NoError(t, err)
+
+	ast, err = s.ApplyMapping(ast, astschema.Mongo)
+	require.NoError(t, err)
+
+	return s, ast
+}
+
+func TestMongoQueryBuilderFromSchemaUsesMappedFieldTypes(t *testing.T) {
+	// Fixture Setup
+	s, ast := getMappedOrdersAst(t)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(ast, MongoQueryBuilderFromSchema(s))
+
+	// Verification
+	require.NoError(t, err)
+
+	queryJson, err := bson.MarshalExtJSON(query, false, false)
+	require.NoError(t, err)
+
+	require.Equal(t, `{"$and":[{"attributes.status":{"$eq":"paid"}},{"meta.total":{"$gt":5}}]}`, string(queryJson))
+}
+
+func TestAtlasSearchQueryBuilderFromSchemaUsesMappedFieldTypes(t *testing.T) {
+	// Fixture Setup
+	s, ast := getMappedOrdersAst(t)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(ast, AtlasSearchQueryBuilderFromSchema(s))
+
+	// Verification
+	require.NoError(t, err)
+
+	queryJson, err := bson.MarshalExtJSON(query, false, false)
+	require.NoError(t, err)
+
+	require.Contains(t, string(queryJson), `"path":"attributes.status"`)
+	require.Contains(t, string(queryJson), `{"range":{"path":"meta.total","gt":5}}`)
+}
+
+func TestAtlasSearchQueryBuilderFromSchemaUsesEmbeddedDocuments(t *testing.T) {
+	// Fixture Setup
+	s, err := astschema.Load(strings.NewReader(`
+fields:
+  sku:
+    operators: [eq]
+    mongo:
+      path: variants.sku
+      embedded_document:
+        path: variants
+        filter:
+          variants.kind: physical
+`))
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(sku,abc)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Validate(ast))
+
+	ast, err = s.ApplyMapping(ast, astschema.Mongo)
+	require.NoError(t, err)
+
+	// Execute SUT
+	query, err := epsearchast.SemanticReduceAst(ast, AtlasSearchQueryBuilderFromSchema(s).MustCompile())
+
+	// Verification
+	require.NoError(t, err)
+
+	queryJson, err := bson.MarshalExtJSON(query, false, false)
+	require.NoError(t, err)
+
+	require.Contains(t, string(queryJson), `{"embeddedDocument":{"path":"variants"`)
+	require.Contains(t, string(queryJson), `{"equals":{"path":"variants.kind","value":"physical"}}`)
+	require.Contains(t, string(queryJson), `{"equals":{"path":"variants.sku","value":"abc"}}`)
+}
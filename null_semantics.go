@@ -0,0 +1,140 @@
+package epsearchast
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// NullSemantics controls which documents the is_null operator matches.
+//
+// Document stores distinguish between a field that is explicitly set to null and a field that is missing, and some backends additionally treat an empty array as null.
+// By default each query builder keeps its historical behaviour, which differs between backends, setting an explicit value ensures the same filter returns the same data everywhere.
+type NullSemantics int
+
+const (
+	// DefaultNullSemantics uses whatever the query builder has historically done (e.g., Mongo matches null or missing, Elasticsearch matches null, missing or empty arrays, and SQL matches NULL).
+	DefaultNullSemantics NullSemantics = iota
+	// ExplicitNullOnly matches only fields that are present and explicitly set to null.
+	ExplicitNullOnly
+	// MissingOnly matches only documents that do not contain the field at all.
+	MissingOnly
+	// NullOrMissing matches fields that are explicitly null or missing.
+	NullOrMissing
+	// NullOrMissingOrEmptyArray matches fields that are explicitly null, missing or an empty array.
+	NullOrMissingOrEmptyArray
+)
+
+func (n NullSemantics) String() string {
+	switch n {
+	case DefaultNullSemantics:
+		return "default"
+	case ExplicitNullOnly:
+		return "explicit_null_only"
+	case MissingOnly:
+		return "missing_only"
+	case NullOrMissing:
+		return "null_or_missing"
+	case NullOrMissingOrEmptyArray:
+		return "null_or_missing_or_empty_array"
+	default:
+		return "unknown"
+	}
+}
+
+// NullSemanticsConfig is shared by the query builders to decide how is_null should be translated for a field.
+type NullSemanticsConfig struct {
+	// The semantics to use for any field that does not have an override.
+	Default NullSemantics
+
+	// Per-field overrides, keys are field names (after aliases have been processed), or regular expressions that start with ^ and end with $.
+	FieldOverrides map[string]NullSemantics
+
+	// The compiled regular expressions from FieldOverrides sorted by pattern, set by MustCompile, otherwise the patterns are compiled for each is_null.
+	compiledOverrides []compiledNullSemantics
+}
+
+type compiledNullSemantics struct {
+	pattern   *regexp.Regexp
+	semantics NullSemantics
+}
+
+// ForField returns the semantics that should be used for the given field, an exact match is used first, then the first pattern that matches in sorted order.
+func (n NullSemanticsConfig) ForField(fieldName string) NullSemantics {
+	if v, ok := n.FieldOverrides[fieldName]; ok {
+		return v
+	}
+
+	for _, o := range n.getOverrides() {
+		if o.pattern.MatchString(fieldName) {
+			return o.semantics
+		}
+	}
+
+	return n.Default
+}
+
+// MustCompile returns a copy of the config with the regular expressions in FieldOverrides compiled, and panics if one of them doesn't compile.
+// The overrides must not be changed after this is called.
+func (n NullSemanticsConfig) MustCompile() NullSemanticsConfig {
+	n.compiledOverrides = n.compileOverrides()
+	return n
+}
+
+// getOverrides returns the patterns in FieldOverrides sorted by pattern, so that the same override is used whether or not the config is compiled.
+func (n NullSemanticsConfig) getOverrides() []compiledNullSemantics {
+	if n.compiledOverrides != nil {
+		return n.compiledOverrides
+	}
+
+	return n.compileOverrides()
+}
+
+func (n NullSemanticsConfig) compileOverrides() []compiledNullSemantics {
+	patterns := make([]string, 0, len(n.FieldOverrides))
+	for k := range n.FieldOverrides {
+		if isRegex(k) {
+			patterns = append(patterns, k)
+		}
+	}
+
+	sort.Strings(patterns)
+
+	compiled := make([]compiledNullSemantics, 0, len(patterns))
+	for _, k := range patterns {
+		compiled = append(compiled, compiledNullSemantics{
+			pattern:   regexp.MustCompile(k),
+			semantics: n.FieldOverrides[k],
+		})
+	}
+
+	return compiled
+}
+
+// ValidateSupported returns an error if the default or an override uses semantics that the backend doesn't support, DefaultNullSemantics is always supported.
+// Query builders call this from MustValidate so that an unsupported config is rejected at start up, rather than on the first is_null.
+func (n NullSemanticsConfig) ValidateSupported(backend string, supported ...NullSemantics) error {
+	isSupported := func(s NullSemantics) bool {
+		return s == DefaultNullSemantics || slices.Contains(supported, s)
+	}
+
+	if !isSupported(n.Default) {
+		return fmt.Errorf("null semantics %s are not supported in %s", n.Default, backend)
+	}
+
+	fields := make([]string, 0, len(n.FieldOverrides))
+	for k := range n.FieldOverrides {
+		fields = append(fields, k)
+	}
+
+	sort.Strings(fields)
+
+	for _, k := range fields {
+		if s := n.FieldOverrides[k]; !isSupported(s) {
+			return fmt.Errorf("null semantics %s are not supported in %s for field [%s]", s, backend, k)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package epsearchast
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNullSemanticsForFieldReturnsDefaultWhenNoOverride(t *testing.T) {
+	// Fixture Setup
+	config := NullSemanticsConfig{
+		Default: NullOrMissing,
+	}
+
+	// Execute SUT
+	semantics := config.ForField("status")
+
+	// Verification
+	require.Equal(t, NullOrMissing, semantics)
+}
+
+func TestNullSemanticsForFieldReturnsOverride(t *testing.T) {
+	// Fixture Setup
+	config := NullSemanticsConfig{
+		Default: NullOrMissing,
+		FieldOverrides: map[string]NullSemantics{
+			"status": ExplicitNullOnly,
+		},
+	}
+
+	// Execute SUT
+	semantics := config.ForField("status")
+
+	// Verification
+	require.Equal(t, ExplicitNullOnly, semantics)
+}
+
+func TestNullSemanticsForFieldReturnsRegexOverride(t *testing.T) {
+	// Fixture Setup
+	config := NullSemanticsConfig{
+		FieldOverrides: map[string]NullSemantics{
+			`^tags\.[a-z]+$`: NullOrMissingOrEmptyArray,
+		},
+	}
+
+	// Execute SUT
+	matched := config.ForField("tags.colour")
+	unmatched := config.ForField("tags.Colour")
+
+	// Verification
+	require.Equal(t, NullOrMissingOrEmptyArray, matched)
+	require.Equal(t, DefaultNullSemantics, unmatched)
+}
+
+func TestNullSemanticsForFieldUsesOverridesInOrder(t *testing.T) {
+	config := NullSemanticsConfig{
+		Default: NullOrMissing,
+		FieldOverrides: map[string]NullSemantics{
+			`^tags\..*$`:      NullOrMissingOrEmptyArray,
+			`^tags\.colour$`:  ExplicitNullOnly,
+			"tags.size":       MissingOnly,
+			`^attributes\..*`: ExplicitNullOnly,
+		},
+	}
+
+	for name, config := range map[string]NullSemanticsConfig{"compiled": config.MustCompile(), "not compiled": config} {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			// The overlapping patterns are checked many times, as iterating over a map would return a different override some of the time.
+			for i := 0; i < 50; i++ {
+				literal := config.ForField("tags.size")
+				firstPattern := config.ForField("tags.colour")
+				unmatched := config.ForField("attributes.colour")
+
+				// Verification
+				require.Equal(t, MissingOnly, literal)
+				require.Equal(t, NullOrMissingOrEmptyArray, firstPattern)
+				require.Equal(t, NullOrMissing, unmatched)
+			}
+		})
+	}
+}
+
+func TestNullSemanticsMustCompilePanicsWhenRegexDoesNotCompile(t *testing.T) {
+	// Fixture Setup
+	config := NullSemanticsConfig{
+		FieldOverrides: map[string]NullSemantics{
+			"^tags($": MissingOnly,
+		},
+	}
+
+	// Execute SUT & Verification
+	require.Panics(t, func() {
+		config.MustCompile()
+	})
+}
+
+func TestNullSemanticsValidateSupported(t *testing.T) {
+	testCases := map[string]struct {
+		config   NullSemanticsConfig
+		expected string
+	}{
+		"default semantics": {
+			config: NullSemanticsConfig{},
+		},
+		"supported": {
+			config: NullSemanticsConfig{Default: NullOrMissing, FieldOverrides: map[string]NullSemantics{"tags": DefaultNullSemantics}},
+		},
+		"unsupported default": {
+			config:   NullSemanticsConfig{Default: MissingOnly},
+			expected: "null semantics missing_only are not supported in SQL",
+		},
+		"unsupported override": {
+			config:   NullSemanticsConfig{FieldOverrides: map[string]NullSemantics{"b": NullOrMissing, "a": ExplicitNullOnly}},
+			expected: "null semantics explicit_null_only are not supported in SQL for field [a]",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			err := tc.config.ValidateSupported("SQL", NullOrMissing, NullOrMissingOrEmptyArray)
+
+			// Verification
+			if tc.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
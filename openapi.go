@@ -0,0 +1,223 @@
+package epsearchast
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GenerateOpenApiFilterParameter returns an OpenAPI 3.1 parameter object for the filter query parameter, which can be marshalled to JSON or YAML.
+//
+// The description contains a table of the fields, and the x-filterable-fields extension describes each field for tools, with an enum of its operators,
+// its aliases, its type, and a JSON Schema for its values (see GenerateAstJsonSchema). Aliases that are regular expressions and rewrite the field
+// (e.g., to $1) are not included.
+func GenerateOpenApiFilterParameter(allowedOps map[string][]string, aliases map[string]string, fieldTypes map[string]FieldType) map[string]any {
+	sb := strings.Builder{}
+	sb.WriteString("Filters the results, e.g., `eq(field,value):gt(other,1)`. The following fields can be filtered:\n\n")
+	sb.WriteString("| Field | Operators | Type | Aliases |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+
+	fields := map[string]any{}
+
+	for _, field := range sortedFields(allowedOps) {
+		ops := lowerOperators(allowedOps[field])
+		fieldAliases := aliasesOf(field, aliases)
+		fieldType := fieldTypes[field]
+
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", escapeTableCell(field), strings.Join(ops, ", "), fieldType, escapeTableCell(strings.Join(fieldAliases, ", "))))
+
+		fieldDescription := map[string]any{
+			"operators":  map[string]any{"type": "string", "enum": ops},
+			"field_type": fieldType.String(),
+			"value":      valueJsonSchema(fieldType),
+		}
+
+		if len(fieldAliases) > 0 {
+			fieldDescription["aliases"] = fieldAliases
+		}
+
+		fields[field] = fieldDescription
+	}
+
+	return map[string]any{
+		"name":                "filter",
+		"in":                  "query",
+		"required":            false,
+		"description":         sb.String(),
+		"schema":              map[string]any{"type": "string"},
+		"x-filterable-fields": fields,
+	}
+}
+
+// GenerateAstJsonSchema returns a JSON Schema (draft 2020-12) for the EP-Internal-Search-AST-v3 header, which can be marshalled to JSON.
+//
+// Each node must be an AND or an OR with at least two children, or an operator allowed for a field (or one of its aliases), with the number of arguments
+// the operator takes (a node can match more than one field, e.g., a regular expression and a field it also matches, so the nodes are combined with anyOf). Values must be valid for the FieldType of the field (like, ilike and text always take a string). Fields and aliases that are
+// regular expressions are matched with a pattern, but aliases that are regular expressions and rewrite the field (e.g., to $1) are not included.
+func GenerateAstJsonSchema(allowedOps map[string][]string, aliases map[string]string, fieldTypes map[string]FieldType) map[string]any {
+	nodes := []any{
+		conjunctionJsonSchema("AND"),
+		conjunctionJsonSchema("OR"),
+	}
+
+	for _, field := range sortedFields(allowedOps) {
+		fieldType := fieldTypes[field]
+
+		var unary, binary, text, variadic []string
+
+		for _, op := range lowerOperators(allowedOps[field]) {
+			switch op {
+			case "is_null":
+				unary = append(unary, strings.ToUpper(op))
+			case "like", "ilike", "text":
+				text = append(text, strings.ToUpper(op))
+			case "in", "contains_any", "contains_all":
+				variadic = append(variadic, strings.ToUpper(op))
+			default:
+				binary = append(binary, strings.ToUpper(op))
+			}
+		}
+
+		names := fieldNamesJsonSchema(field, aliases)
+
+		for _, group := range []struct {
+			ops      []string
+			value    map[string]any
+			minItems int
+			maxItems int
+		}{
+			{unary, nil, 1, 1},
+			{binary, valueJsonSchema(fieldType), 2, 2},
+			{text, valueJsonSchema(String), 2, 2},
+			{variadic, valueJsonSchema(fieldType), 2, 0},
+		} {
+			if len(group.ops) == 0 {
+				continue
+			}
+
+			args := map[string]any{
+				"type":        "array",
+				"prefixItems": []any{names},
+				"minItems":    group.minItems,
+			}
+
+			if group.value != nil {
+				args["items"] = group.value
+			}
+
+			if group.maxItems > 0 {
+				args["maxItems"] = group.maxItems
+			}
+
+			nodes = append(nodes, map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"type": map[string]any{"enum": group.ops},
+					"args": args,
+				},
+				"required": []string{"type", "args"},
+			})
+		}
+	}
+
+	return map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "EP-Internal-Search-AST-v3",
+		"$ref":    "#/$defs/node",
+		"$defs": map[string]any{
+			"node": map[string]any{"anyOf": nodes},
+		},
+	}
+}
+
+func conjunctionJsonSchema(nodeType string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type": map[string]any{"const": nodeType},
+			"children": map[string]any{
+				"type":     "array",
+				"minItems": 2,
+				"items":    map[string]any{"$ref": "#/$defs/node"},
+			},
+		},
+		"required": []string{"type", "children"},
+	}
+}
+
+// fieldNamesJsonSchema returns a schema that matches the field or any of its aliases.
+func fieldNamesJsonSchema(field string, aliases map[string]string) map[string]any {
+	var names []string
+	var patterns []any
+
+	for _, name := range append([]string{field}, aliasesOf(field, aliases)...) {
+		if isRegex(name) {
+			patterns = append(patterns, map[string]any{"type": "string", "pattern": name})
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) > 0 {
+		patterns = append(patterns, map[string]any{"enum": names})
+	}
+
+	if len(patterns) == 1 {
+		return patterns[0].(map[string]any)
+	}
+
+	return map[string]any{"anyOf": patterns}
+}
+
+// valueJsonSchema returns a schema that documents the syntax of the string values for the type. The patterns for int64 and float64 don't check the range,
+// so a value that would overflow (e.g., 99999999999999999999 for an int64) matches but is rejected by ValidateValue. The float64 pattern only documents
+// decimal numbers, ValidateValue also accepts what strconv.ParseFloat does (e.g., Inf, NaN, hexadecimal and underscores).
+func valueJsonSchema(t FieldType) map[string]any {
+	switch t {
+	case Int64:
+		return map[string]any{"type": "string", "pattern": `^[+-]?[0-9]+$`}
+	case Float64:
+		return map[string]any{"type": "string", "pattern": `^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`}
+	case Boolean:
+		return map[string]any{"type": "string", "enum": []string{"1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"}}
+	case Date:
+		return map[string]any{"type": "string", "anyOf": []any{map[string]any{"format": "date-time"}, map[string]any{"format": "date"}}}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// escapeTableCell escapes | in a markdown table cell (e.g., in a regular expression like ^(a|b)$), which would otherwise end the cell.
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func sortedFields(allowedOps map[string][]string) []string {
+	return slices.Sorted(maps.Keys(allowedOps))
+}
+
+func lowerOperators(ops []string) []string {
+	lower := make([]string, len(ops))
+
+	for i, op := range ops {
+		lower[i] = strings.ToLower(op)
+	}
+
+	return lower
+}
+
+// aliasesOf returns the sorted aliases that point to the field.
+func aliasesOf(field string, aliases map[string]string) []string {
+	var fieldAliases []string
+
+	for alias, target := range aliases {
+		if target == field {
+			fieldAliases = append(fieldAliases, alias)
+		}
+	}
+
+	slices.Sort(fieldAliases)
+
+	return fieldAliases
+}
@@ -0,0 +1,283 @@
+package epsearchast
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var openApiAllowedOps = map[string][]string{
+	"status":      {"eq", "in", "is_null"},
+	"total":       {"GT", "lt"},
+	"name":        {"eq", "like", "text"},
+	"^attr\\..*$": {"eq"},
+}
+
+var openApiAliases = map[string]string{
+	"state": "status",
+	"st":    "status",
+}
+
+var openApiFieldTypes = map[string]FieldType{
+	"total": Int64,
+}
+
+func TestGenerateOpenApiFilterParameter(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	expected := `{
+		"name": "filter",
+		"in": "query",
+		"required": false,
+		"description": "Filters the results, e.g., ` + "`eq(field,value):gt(other,1)`" + `. The following fields can be filtered:\n\n| Field | Operators | Type | Aliases |\n| --- | --- | --- | --- |\n| ` + "`^attr\\\\..*$`" + ` | eq | string |  |\n| ` + "`name`" + ` | eq, like, text | string |  |\n| ` + "`status`" + ` | eq, in, is_null | string | st, state |\n| ` + "`total`" + ` | gt, lt | int64 |  |\n",
+		"schema": {"type": "string"},
+		"x-filterable-fields": {
+			"^attr\\..*$": {
+				"operators": {"type": "string", "enum": ["eq"]},
+				"field_type": "string",
+				"value": {"type": "string"}
+			},
+			"name": {
+				"operators": {"type": "string", "enum": ["eq", "like", "text"]},
+				"field_type": "string",
+				"value": {"type": "string"}
+			},
+			"status": {
+				"operators": {"type": "string", "enum": ["eq", "in", "is_null"]},
+				"field_type": "string",
+				"aliases": ["st", "state"],
+				"value": {"type": "string"}
+			},
+			"total": {
+				"operators": {"type": "string", "enum": ["gt", "lt"]},
+				"field_type": "int64",
+				"value": {"type": "string", "pattern": "^[+-]?[0-9]+$"}
+			}
+		}
+	}`
+
+	// Execute SUT
+	parameter := GenerateOpenApiFilterParameter(openApiAllowedOps, openApiAliases, openApiFieldTypes)
+
+	// Verify
+	parameterJson, err := json.Marshal(parameter)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(parameterJson))
+}
+
+func TestGenerateOpenApiFilterParameterEscapesPipesInTable(t *testing.T) {
+	// Fixture Setup
+	allowedOps := map[string][]string{"^(color|colour)$": {"eq"}, "size": {"eq"}}
+	aliases := map[string]string{"^(sz|sizing)$": "size"}
+
+	// Execute SUT
+	parameter := GenerateOpenApiFilterParameter(allowedOps, aliases, nil)
+
+	// Verify
+	description := parameter["description"].(string)
+	require.Contains(t, description, "| `^(color\\|colour)$` | eq | string |  |\n")
+	require.Contains(t, description, "| `size` | eq | string | ^(sz\\|sizing)$ |\n")
+}
+
+func TestGenerateAstJsonSchemaAllowsNodesThatMatchMoreThanOneField(t *testing.T) {
+	// Fixture Setup
+	allowedOps := map[string][]string{"^attr\\..*$": {"eq"}, "attr.color": {"eq"}}
+
+	// Execute SUT
+	schema := GenerateAstJsonSchema(allowedOps, nil, nil)
+
+	// Verify
+	node := schema["$defs"].(map[string]any)["node"].(map[string]any)
+
+	// eq(attr.color,red) matches the schema for both fields, which oneOf would reject.
+	require.NotContains(t, node, "oneOf")
+	require.Len(t, node["anyOf"], 4)
+}
+
+func TestGenerateAstJsonSchema(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	expected := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "EP-Internal-Search-AST-v3",
+		"$ref": "#/$defs/node",
+		"$defs": {
+			"node": {
+				"anyOf": [
+					{
+						"type": "object",
+						"properties": {
+							"type": {"const": "AND"},
+							"children": {"type": "array", "minItems": 2, "items": {"$ref": "#/$defs/node"}}
+						},
+						"required": ["type", "children"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"const": "OR"},
+							"children": {"type": "array", "minItems": 2, "items": {"$ref": "#/$defs/node"}}
+						},
+						"required": ["type", "children"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["EQ"]},
+							"args": {"type": "array", "prefixItems": [{"type": "string", "pattern": "^attr\\..*$"}], "items": {"type": "string"}, "minItems": 2, "maxItems": 2}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["EQ"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["name"]}], "items": {"type": "string"}, "minItems": 2, "maxItems": 2}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["LIKE", "TEXT"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["name"]}], "items": {"type": "string"}, "minItems": 2, "maxItems": 2}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["IS_NULL"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["status", "st", "state"]}], "minItems": 1, "maxItems": 1}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["EQ"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["status", "st", "state"]}], "items": {"type": "string"}, "minItems": 2, "maxItems": 2}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["IN"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["status", "st", "state"]}], "items": {"type": "string"}, "minItems": 2}
+						},
+						"required": ["type", "args"]
+					},
+					{
+						"type": "object",
+						"properties": {
+							"type": {"enum": ["GT", "LT"]},
+							"args": {"type": "array", "prefixItems": [{"enum": ["total"]}], "items": {"type": "string", "pattern": "^[+-]?[0-9]+$"}, "minItems": 2, "maxItems": 2}
+						},
+						"required": ["type", "args"]
+					}
+				]
+			}
+		}
+	}`
+
+	// Execute SUT
+	schema := GenerateAstJsonSchema(openApiAllowedOps, openApiAliases, openApiFieldTypes)
+
+	// Verify
+	schemaJson, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(schemaJson))
+}
+
+func TestGenerateAstJsonSchemaValueFormats(t *testing.T) {
+	testCases := []struct {
+		fieldType FieldType
+		expected  string
+	}{
+		{String, `{"type": "string"}`},
+		{Int64, `{"type": "string", "pattern": "^[+-]?[0-9]+$"}`},
+		{Float64, `{"type": "string", "pattern": "^[+-]?([0-9]+(\\.[0-9]*)?|\\.[0-9]+)([eE][+-]?[0-9]+)?$"}`},
+		{Boolean, `{"type": "string", "enum": ["1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"]}`},
+		{Date, `{"type": "string", "anyOf": [{"format": "date-time"}, {"format": "date"}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fieldType.String(), func(t *testing.T) {
+			// Fixture Setup
+			allowedOps := map[string][]string{"field": {"eq"}}
+			fieldTypes := map[string]FieldType{"field": tc.fieldType}
+
+			// Execute SUT
+			schema := GenerateAstJsonSchema(allowedOps, nil, fieldTypes)
+
+			// Verify
+			node := schema["$defs"].(map[string]any)["node"].(map[string]any)["anyOf"].([]any)[2].(map[string]any)
+			items := node["properties"].(map[string]any)["args"].(map[string]any)["items"]
+
+			itemsJson, err := json.Marshal(items)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(itemsJson))
+		})
+	}
+}
+
+func TestGenerateAstJsonSchemaPatternsMatchValidateValue(t *testing.T) {
+	values := []string{"0", "42", "-7", "+3", "1.5", "-.5", "2.", "1e10", "1.5E-3", "abc", "", "1,000", "0x10", "1e", "--1", " 1"}
+
+	for _, fieldType := range []FieldType{Int64, Float64} {
+		for _, v := range values {
+			t.Run(fieldType.String()+" "+v, func(t *testing.T) {
+				// Fixture Setup
+				pattern := regexp.MustCompile(valueJsonSchema(fieldType)["pattern"].(string))
+
+				// Execute SUT
+				matched := pattern.MatchString(v)
+
+				// Verify
+				require.Equal(t, ValidateValue(fieldType, v) == nil, matched)
+			})
+		}
+	}
+}
+
+func TestGenerateAstJsonSchemaFloat64PatternOnlyDocumentsDecimals(t *testing.T) {
+	for _, v := range []string{"Inf", "-Inf", "+inf", "infinity", "NaN", "0x1p-2", "1_000", "1_000.5"} {
+		t.Run(v, func(t *testing.T) {
+			// Fixture Setup
+			pattern := regexp.MustCompile(valueJsonSchema(Float64)["pattern"].(string))
+
+			// Execute SUT
+			matched := pattern.MatchString(v)
+
+			// Verify
+			require.False(t, matched)
+			require.NoError(t, ValidateValue(Float64, v))
+		})
+	}
+}
+
+func TestGenerateAstJsonSchemaPatternsDoNotCheckRange(t *testing.T) {
+	testCases := map[FieldType][]string{
+		Int64:   {"99999999999999999999", "9223372036854775808", "-9223372036854775809"},
+		Float64: {"1e400", "-1e400"},
+	}
+
+	for fieldType, values := range testCases {
+		for _, v := range values {
+			t.Run(fieldType.String()+" "+v, func(t *testing.T) {
+				// Fixture Setup
+				pattern := regexp.MustCompile(valueJsonSchema(fieldType)["pattern"].(string))
+
+				// Execute SUT
+				matched := pattern.MatchString(v)
+
+				// Verify
+				require.True(t, matched)
+				require.Error(t, ValidateValue(fieldType, v))
+			})
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package astschema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema describes every field that can be filtered on in one place, and is used to validate filters and to configure the query builder for each backend
+// (see asthttp.ValidationFromSchema, astmongo.MongoQueryBuilderFromSchema, astmongo.AtlasSearchQueryBuilderFromSchema, astes.EsQueryBuilderFromSchema and
+// astmem.MemQueryBuilderFromSchema). This package only depends on epsearchast, so validating with a Schema doesn't require the dependencies of every backend.
+//
+// A Schema should be created with Load or LoadFile, which check that it is consistent.
+type Schema struct {
+	// AllowedIndexIntersections limits how complex OR filters can be (see epsearchast.GetEffectiveIndexIntersectionCount), if zero there is no limit.
+	AllowedIndexIntersections uint64 `json:"allowed_index_intersections,omitempty" yaml:"allowed_index_intersections,omitempty"`
+
+	// Fields are keyed by the name of the field in the filter.
+	Fields map[string]Field `json:"fields" yaml:"fields"`
+
+	fieldTypes map[string]epsearchast.FieldType
+}
+
+// Field describes a single field that can be filtered on.
+type Field struct {
+	// Description is free text for people reviewing the schema, it is not used otherwise.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Operators are the operators allowed for the field (e.g., eq, in, gt).
+	Operators []string `json:"operators" yaml:"operators"`
+
+	// Type is the name of an epsearchast.FieldType (string, int64, bool, float64 or date), if empty the field is a string.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Aliases are other names that can be used for the field in a filter.
+	Aliases []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	// Validator is a go-playground/validator rule for each value (e.g., oneof=paid pending).
+	Validator string `json:"validator,omitempty" yaml:"validator,omitempty"`
+
+	// Sql maps the field to a column, if the column has a different name.
+	Sql *SqlMapping `json:"sql,omitempty" yaml:"sql,omitempty"`
+
+	// Mongo maps the field to a path in the document for Mongo and Atlas Search, if the path is different.
+	Mongo *MongoMapping `json:"mongo,omitempty" yaml:"mongo,omitempty"`
+
+	// Es maps the field to a field in Elasticsearch (or OpenSearch), if the field is different or has multi-fields.
+	Es *EsMapping `json:"es,omitempty" yaml:"es,omitempty"`
+}
+
+// SqlMapping is how a field is stored in SQL.
+type SqlMapping struct {
+	Column string `json:"column,omitempty" yaml:"column,omitempty"`
+
+	// Array is true if the column is a Postgres array, see astgorm.DefaultGormQueryBuilder.ArrayColumns.
+	Array bool `json:"array,omitempty" yaml:"array,omitempty"`
+}
+
+// MongoMapping is how a field is stored in Mongo.
+type MongoMapping struct {
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// EmbeddedDocument is set if the path is in an array of documents indexed with the embeddedDocuments type in Atlas Search,
+	// see astmongo.DefaultAtlasSearchQueryBuilder.EmbeddedDocumentFieldToQuery. It isn't used by the Mongo query builder.
+	EmbeddedDocument *MongoEmbeddedDocument `json:"embedded_document,omitempty" yaml:"embedded_document,omitempty"`
+}
+
+// MongoEmbeddedDocument is the array of documents that a field is in.
+type MongoEmbeddedDocument struct {
+	// Path is the path of the array, the path of the field must be under it (e.g., variants for variants.sku).
+	Path string `json:"path" yaml:"path"`
+
+	// Filter are other fields under the path that the same document must be equal to (e.g., variants.kind: physical).
+	Filter map[string]string `json:"filter,omitempty" yaml:"filter,omitempty"`
+}
+
+// EsMapping is how a field is stored in Elasticsearch, see astes.DefaultEsQueryBuilder.OpTypeToFieldNames for multi-fields.
+type EsMapping struct {
+	Field       string         `json:"field,omitempty" yaml:"field,omitempty"`
+	MultiFields *EsMultiFields `json:"multi_fields,omitempty" yaml:"multi_fields,omitempty"`
+
+	// Nested is set if the field is in a nested object, see astes.DefaultEsQueryBuilder.NestedFieldToQuery.
+	Nested *EsNested `json:"nested,omitempty" yaml:"nested,omitempty"`
+
+	// Join is set if the field is in a related document, see astes.DefaultEsQueryBuilder.JoinFieldToQuery. The field can also be nested in the related document.
+	Join *EsJoin `json:"join,omitempty" yaml:"join,omitempty"`
+}
+
+// EsMultiFields is the multi-field to use for each type of operator, see astes.OperatorTypeToMultiFieldName.
+type EsMultiFields struct {
+	Equality   string `json:"equality,omitempty" yaml:"equality,omitempty"`
+	Relational string `json:"relational,omitempty" yaml:"relational,omitempty"`
+	Text       string `json:"text,omitempty" yaml:"text,omitempty"`
+	Array      string `json:"array,omitempty" yaml:"array,omitempty"`
+	Wildcard   string `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`
+	NullValue  string `json:"null_value,omitempty" yaml:"null_value,omitempty"`
+}
+
+// EsNested is the nested object that a field is in.
+type EsNested struct {
+	// Path is the path of the nested object, the field must be under it (e.g., items for items.sku).
+	Path string `json:"path" yaml:"path"`
+
+	// Filter are other fields under the path that the same nested object must be equal to (e.g., items.kind: product).
+	Filter map[string]string `json:"filter,omitempty" yaml:"filter,omitempty"`
+}
+
+// EsJoin is the related document that a field is in.
+type EsJoin struct {
+	// Type is has_child if the field is in a child document, or has_parent if it is in the parent document.
+	Type string `json:"type" yaml:"type"`
+
+	// Relation is the child relation for has_child, or the parent relation for has_parent.
+	Relation string `json:"relation" yaml:"relation"`
+}
+
+// Backend identifies a backend that fields can be mapped to.
+type Backend string
+
+const (
+	Sql   Backend = "sql"
+	Mongo Backend = "mongo"
+	Es    Backend = "es"
+)
+
+var operators = map[string]bool{
+	"eq": true, "le": true, "lt": true, "ge": true, "gt": true, "in": true, "like": true, "ilike": true,
+	"contains": true, "contains_any": true, "contains_all": true, "text": true, "is_null": true,
+}
+
+// LoadFile reads a schema from a YAML or JSON file, see Load.
+func LoadFile(name string) (*Schema, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema: %w", err)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load reads a schema in YAML or JSON, and returns an error if it has unknown keys, unknown operators or types, or conflicting aliases or mappings.
+func Load(r io.Reader) (*Schema, error) {
+	s := &Schema{}
+
+	// JSON is valid YAML, so this reads both.
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not read schema: %w", err)
+	}
+
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *Schema) check() error {
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("no fields are specified")
+	}
+
+	s.fieldTypes = map[string]epsearchast.FieldType{}
+	aliasedBy := map[string]string{}
+
+	for _, name := range s.fieldNames() {
+		field := s.Fields[name]
+
+		if len(field.Operators) == 0 {
+			return fmt.Errorf("field `%s` has no operators", name)
+		}
+
+		for _, op := range field.Operators {
+			if !operators[strings.ToLower(op)] {
+				return fmt.Errorf("field `%s` has unknown operator `%s`", name, op)
+			}
+		}
+
+		if field.Type != "" {
+			t, err := epsearchast.ParseFieldType(field.Type)
+			if err != nil {
+				return fmt.Errorf("field `%s` is invalid: %w", name, err)
+			}
+
+			s.fieldTypes[name] = t
+		}
+
+		for _, alias := range field.Aliases {
+			if _, ok := s.Fields[alias]; ok {
+				return fmt.Errorf("alias `%s` of field `%s` is also a field", alias, name)
+			}
+
+			if other, ok := aliasedBy[alias]; ok {
+				return fmt.Errorf("alias `%s` is used by both field `%s` and field `%s`", alias, other, name)
+			}
+
+			aliasedBy[alias] = name
+		}
+
+		if err := s.checkMappings(name, field); err != nil {
+			return err
+		}
+	}
+
+	for _, backend := range []Backend{Sql, Mongo, Es} {
+		mappedFrom := map[string]string{}
+
+		for _, name := range s.fieldNames() {
+			mapped := s.MappedName(name, backend)
+
+			if other, ok := mappedFrom[mapped]; ok {
+				return fmt.Errorf("fields `%s` and `%s` are both mapped to `%s` in %s", other, name, mapped, backend)
+			}
+
+			mappedFrom[mapped] = name
+		}
+	}
+
+	if _, err := epsearchast.NewValidatingVisitor(s.AllowedOps(), s.Aliases(), s.ValueValidators(), s.FieldTypes()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Schema) checkMappings(name string, field Field) error {
+	if field.Mongo != nil && field.Mongo.EmbeddedDocument != nil {
+		if err := checkSubdocument(s.MappedName(name, Mongo), field.Mongo.EmbeddedDocument.Path, field.Mongo.EmbeddedDocument.Filter); err != nil {
+			return fmt.Errorf("field `%s` has an invalid embedded document: %w", name, err)
+		}
+	}
+
+	if field.Es != nil && field.Es.Nested != nil {
+		if err := checkSubdocument(s.MappedName(name, Es), field.Es.Nested.Path, field.Es.Nested.Filter); err != nil {
+			return fmt.Errorf("field `%s` has an invalid nested object: %w", name, err)
+		}
+	}
+
+	if field.Es != nil && field.Es.Join != nil {
+		if field.Es.Join.Type != "has_child" && field.Es.Join.Type != "has_parent" {
+			return fmt.Errorf("field `%s` has unknown join type `%s`", name, field.Es.Join.Type)
+		}
+
+		if field.Es.Join.Relation == "" {
+			return fmt.Errorf("field `%s` has a join without a relation", name)
+		}
+	}
+
+	return nil
+}
+
+// checkSubdocument checks that the mapped field and filter are under the path, and that they don't use the $ templates of the query builders.
+func checkSubdocument(mapped string, path string, filter map[string]string) error {
+	if path == "" {
+		return fmt.Errorf("no path is specified")
+	}
+
+	if _, ok := filter[mapped]; ok {
+		return fmt.Errorf("the filter can't contain the field itself")
+	}
+
+	for _, f := range append([]string{mapped}, slices.Sorted(maps.Keys(filter))...) {
+		if !strings.HasPrefix(f, path+".") {
+			return fmt.Errorf("`%s` is not under the path `%s`", f, path)
+		}
+
+		if strings.Contains(f+filter[f], "$") {
+			return fmt.Errorf("`%s` can't contain a $", f)
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) fieldNames() []string {
+	return slices.Sorted(maps.Keys(s.Fields))
+}
+
+// MappedName returns the name of the field in the backend.
+func (s *Schema) MappedName(name string, backend Backend) string {
+	field := s.Fields[name]
+
+	switch {
+	case backend == Sql && field.Sql != nil && field.Sql.Column != "":
+		return field.Sql.Column
+	case backend == Mongo && field.Mongo != nil && field.Mongo.Path != "":
+		return field.Mongo.Path
+	case backend == Es && field.Es != nil && field.Es.Field != "":
+		return field.Es.Field
+	default:
+		return name
+	}
+}
+
+// AllowedOps returns the operators allowed for each field, for use with the epsearchast validation functions.
+func (s *Schema) AllowedOps() map[string][]string {
+	allowedOps := map[string][]string{}
+
+	for name, field := range s.Fields {
+		allowedOps[name] = field.Operators
+	}
+
+	return allowedOps
+}
+
+// Aliases returns a map from each alias to its field, for use with epsearchast.ApplyAliases and the epsearchast validation functions.
+func (s *Schema) Aliases() map[string]string {
+	aliases := map[string]string{}
+
+	for name, field := range s.Fields {
+		for _, alias := range field.Aliases {
+			aliases[alias] = name
+		}
+	}
+
+	return aliases
+}
+
+// ValueValidators returns the validator for each field that has one, for use with the epsearchast validation functions.
+func (s *Schema) ValueValidators() map[string]string {
+	valueValidators := map[string]string{}
+
+	for name, field := range s.Fields {
+		if field.Validator != "" {
+			valueValidators[name] = field.Validator
+		}
+	}
+
+	return valueValidators
+}
+
+// FieldTypes returns the type of each field that has one, keyed by the name of the field in the filter.
+func (s *Schema) FieldTypes() map[string]epsearchast.FieldType {
+	return maps.Clone(s.fieldTypes)
+}
+
+// Mapping returns a map from each field to its name in the backend, for fields where the names are different.
+func (s *Schema) Mapping(backend Backend) map[string]string {
+	mapping := map[string]string{}
+
+	for name := range s.Fields {
+		if mapped := s.MappedName(name, backend); mapped != name {
+			mapping[name] = mapped
+		}
+	}
+
+	return mapping
+}
+
+// Validate returns an error if the AST isn't valid for the schema, see epsearchast.ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIndexIntersections.
+func (s *Schema) Validate(ast *epsearchast.AstNode) error {
+	return epsearchast.ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIndexIntersections(ast, s.AllowedOps(), s.Aliases(), s.ValueValidators(), s.FieldTypes(), s.AllowedIndexIntersections)
+}
+
+// ApplyAliases returns a new AST where aliases have been replaced with their field, it should be called after Validate.
+func (s *Schema) ApplyAliases(ast *epsearchast.AstNode) (*epsearchast.AstNode, error) {
+	return epsearchast.ApplyAliases(ast, s.Aliases())
+}
+
+// ApplyMapping returns a new AST where fields have been replaced with their name in the backend, it should be called after ApplyAliases,
+// and the result used with the query builder for the backend.
+func (s *Schema) ApplyMapping(ast *epsearchast.AstNode, backend Backend) (*epsearchast.AstNode, error) {
+	return epsearchast.ApplyAliases(ast, s.Mapping(backend))
+}
+
+// OpenApiFilterParameter returns an OpenAPI 3.1 parameter object describing the filter query parameter, see epsearchast.GenerateOpenApiFilterParameter.
+func (s *Schema) OpenApiFilterParameter() map[string]any {
+	return epsearchast.GenerateOpenApiFilterParameter(s.AllowedOps(), s.Aliases(), s.FieldTypes())
+}
+
+// AstJsonSchema returns a JSON Schema for the header, see epsearchast.GenerateAstJsonSchema.
+func (s *Schema) AstJsonSchema() map[string]any {
+	return epsearchast.GenerateAstJsonSchema(s.AllowedOps(), s.Aliases(), s.FieldTypes())
+}
+
+// MappedFieldTypes returns the field types keyed by the name of the field in the backend, for the query builder of the backend.
+func (s *Schema) MappedFieldTypes(backend Backend) map[string]epsearchast.FieldType {
+	fieldTypes := map[string]epsearchast.FieldType{}
+
+	for name, t := range s.fieldTypes {
+		fieldTypes[s.MappedName(name, backend)] = t
+	}
+
+	return fieldTypes
+}
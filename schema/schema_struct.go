@@ -0,0 +1,247 @@
+package astschema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+)
+
+// TagName is the struct tag read by FromStruct.
+const TagName = "epsearch"
+
+// FromStruct derives a Schema from the epsearch tags of a struct (or pointer to one), for example:
+//
+//	type Order struct {
+//		Status  string    `json:"status" gorm:"column:order_status" epsearch:"ops=eq|in,alias=state,validator=oneof=paid pending"`
+//		Total   int64     `json:"total" epsearch:"ops=gt|lt"`
+//		Created time.Time `json:"created_at" bson:"created" epsearch:"ops=gt|lt"`
+//		Contact Contact   `json:"contact"`
+//	}
+//
+// The tag is a comma separated list of keys: name (defaults to the json name, or the name of the Go field), ops and alias (separated by |),
+// type (defaults to the type inferred from the Go type, see below), and validator, which must be last as the rule can contain commas.
+//
+// Fields without the tag are not filterable, except that nested structs are recursed into with a dotted name (e.g., contact.email), and embedded structs
+// are promoted (a struct isn't recursed into again inside itself). Fields tagged json:"-" are never serialized, so they are skipped unless the tag sets a name.
+// The type is inferred as int64 for integers, bool for booleans, float64 for floats, date for time.Time and string otherwise (pointers,
+// slices and arrays use the type of their element). A uint64 (or uint) can be larger than an int64, so the type must be set for those.
+//
+// If a field has a bson name that's different from its name (including in nested structs) it's used as the Mongo path, and if a field that isn't
+// nested has a gorm column it's used as the SQL column.
+func FromStruct[T any]() (*Schema, error) {
+	t := reflect.TypeFor[T]()
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported type %s, must be a struct", t)
+	}
+
+	s := &Schema{Fields: map[string]Field{}}
+
+	if err := s.addStructFields(t, "", "", true, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	return s, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (s *Schema) addStructFields(t reflect.Type, prefix string, bsonPrefix string, topLevel bool, seen map[reflect.Type]bool) error {
+	// Models often refer to each other (e.g., an order has a customer which has orders), so a type isn't expanded again inside itself.
+	if seen[t] {
+		return nil
+	}
+
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+
+		tag, hasTag := sf.Tag.Lookup(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		opts, err := parseTag(tag)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag on field %s of %s: %w", TagName, sf.Name, t, err)
+		}
+
+		if opts.name == "" && sf.Tag.Get("json") == "-" {
+			continue
+		}
+
+		name := opts.name
+		if name == "" {
+			name = tagValueName(sf, "json")
+		}
+
+		bsonName := tagValueName(sf, "bson")
+		if _, ok := sf.Tag.Lookup("bson"); !ok {
+			bsonName = name
+		}
+
+		elem := elementType(sf.Type)
+
+		if elem.Kind() == reflect.Struct && elem != timeType {
+			if len(opts.ops) > 0 {
+				return fmt.Errorf("invalid %s tag on field %s of %s: nested structs can't be filtered on, tag their fields instead", TagName, sf.Name, t)
+			}
+
+			if sf.Anonymous && opts.name == "" {
+				if err := s.addStructFields(elem, prefix, bsonPrefix, topLevel, seen); err != nil {
+					return err
+				}
+			} else if err := s.addStructFields(elem, prefix+name+".", bsonPrefix+bsonName+".", false, seen); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !hasTag {
+			continue
+		}
+
+		fullName := prefix + name
+		if _, ok := s.Fields[fullName]; ok {
+			return fmt.Errorf("field %s of %s has the name `%s` which is already used", sf.Name, t, fullName)
+		}
+
+		fieldType := opts.fieldType
+		if fieldType == "" {
+			inferred, err := inferFieldType(elem)
+			if err != nil {
+				return fmt.Errorf("invalid %s tag on field %s of %s: %w", TagName, sf.Name, t, err)
+			}
+
+			fieldType = inferred.String()
+		}
+
+		field := Field{
+			Operators: opts.ops,
+			Aliases:   opts.aliases,
+			Validator: opts.validator,
+		}
+
+		if fieldType != epsearchast.String.String() {
+			field.Type = fieldType
+		}
+
+		if path := bsonPrefix + bsonName; path != fullName {
+			field.Mongo = &MongoMapping{Path: path}
+		}
+
+		if column := gormColumn(sf); topLevel && column != "" && column != fullName {
+			field.Sql = &SqlMapping{Column: column}
+		}
+
+		s.Fields[fullName] = field
+	}
+
+	return nil
+}
+
+type tagOptions struct {
+	name      string
+	ops       []string
+	aliases   []string
+	fieldType string
+	validator string
+}
+
+func parseTag(tag string) (tagOptions, error) {
+	opts := tagOptions{}
+
+	for tag != "" {
+		part, rest, _ := strings.Cut(tag, ",")
+		key, value, _ := strings.Cut(part, "=")
+
+		switch key {
+		case "name":
+			opts.name = value
+		case "ops":
+			opts.ops = strings.Split(value, "|")
+		case "alias":
+			opts.aliases = strings.Split(value, "|")
+		case "type":
+			opts.fieldType = value
+		case "validator":
+			// Validator rules can contain commas, so the validator is the rest of the tag.
+			_, opts.validator, _ = strings.Cut(tag, "=")
+			rest = ""
+		default:
+			return opts, fmt.Errorf("unknown key `%s`", key)
+		}
+
+		tag = rest
+	}
+
+	return opts, nil
+}
+
+// tagValueName returns the name in a json or bson style tag, or the name of the field if there isn't one.
+func tagValueName(sf reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(sf.Tag.Get(key), ",")
+
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+
+	return name
+}
+
+func gormColumn(sf reflect.StructField) string {
+	for _, setting := range strings.Split(sf.Tag.Get("gorm"), ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(setting), ":"); ok && strings.EqualFold(k, "column") {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// elementType returns the type of the values in a field, after removing pointers, slices and arrays (except []byte which is a string).
+func elementType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || ((t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8) {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func inferFieldType(t reflect.Type) (epsearchast.FieldType, error) {
+	if t == timeType {
+		return epsearchast.Date, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return epsearchast.Int64, nil
+	case reflect.Uint, reflect.Uint64:
+		// Values above math.MaxInt64 would pass validation as an int64 but overflow when they are converted.
+		return epsearchast.String, fmt.Errorf("the type can't be inferred for %s as values can be larger than an int64, set the type explicitly", t)
+	case reflect.Bool:
+		return epsearchast.Boolean, nil
+	case reflect.Float32, reflect.Float64:
+		return epsearchast.Float64, nil
+	default:
+		return epsearchast.String, nil
+	}
+}
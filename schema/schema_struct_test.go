@@ -0,0 +1,218 @@
+package astschema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at" bson:"created" epsearch:"ops=gt|lt"`
+	CreatedBy string    `json:"created_by"`
+}
+
+type Contact struct {
+	Email    string   `json:"email" bson:"email_address" epsearch:"ops=eq|like"`
+	Verified *bool    `json:"verified" epsearch:"ops=eq"`
+	Customer Customer `json:"customer"`
+}
+
+type Customer struct {
+	Name   string  `json:"name" epsearch:"ops=eq"`
+	Orders []Order `json:"orders"`
+}
+
+type Order struct {
+	Audit
+	Id       string            `json:"id" gorm:"primaryKey;column:order_id" epsearch:"ops=eq|in"`
+	Status   string            `json:"status" epsearch:"ops=eq|in,alias=state|order_status,validator=oneof=paid pending,required"`
+	Total    int64             `json:"total" epsearch:"ops=gt|lt|is_null"`
+	Quantity uint16            `json:"quantity" epsearch:"ops=eq"`
+	Discount float32           `json:"discount" epsearch:"ops=gt"`
+	Paid     bool              `json:"paid" epsearch:"ops=eq"`
+	Tags     []string          `json:"tags" epsearch:"ops=contains|contains_all"`
+	Scores   []float64         `json:"scores" epsearch:"name=score,ops=gt"`
+	Code     []byte            `json:"code" epsearch:"ops=eq"`
+	Sku      string            `epsearch:"ops=eq|like,type=int64"`
+	Contact  *Contact          `json:"contact" bson:"contact_info"`
+	Notes    string            `json:"notes"`
+	Ignored  string            `json:"ignored" epsearch:"-"`
+	Secret   string            `json:"-" epsearch:"ops=eq"`
+	Internal string            `json:"-" epsearch:"name=internal_ref,ops=eq"`
+	Serial   uint64            `json:"serial" epsearch:"ops=eq,type=string"`
+	Meta     map[string]string `json:"meta"`
+	internal string            `epsearch:"ops=eq"`
+}
+
+func TestFromStructDerivesFields(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	s, err := FromStruct[*Order]()
+
+	// Verification
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]string{
+		"created_at":            {"gt", "lt"},
+		"id":                    {"eq", "in"},
+		"status":                {"eq", "in"},
+		"total":                 {"gt", "lt", "is_null"},
+		"quantity":              {"eq"},
+		"discount":              {"gt"},
+		"paid":                  {"eq"},
+		"tags":                  {"contains", "contains_all"},
+		"score":                 {"gt"},
+		"code":                  {"eq"},
+		"Sku":                   {"eq", "like"},
+		"contact.email":         {"eq", "like"},
+		"contact.verified":      {"eq"},
+		"contact.customer.name": {"eq"},
+		"internal_ref":          {"eq"},
+		"serial":                {"eq"},
+	}, s.AllowedOps())
+
+	require.Equal(t, map[string]string{"state": "status", "order_status": "status"}, s.Aliases())
+	require.Equal(t, map[string]string{"status": "oneof=paid pending,required"}, s.ValueValidators())
+
+	require.Equal(t, map[string]epsearchast.FieldType{
+		"created_at":       epsearchast.Date,
+		"total":            epsearchast.Int64,
+		"quantity":         epsearchast.Int64,
+		"discount":         epsearchast.Float64,
+		"paid":             epsearchast.Boolean,
+		"score":            epsearchast.Float64,
+		"Sku":              epsearchast.Int64,
+		"contact.verified": epsearchast.Boolean,
+	}, s.FieldTypes())
+
+	require.Equal(t, map[string]string{"id": "order_id"}, s.Mapping(Sql))
+	require.Equal(t, map[string]string{
+		"created_at":            "created",
+		"contact.email":         "contact_info.email_address",
+		"contact.verified":      "contact_info.verified",
+		"contact.customer.name": "contact_info.customer.name",
+	}, s.Mapping(Mongo))
+	require.Empty(t, s.Mapping(Es))
+}
+
+func TestFromStructConfiguresValidationAndMongo(t *testing.T) {
+	// Fixture Setup
+	s, err := FromStruct[Order]()
+	require.NoError(t, err)
+
+	ast, err := epsearchast.ParseFilter(`eq(state,paid):gt(created_at,2024-01-01)`)
+	require.NoError(t, err)
+
+	// Execute SUT
+	err = epsearchast.ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypes(ast, s.AllowedOps(), s.Aliases(), s.ValueValidators(), s.FieldTypes())
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, map[string]epsearchast.FieldType{
+		"created":               epsearchast.Date,
+		"total":                 epsearchast.Int64,
+		"quantity":              epsearchast.Int64,
+		"discount":              epsearchast.Float64,
+		"paid":                  epsearchast.Boolean,
+		"score":                 epsearchast.Float64,
+		"Sku":                   epsearchast.Int64,
+		"contact_info.verified": epsearchast.Boolean,
+	}, s.MappedFieldTypes(Mongo))
+}
+
+func TestFromStructReturnsErrors(t *testing.T) {
+	type unknownKey struct {
+		Status string `epsearch:"ops=eq,sortable"`
+	}
+
+	type unknownOperator struct {
+		Status string `epsearch:"ops=eq|regex"`
+	}
+
+	type unknownType struct {
+		Status string `epsearch:"ops=eq,type=money"`
+	}
+
+	type filteredStruct struct {
+		Contact Contact `epsearch:"ops=eq"`
+	}
+
+	type duplicateName struct {
+		Status string `json:"status" epsearch:"ops=eq"`
+		State  string `epsearch:"name=status,ops=eq"`
+	}
+
+	type noOperators struct {
+		Status string `epsearch:"name=status"`
+	}
+
+	type unsignedInt64 struct {
+		Count uint64 `json:"count" epsearch:"ops=gt"`
+	}
+
+	type unsignedInt struct {
+		Count []uint `json:"count" epsearch:"ops=gt"`
+	}
+
+	testCases := map[string]struct {
+		fromStruct func() (*Schema, error)
+		expected   string
+	}{
+		"not a struct": {
+			fromStruct: FromStruct[map[string]any],
+			expected:   "unsupported type map[string]interface {}, must be a struct",
+		},
+		"unknown key": {
+			fromStruct: FromStruct[unknownKey],
+			expected:   "invalid epsearch tag on field Status of astschema.unknownKey: unknown key `sortable`",
+		},
+		"unknown operator": {
+			fromStruct: FromStruct[unknownOperator],
+			expected:   "invalid schema: field `Status` has unknown operator `regex`",
+		},
+		"unknown type": {
+			fromStruct: FromStruct[unknownType],
+			expected:   "invalid schema: field `Status` is invalid: unknown field type `money`, must be one of string, int64, bool, float64 or date",
+		},
+		"filtered struct": {
+			fromStruct: FromStruct[filteredStruct],
+			expected:   "invalid epsearch tag on field Contact of astschema.filteredStruct: nested structs can't be filtered on, tag their fields instead",
+		},
+		"duplicate name": {
+			fromStruct: FromStruct[duplicateName],
+			expected:   "field State of astschema.duplicateName has the name `status` which is already used",
+		},
+		"no operators": {
+			fromStruct: FromStruct[noOperators],
+			expected:   "invalid schema: field `status` has no operators",
+		},
+		"uint64": {
+			fromStruct: FromStruct[unsignedInt64],
+			expected:   "invalid epsearch tag on field Count of astschema.unsignedInt64: the type can't be inferred for uint64 as values can be larger than an int64, set the type explicitly",
+		},
+		"uint": {
+			fromStruct: FromStruct[unsignedInt],
+			expected:   "invalid epsearch tag on field Count of astschema.unsignedInt: the type can't be inferred for uint as values can be larger than an int64, set the type explicitly",
+		},
+		"no fields": {
+			fromStruct: FromStruct[struct{ Name string }],
+			expected:   "invalid schema: no fields are specified",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			s, err := tc.fromStruct()
+
+			// Verification
+			require.EqualError(t, err, tc.expected)
+			require.Nil(t, s)
+		})
+	}
+}
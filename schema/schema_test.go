@@ -0,0 +1,262 @@
+package astschema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elasticpath/epcc-search-ast-helper"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersSchema = `
+allowed_index_intersections: 4
+fields:
+  status:
+    description: The status of the order.
+    operators: [eq, in]
+    aliases: [state]
+    validator: oneof=paid pending
+    sql:
+      column: order_status
+    mongo:
+      path: attributes.status
+  total:
+    operators: [gt, lt, is_null]
+    type: int64
+    es:
+      field: meta.total
+      multi_fields:
+        relational: meta.total.numeric
+  name:
+    operators: [eq, like]
+    es:
+      multi_fields:
+        equality: name.keyword
+        wildcard: name.wildcard
+`
+
+func loadOrdersSchema(t *testing.T) *Schema {
+	s, err := Load(strings.NewReader(ordersSchema))
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestLoadReadsValidationConfiguration(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	s := loadOrdersSchema(t)
+
+	// Verification
+	require.Equal(t, uint64(4), s.AllowedIndexIntersections)
+	require.Equal(t, "The status of the order.", s.Fields["status"].Description)
+	require.Equal(t, map[string][]string{"status": {"eq", "in"}, "total": {"gt", "lt", "is_null"}, "name": {"eq", "like"}}, s.AllowedOps())
+	require.Equal(t, map[string]string{"state": "status"}, s.Aliases())
+	require.Equal(t, map[string]string{"status": "oneof=paid pending"}, s.ValueValidators())
+	require.Equal(t, map[string]epsearchast.FieldType{"total": epsearchast.Int64}, s.FieldTypes())
+	require.Equal(t, map[string]string{"status": "order_status"}, s.Mapping(Sql))
+	require.Equal(t, map[string]string{"status": "attributes.status"}, s.Mapping(Mongo))
+	require.Equal(t, map[string]string{"total": "meta.total"}, s.Mapping(Es))
+	require.Equal(t, map[string]epsearchast.FieldType{"meta.total": epsearchast.Int64}, s.MappedFieldTypes(Es))
+	require.Equal(t, "order_status", s.MappedName("status", Sql))
+	require.Equal(t, "name", s.MappedName("name", Es))
+}
+
+func TestLoadFileReadsJson(t *testing.T) {
+	// Fixture Setup
+	//language=JSON
+	jsonSchema := `{
+		"fields": {
+			"status": {"operators": ["eq"], "aliases": ["state"], "sql": {"column": "order_status"}},
+			"total": {"operators": ["gt"], "type": "float64"}
+		}
+	}`
+
+	name := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(name, []byte(jsonSchema), 0600))
+
+	// Execute SUT
+	s, err := LoadFile(name)
+
+	// Verification
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"status": {"eq"}, "total": {"gt"}}, s.AllowedOps())
+	require.Equal(t, map[string]string{"state": "status"}, s.Aliases())
+	require.Equal(t, map[string]epsearchast.FieldType{"total": epsearchast.Float64}, s.FieldTypes())
+	require.Equal(t, map[string]string{"status": "order_status"}, s.Mapping(Sql))
+}
+
+func TestSchemaValidatesFilters(t *testing.T) {
+	testCases := map[string]string{
+		`eq(state,paid)`:         "",
+		`gt(total,5)`:            "",
+		`eq(state,draft)`:        "error validating filter: could not validate [state] with [eq], value [draft] does not satisfy requirement [oneof]",
+		`gt(total,cheap)`:        "error validating filter: could not validate [total], the value [cheap] could not be converted to int64: invalid value for int64: `cheap`",
+		`like(status,p*)`:        "error validating filter: unknown operator [like] specified in search filter for field [status], allowed operators are [eq in]",
+		`eq(description,shirts)`: "error validating filter: unknown field [description] specified in search filter, allowed fields are [name status total]",
+	}
+
+	s := loadOrdersSchema(t)
+
+	for filter, expectedErr := range testCases {
+		t.Run(filter, func(t *testing.T) {
+			// Fixture Setup
+			ast, err := epsearchast.ParseFilter(filter)
+			require.NoError(t, err)
+
+			// Execute SUT
+			err = s.Validate(ast)
+
+			// Verification
+			if expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, expectedErr)
+			}
+		})
+	}
+}
+
+func TestSchemaAppliesMappingForEachBackend(t *testing.T) {
+	testCases := map[Backend]string{
+		Sql:   `eq("order_status","paid"):gt("total","5"):like("name","Red*")`,
+		Mongo: `eq("attributes.status","paid"):gt("total","5"):like("name","Red*")`,
+		Es:    `eq("status","paid"):gt("meta.total","5"):like("name","Red*")`,
+	}
+
+	for backend, expected := range testCases {
+		t.Run(string(backend), func(t *testing.T) {
+			// Fixture Setup
+			s := loadOrdersSchema(t)
+
+			ast, err := epsearchast.ParseFilter(`eq(state,paid):gt(total,5):like(name,Red*)`)
+			require.NoError(t, err)
+			require.NoError(t, s.Validate(ast))
+
+			ast, err = s.ApplyAliases(ast)
+			require.NoError(t, err)
+
+			// Execute SUT
+			mappedAst, err := s.ApplyMapping(ast, backend)
+
+			// Verification
+			require.NoError(t, err)
+			require.Equal(t, expected, mappedAst.AsFilter())
+		})
+	}
+}
+
+func TestLoadReturnsErrorForInvalidSchema(t *testing.T) {
+	testCases := map[string]struct {
+		schema   string
+		expected string
+	}{
+		"empty": {
+			schema:   ``,
+			expected: "invalid schema: no fields are specified",
+		},
+		"unknown key": {
+			schema:   "fields:\n  status:\n    operators: [eq]\n    column: order_status\n",
+			expected: "could not read schema: yaml: unmarshal errors:\n  line 4: field column not found in type astschema.Field",
+		},
+		"no operators": {
+			schema:   "fields:\n  status:\n    type: string\n",
+			expected: "invalid schema: field `status` has no operators",
+		},
+		"unknown operator": {
+			schema:   "fields:\n  status:\n    operators: [eq, regex]\n",
+			expected: "invalid schema: field `status` has unknown operator `regex`",
+		},
+		"unknown type": {
+			schema:   "fields:\n  total:\n    operators: [eq]\n    type: money\n",
+			expected: "invalid schema: field `total` is invalid: unknown field type `money`, must be one of string, int64, bool, float64 or date",
+		},
+		"alias is a field": {
+			schema:   "fields:\n  status:\n    operators: [eq]\n    aliases: [state]\n  state:\n    operators: [eq]\n",
+			expected: "invalid schema: alias `state` of field `status` is also a field",
+		},
+		"alias is used twice": {
+			schema:   "fields:\n  a:\n    operators: [eq]\n    aliases: [c]\n  b:\n    operators: [eq]\n    aliases: [c]\n",
+			expected: "invalid schema: alias `c` is used by both field `a` and field `b`",
+		},
+		"mapped to the same column": {
+			schema:   "fields:\n  a:\n    operators: [eq]\n    sql:\n      column: c\n  b:\n    operators: [eq]\n    sql:\n      column: c\n",
+			expected: "invalid schema: fields `a` and `b` are both mapped to `c` in sql",
+		},
+		"mapped to another field": {
+			schema:   "fields:\n  a:\n    operators: [eq]\n    mongo:\n      path: b\n  b:\n    operators: [eq]\n",
+			expected: "invalid schema: fields `a` and `b` are both mapped to `b` in mongo",
+		},
+		"nested field is not under the path": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    es:\n      nested:\n        path: items\n",
+			expected: "invalid schema: field `sku` has an invalid nested object: `sku` is not under the path `items`",
+		},
+		"nested filter is not under the path": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    es:\n      field: items.sku\n      nested:\n        path: items\n        filter:\n          kind: product\n",
+			expected: "invalid schema: field `sku` has an invalid nested object: `kind` is not under the path `items`",
+		},
+		"nested filter contains the field": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    es:\n      field: items.sku\n      nested:\n        path: items\n        filter:\n          items.sku: a\n",
+			expected: "invalid schema: field `sku` has an invalid nested object: the filter can't contain the field itself",
+		},
+		"embedded document without a path": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    mongo:\n      path: variants.sku\n      embedded_document:\n        filter:\n          variants.kind: physical\n",
+			expected: "invalid schema: field `sku` has an invalid embedded document: no path is specified",
+		},
+		"embedded document filter with a template": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    mongo:\n      path: variants.sku\n      embedded_document:\n        path: variants\n        filter:\n          variants.kind: $value\n",
+			expected: "invalid schema: field `sku` has an invalid embedded document: `variants.kind` can't contain a $",
+		},
+		"unknown join type": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    es:\n      join:\n        type: has_sibling\n        relation: sku\n",
+			expected: "invalid schema: field `sku` has unknown join type `has_sibling`",
+		},
+		"join without a relation": {
+			schema:   "fields:\n  sku:\n    operators: [eq]\n    es:\n      join:\n        type: has_child\n",
+			expected: "invalid schema: field `sku` has a join without a relation",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			s, err := Load(strings.NewReader(tc.schema))
+
+			// Verification
+			require.EqualError(t, err, tc.expected)
+			require.Nil(t, s)
+		})
+	}
+}
+
+func TestLoadFileReturnsErrorForMissingFile(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	_, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	// Verification
+	require.ErrorContains(t, err, "could not read schema")
+}
+
+func TestSchemaGeneratesDocumentation(t *testing.T) {
+	// Fixture Setup
+	s := loadOrdersSchema(t)
+
+	// Execute SUT
+	parameter := s.OpenApiFilterParameter()
+	schema := s.AstJsonSchema()
+
+	// Verification
+	require.Equal(t, epsearchast.GenerateOpenApiFilterParameter(s.AllowedOps(), s.Aliases(), s.FieldTypes()), parameter)
+	require.Equal(t, epsearchast.GenerateAstJsonSchema(s.AllowedOps(), s.Aliases(), s.FieldTypes()), schema)
+
+	fields := parameter["x-filterable-fields"].(map[string]any)
+	require.Equal(t, []string{"state"}, fields["status"].(map[string]any)["aliases"])
+	require.Equal(t, "int64", fields["total"].(map[string]any)["field_type"])
+}
@@ -3,6 +3,7 @@ package epsearchast
 import (
 	"fmt"
 	"strconv"
+	"time"
 )
 
 type FieldType int
@@ -12,6 +13,8 @@ const (
 	Int64
 	Boolean
 	Float64
+	// Date values must be formatted as RFC 3339 (e.g., 2024-01-01T00:00:00Z), or as a date (e.g., 2024-01-01) which is treated as midnight UTC.
+	Date
 )
 
 func (f FieldType) String() string {
@@ -24,11 +27,24 @@ func (f FieldType) String() string {
 		return "bool"
 	case Float64:
 		return "float64"
+	case Date:
+		return "date"
 	default:
 		return "unknown"
 	}
 }
 
+// ParseFieldType returns the FieldType with the given name (i.e., the value returned by String()), which is useful for reading field types from configuration.
+func ParseFieldType(name string) (FieldType, error) {
+	for _, t := range []FieldType{String, Int64, Boolean, Float64, Date} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return String, fmt.Errorf("unknown field type `%s`, must be one of string, int64, bool, float64 or date", name)
+}
+
 func Convert(t FieldType, v string) (interface{}, error) {
 
 	err := ValidateValue(t, v)
@@ -46,6 +62,8 @@ func Convert(t FieldType, v string) (interface{}, error) {
 		newV, _ = strconv.ParseBool(v)
 	case Float64:
 		newV, _ = strconv.ParseFloat(v, 64)
+	case Date:
+		newV, _ = parseDate(v)
 	}
 
 	return newV, nil
@@ -92,11 +110,25 @@ func ValidateValue(t FieldType, v string) error {
 			return fmt.Errorf("invalid value for boolean: `%v`", v)
 		}
 		return nil
+	case Date:
+		_, e := parseDate(v)
+		if e != nil {
+			return fmt.Errorf("invalid value for date: `%v`", v)
+		}
+		return nil
 	default:
 		return fmt.Errorf("Unsupported field type %v:%v", t, v)
 	}
 }
 
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, v)
+}
+
 func ValidateAllValues(t FieldType, v ...string) error {
 	for idx, value := range v {
 		err := ValidateValue(t, value)
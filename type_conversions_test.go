@@ -0,0 +1,32 @@
+package epsearchast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldTypeIsTheInverseOfString(t *testing.T) {
+	for _, fieldType := range []FieldType{String, Int64, Boolean, Float64, Date} {
+		t.Run(fieldType.String(), func(t *testing.T) {
+			// Fixture Setup
+
+			// Execute SUT
+			parsed, err := ParseFieldType(fieldType.String())
+
+			// Verify
+			require.NoError(t, err)
+			require.Equal(t, fieldType, parsed)
+		})
+	}
+}
+
+func TestParseFieldTypeReturnsErrorForUnknownType(t *testing.T) {
+	// Fixture Setup
+
+	// Execute SUT
+	_, err := ParseFieldType("money")
+
+	// Verify
+	require.EqualError(t, err, "unknown field type `money`, must be one of string, int64, bool, float64 or date")
+}
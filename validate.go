@@ -65,14 +65,14 @@ func ValidateAstFieldAndOperatorsWithAliasesAndValueValidationAndFieldTypesAndIn
 		}
 
 		if effectiveIndexIntersections > allowedIndexIntersections {
-			return NewValidationErr(fmt.Errorf("filter is too complex and has too many OR conditions %d vs allowed %d", effectiveIndexIntersections, allowedIndexIntersections))
+			return newValidationErrForAst(astNode, fmt.Errorf("filter is too complex and has too many OR conditions %d vs allowed %d", effectiveIndexIntersections, allowedIndexIntersections))
 		}
 	}
 
 	err = astNode.Accept(visitor)
 
 	if err != nil {
-		return NewValidationErr(err)
+		return newValidationErrForAst(astNode, err)
 	}
 
 	return nil
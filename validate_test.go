@@ -1245,6 +1245,46 @@ func TestValidateAstWithTypeValidationReturnsErrorWhenRequestIsNotValidAsFloat64
 	require.ErrorContains(t, err, "invalid value for float64")
 }
 
+func TestValidateAstWithTypeValidationReturnsErrorWhenRequestIsNotValidAsDate(t *testing.T) {
+	// Fixture Setup
+	// language=JSON
+	jsonTxt := `
+			{
+				"type": "GT",
+				"args": [ "created_at",  "yesterday"]
+			}
+			`
+	ast, err := GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	err = ValidateAstFieldAndOperatorsWithFieldTypes(ast, map[string][]string{"created_at": {"gt"}}, map[string]FieldType{"created_at": Date})
+
+	// Verification
+	require.ErrorContains(t, err, "could not validate [created_at]")
+	require.ErrorContains(t, err, "the value [yesterday]")
+	require.ErrorContains(t, err, "invalid value for date")
+}
+
+func TestValidateAstWithTypeValidationReturnsNoErrorWhenRequestIsValidAsDate(t *testing.T) {
+	// Fixture Setup
+	// language=JSON
+	jsonTxt := `
+			{
+				"type": "IN",
+				"args": [ "created_at",  "2024-01-01T12:30:00Z", "2024-01-01", "2024-01-01T12:30:00.123+02:00"]
+			}
+			`
+	ast, err := GetAst(jsonTxt)
+	require.NoError(t, err)
+
+	// Execute SUT
+	err = ValidateAstFieldAndOperatorsWithFieldTypes(ast, map[string][]string{"created_at": {"in"}}, map[string]FieldType{"created_at": Date})
+
+	// Verification
+	require.NoError(t, err)
+}
+
 func TestValidateAstWithTypeValidationReturnsNoErrorWhenRequestIsValidAsFloat64AndIntegerAndPassesValidator(t *testing.T) {
 	// Fixture Setup
 	// language=JSON
@@ -29,7 +29,7 @@ var _ AstVisitor = (*validatingVisitor)(nil)
 func NewValidatingVisitor(allowedOps map[string][]string, aliases map[string]string, valueValidators map[string]string, fieldTypeMap map[string]FieldType) (AstVisitor, error) {
 
 	for k, v := range aliases {
-		if len(k) > 0 && k[0] == '^' && k[len(k)-1] == '$' {
+		if isRegex(k) {
 			// We can't validate regular expression based aliases without being too rigid, and having a lot of validation complexity for an edge case.
 			// For example, you could declare an alias of `t.(a|b)` to `$1` (i.e., a or b) and then specify validators on just a or b.
 			continue
@@ -41,7 +41,7 @@ func NewValidatingVisitor(allowedOps map[string][]string, aliases map[string]str
 	}
 
 	for k, v := range valueValidators {
-		if len(k) > 0 && k[0] == '^' && k[len(k)-1] == '$' {
+		if isRegex(k) {
 			// We can't validate regular expression based aliases without being too rigid, and having a lot of validation complexity for an edge case.
 			// For example, you could declare an alias of `t.(a|b)` to `$1` (i.e., a or b) and then specify validators on just a or b.
 			continue
@@ -110,7 +110,7 @@ func (v *validatingVisitor) VisitIn(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("in", fieldName, astNode.Args[1:]...); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -120,7 +120,7 @@ func (v *validatingVisitor) VisitEq(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("eq", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -130,7 +130,7 @@ func (v *validatingVisitor) VisitLe(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("le", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -140,7 +140,7 @@ func (v *validatingVisitor) VisitLt(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("lt", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -150,7 +150,7 @@ func (v *validatingVisitor) VisitGe(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("ge", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -160,7 +160,7 @@ func (v *validatingVisitor) VisitGt(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("gt", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -170,7 +170,7 @@ func (v *validatingVisitor) VisitLike(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("like", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -180,7 +180,7 @@ func (v *validatingVisitor) VisitILike(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("ilike", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -190,7 +190,7 @@ func (v *validatingVisitor) VisitContains(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("contains", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -200,7 +200,7 @@ func (v *validatingVisitor) VisitContainsAny(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("contains_any", fieldName, astNode.Args[1:]...); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -210,7 +210,7 @@ func (v *validatingVisitor) VisitContainsAll(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("contains_all", fieldName, astNode.Args[1:]...); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -220,7 +220,7 @@ func (v *validatingVisitor) VisitText(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("text", fieldName, astNode.Args[1]); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -230,7 +230,7 @@ func (v *validatingVisitor) VisitIsNull(astNode *AstNode) (bool, error) {
 	fieldName := astNode.Args[0]
 
 	if err := v.validateFieldAndValue("is_null", fieldName); err != nil {
-		return false, err
+		return false, newNodeErr(astNode, err)
 	}
 
 	return false, nil
@@ -316,7 +316,7 @@ func (v *validatingVisitor) resolveFieldName(requestField string) string {
 		canonicalField = realName
 	} else {
 		for k, v := range v.ColumnAliases {
-			if len(k) > 0 && k[0] == '^' && k[len(k)-1] == '$' {
+			if isRegex(k) {
 				r := regexp.MustCompile(k)
 				canonicalField = string(r.ReplaceAll([]byte(canonicalField), []byte(v)))
 			}
@@ -326,6 +326,11 @@ func (v *validatingVisitor) resolveFieldName(requestField string) string {
 	return canonicalField
 }
 
+// isRegex returns whether a key (e.g., of aliases or allowed ops) is a regular expression rather than a field, which is the case if it is anchored at both ends.
+func isRegex(s string) bool {
+	return len(s) > 0 && s[0] == '^' && s[len(s)-1] == '$'
+}
+
 func findMatchInMap[T any](key string, m map[string]T) (T, bool) {
 
 	if v, ok := m[key]; ok {
@@ -333,7 +338,7 @@ func findMatchInMap[T any](key string, m map[string]T) (T, bool) {
 	}
 
 	for k, v := range m {
-		if len(k) > 0 && k[0] == '^' && k[len(k)-1] == '$' {
+		if isRegex(k) {
 			r := regexp.MustCompile(k)
 			if r.MatchString(key) {
 				return v, true